@@ -6,6 +6,7 @@ package lru_test
 import (
 	"fmt"
 	"math/rand"
+	"time"
 
 	gc "gopkg.in/check.v1"
 
@@ -196,8 +197,8 @@ func (*BenchmarkLRUSuite) BenchmarkGet2000000(c *gc.C) {
 	benchGet(c, 2000000)
 }
 
-func benchGet(c *gc.C, size int) {
-	cache := lru.New(size)
+func benchGet(c *gc.C, size int, options ...lru.Option) {
+	cache := lru.New(size, options...)
 	lookups := make([]int, size)
 	// Fill the cache:
 	for i := 0; i < size; i++ {
@@ -297,3 +298,121 @@ func benchAddAndEvictStr(c *gc.C, size int) {
 	c.Assert(cache.Len(), gc.Equals, expectLen)
 
 }
+
+func (*BenchmarkLRUSuite) BenchmarkTypedAddAndEvictInt0001000(c *gc.C) {
+	benchTypedAddAndEvictInt(c, 1000)
+}
+
+func (*BenchmarkLRUSuite) BenchmarkTypedAddAndEvictInt0100000(c *gc.C) {
+	benchTypedAddAndEvictInt(c, 100000)
+}
+
+func benchTypedAddAndEvictInt(c *gc.C, size int) {
+	keys := make([]int, c.N)
+	for i := 0; i < c.N; i++ {
+		keys[i] = i + 1e7
+	}
+	rand.Shuffle(c.N, func(i, j int) { keys[j], keys[i] = keys[i], keys[j] })
+	cache := lru.NewCache[int, int](size)
+	c.ResetTimer()
+	for i := 0; i < c.N; i++ {
+		cache.Add(keys[i], i)
+	}
+	expectLen := size
+	if c.N < expectLen {
+		expectLen = c.N
+	}
+	c.Assert(cache.Len(), gc.Equals, expectLen)
+}
+
+func (*BenchmarkLRUSuite) BenchmarkAddAndEvictInt64LRU(c *gc.C) {
+	keys := int64Keys(c.N)
+	cache := lru.New(1000)
+	c.ResetTimer()
+	for i := 0; i < c.N; i++ {
+		cache.Add(keys[i], i)
+	}
+}
+
+func (*BenchmarkLRUSuite) BenchmarkAddAndEvictInt64IntCache(c *gc.C) {
+	keys := int64Keys(c.N)
+	cache := lru.NewIntCache[int](1000)
+	c.ResetTimer()
+	for i := 0; i < c.N; i++ {
+		cache.Add(keys[i], i)
+	}
+}
+
+func (*BenchmarkLRUSuite) BenchmarkGetInt64LRU(c *gc.C) {
+	cache := lru.New(1000)
+	for i := int64(0); i < 1000; i++ {
+		cache.Add(i+1e7, i)
+	}
+	keys := int64Keys(1000)
+	c.ResetTimer()
+	for i := 0; i < c.N; i++ {
+		cache.Get(keys[i%1000])
+	}
+}
+
+func (*BenchmarkLRUSuite) BenchmarkGetInt64IntCache(c *gc.C) {
+	cache := lru.NewIntCache[int64](1000)
+	for i := int64(0); i < 1000; i++ {
+		cache.Add(i+1e7, i)
+	}
+	keys := int64Keys(1000)
+	c.ResetTimer()
+	for i := 0; i < c.N; i++ {
+		cache.Get(keys[i%1000])
+	}
+}
+
+func int64Keys(n int) []int64 {
+	keys := make([]int64, n)
+	for i := 0; i < n; i++ {
+		keys[i] = int64(i) + 1e7
+	}
+	rand.Shuffle(n, func(i, j int) { keys[j], keys[i] = keys[i], keys[j] })
+	return keys
+}
+
+func (*BenchmarkLRUSuite) BenchmarkRemoveExpired(c *gc.C) {
+	// A large cache where only a few entries expire between each call, so
+	// the cost should be in proportion to those rather than to the size.
+	clock := newTestClock()
+	cache := lru.New(200000, lru.WithClock(clock), lru.WithExpiry(time.Hour))
+	for i := 0; i < 200000; i++ {
+		cache.Add(i, i)
+		clock.Advance(time.Hour / 200000)
+	}
+	c.ResetTimer()
+	for i := 0; i < c.N; i++ {
+		cache.Add(200000+i, i)
+		clock.Advance(time.Hour / 200000)
+		cache.RemoveExpired()
+	}
+}
+
+func (*BenchmarkLRUSuite) BenchmarkGetRandomEviction0100000(c *gc.C) {
+	benchGet(c, 100000, lru.WithRandomEviction())
+}
+
+func (*BenchmarkLRUSuite) BenchmarkAddAndEvictRandomEviction(c *gc.C) {
+	cache := lru.New(100000, lru.WithRandomEviction())
+	c.ResetTimer()
+	for i := 0; i < c.N; i++ {
+		cache.Add(i, i)
+	}
+}
+
+func (*BenchmarkLRUSuite) BenchmarkGetSampledEviction0100000(c *gc.C) {
+	benchGet(c, 100000, lru.WithSampledEviction(5))
+}
+
+func (*BenchmarkLRUSuite) BenchmarkAddAndEvictSampledEviction(c *gc.C) {
+	cache := lru.New(100000, lru.WithSampledEviction(5))
+	c.ResetTimer()
+	for i := 0; i < c.N; i++ {
+		cache.Add(i, i)
+	}
+}
@@ -0,0 +1,62 @@
+// Copyright 2026 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package lru
+
+// BytesCache is a cache keyed by byte slices, such as binary digests. Lookups
+// index the internal map with the bytes directly, so they do not allocate.
+// The key is only copied when a new entry is actually added to the cache,
+// which means callers are free to reuse the key buffer after any call.
+// Note that BytesCache is *not* thread safe, some form of mutex is necessary
+// if you want to access it from multiple threads.
+type BytesCache[V any] struct {
+	cache Cache[string, V]
+}
+
+// NewBytesCache creates a new BytesCache that will hold no more than the given
+// number of items.
+func NewBytesCache[V any](size int) *BytesCache[V] {
+	c := &BytesCache[V]{}
+	c.cache.init(size)
+	return c
+}
+
+// Len gives the number of items in the cache
+func (c *BytesCache[V]) Len() int {
+	return c.cache.Len()
+}
+
+// Add a new entry into the cache
+func (c *BytesCache[V]) Add(key []byte, value V) {
+	// Note: the compiler avoids allocating for string(key) when it is only
+	// used to index a map.
+	if elem, exists := c.cache.elements[string(key)]; exists {
+		entry := &c.cache.buf[elem]
+		c.cache.moveToFront(elem, entry)
+		entry.value = value
+		return
+	}
+	c.cache.insert(string(key), value)
+}
+
+// Get returns the value associated with key, and a boolean as to whether it
+// actually exists in the cache. If it does exist in the cache, then it is
+// treated as recently accessed.
+func (c *BytesCache[V]) Get(key []byte) (V, bool) {
+	if elem, exists := c.cache.elements[string(key)]; exists {
+		entry := &c.cache.buf[elem]
+		c.cache.moveToFront(elem, entry)
+		return entry.value, true
+	}
+	var zero V
+	return zero, false
+}
+
+// Peek is just like Get() except it doesn't affect if it was 'recently accessed'
+func (c *BytesCache[V]) Peek(key []byte) (V, bool) {
+	if elem, exists := c.cache.elements[string(key)]; exists {
+		return c.cache.buf[elem].value, true
+	}
+	var zero V
+	return zero, false
+}
@@ -0,0 +1,70 @@
+// Copyright 2026 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package lru_test
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"testing"
+
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/lru"
+)
+
+type BytesCacheSuite struct{}
+
+var _ = gc.Suite(&BytesCacheSuite{})
+
+func (*BytesCacheSuite) TestAddGetEvict(c *gc.C) {
+	cache := lru.NewBytesCache[int](2)
+	cache.Add([]byte("one"), 1)
+	cache.Add([]byte("two"), 2)
+	v, ok := cache.Get([]byte("one"))
+	c.Check(ok, gc.Equals, true)
+	c.Check(v, gc.Equals, 1)
+	cache.Add([]byte("three"), 3)
+	_, ok = cache.Peek([]byte("two"))
+	c.Check(ok, gc.Equals, false)
+	c.Check(cache.Len(), gc.Equals, 2)
+}
+
+func (*BytesCacheSuite) TestKeyIsCopied(c *gc.C) {
+	cache := lru.NewBytesCache[string](10)
+	key := []byte("abc")
+	cache.Add(key, "value")
+	key[0] = 'x'
+	_, ok := cache.Peek(key)
+	c.Check(ok, gc.Equals, false)
+	v, ok := cache.Peek([]byte("abc"))
+	c.Check(ok, gc.Equals, true)
+	c.Check(v, gc.Equals, "value")
+}
+
+func (*BytesCacheSuite) TestLookupDoesNotAllocate(c *gc.C) {
+	cache := lru.NewBytesCache[int](10)
+	digest := sha256.Sum256([]byte("content"))
+	cache.Add(digest[:], 1)
+	key := digest[:]
+	allocs := testing.AllocsPerRun(100, func() {
+		cache.Get(key)
+		cache.Peek(key)
+		cache.Add(key, 2)
+	})
+	c.Check(allocs, gc.Equals, float64(0))
+}
+
+func (*BenchmarkLRUSuite) BenchmarkGetBytesCache(c *gc.C) {
+	cache := lru.NewBytesCache[int](1000)
+	keys := make([][]byte, 1000)
+	for i := range keys {
+		digest := sha256.Sum256([]byte(fmt.Sprint(i)))
+		keys[i] = digest[:]
+		cache.Add(keys[i], i)
+	}
+	c.ResetTimer()
+	for i := 0; i < c.N; i++ {
+		cache.Get(keys[i%1000])
+	}
+}
@@ -0,0 +1,238 @@
+// Copyright 2026 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package lru
+
+import (
+	"fmt"
+)
+
+// Cache is a type safe version of LRU. Keys and values are stored directly in
+// the cache's buffer rather than being boxed into interface{} values, and Get
+// returns a V rather than something that needs a type assertion.
+// Note that Cache is *not* thread safe, some form of mutex is necessary if you
+// want to access it from multiple threads.
+type Cache[K comparable, V any] struct {
+	size     int
+	maxSize  int
+	buf      []typedEntry[K, V]
+	elements map[K]uint32
+	root     *typedEntry[K, V]
+}
+
+type typedEntry[K comparable, V any] struct {
+	prev, next uint32
+	key        K
+	value      V
+}
+
+// NewCache creates a new Cache that will hold no more than the given number of
+// items.
+func NewCache[K comparable, V any](size int) *Cache[K, V] {
+	c := &Cache[K, V]{}
+	c.init(size)
+	return c
+}
+
+func (c *Cache[K, V]) init(size int) {
+	if size > maxLRUSize || size <= 0 {
+		panic("size must not be <= 0 or >= 2^32")
+	}
+	initialBufSize := size + 1
+	if initialBufSize > 100 {
+		initialBufSize = 101
+	}
+	c.size = 0
+	c.maxSize = size
+	c.buf = make([]typedEntry[K, V], initialBufSize)
+	c.elements = make(map[K]uint32, initialBufSize)
+	c.root = &c.buf[0]
+}
+
+// Len gives the number of items in the cache
+func (c *Cache[K, V]) Len() int {
+	return c.size
+}
+
+// Add a new entry into the cache
+func (c *Cache[K, V]) Add(key K, value V) {
+	if elem, exists := c.elements[key]; exists {
+		entry := &c.buf[elem]
+		c.moveToFront(elem, entry)
+		entry.value = value
+	} else {
+		c.insert(key, value)
+	}
+}
+
+// Update looks up key and passes its current value (or the zero value if it
+// is not present) to fn. If fn returns write=true, the returned value is stored
+// under key, adding the entry if it did not exist. See LRU.Update.
+func (c *Cache[K, V]) Update(key K, fn func(old V, exists bool) (new V, write bool)) {
+	elem, exists := c.elements[key]
+	var old V
+	if exists {
+		old = c.buf[elem].value
+	}
+	value, write := fn(old, exists)
+	if !write {
+		return
+	}
+	if exists {
+		entry := &c.buf[elem]
+		c.moveToFront(elem, entry)
+		entry.value = value
+	} else {
+		c.insert(key, value)
+	}
+}
+
+// insert adds a key that is known not to be in the cache, evicting the least
+// recently used entry if the cache is full.
+func (c *Cache[K, V]) insert(key K, value V) {
+	var elem uint32
+	if c.size < c.maxSize {
+		c.size++
+		elem = uint32(c.size)
+		if c.size >= len(c.buf) {
+			c.realloc()
+		}
+	} else {
+		elem = c.root.prev
+		delete(c.elements, c.buf[elem].key)
+	}
+	if elem >= uint32(len(c.buf)) {
+		panic(fmt.Sprintf("element %d outside of buffer range: %d", elem, len(c.buf)))
+	}
+	entry := &c.buf[elem]
+	entry.key = key
+	entry.value = value
+	c.elements[key] = elem
+	c.moveToFront(elem, entry)
+}
+
+// Get returns the value associated with key, and a boolean as to whether it
+// actually exists in the cache. If it does exist in the cache, then it is
+// treated as recently accessed.
+func (c *Cache[K, V]) Get(key K) (V, bool) {
+	if elem, exists := c.elements[key]; exists {
+		entry := &c.buf[elem]
+		c.moveToFront(elem, entry)
+		return entry.value, true
+	}
+	var zero V
+	return zero, false
+}
+
+// GetWithDefault is like Get, but returns def if key is not in the cache. def
+// is not added to the cache.
+func (c *Cache[K, V]) GetWithDefault(key K, def V) V {
+	if value, ok := c.Get(key); ok {
+		return value
+	}
+	return def
+}
+
+// Peek is just like Get() except it doesn't affect if it was 'recently accessed'
+func (c *Cache[K, V]) Peek(key K) (V, bool) {
+	if elem, exists := c.elements[key]; exists {
+		return c.buf[elem].value, true
+	}
+	var zero V
+	return zero, false
+}
+
+// Range calls fn for each entry in the cache, starting with the most recently
+// used, until fn returns false. fn must not modify the cache.
+func (c *Cache[K, V]) Range(fn func(key K, value V) bool) {
+	for elem := c.root.next; elem != 0; elem = c.buf[elem].next {
+		entry := &c.buf[elem]
+		if !fn(entry.key, entry.value) {
+			return
+		}
+	}
+}
+
+// RangeReverse is like Range, but starts with the least recently used entry.
+func (c *Cache[K, V]) RangeReverse(fn func(key K, value V) bool) {
+	for elem := c.root.prev; elem != 0; elem = c.buf[elem].prev {
+		entry := &c.buf[elem]
+		if !fn(entry.key, entry.value) {
+			return
+		}
+	}
+}
+
+// RemoveIf removes every entry for which fn returns true, and returns how many
+// entries were removed. fn must not modify the cache.
+func (c *Cache[K, V]) RemoveIf(fn func(key K, value V) bool) int {
+	removed := 0
+	for elem := c.root.next; elem != 0; {
+		entry := &c.buf[elem]
+		next := entry.next
+		if fn(entry.key, entry.value) {
+			if next == uint32(c.size) {
+				next = elem
+			}
+			c.removeElem(elem)
+			removed++
+		}
+		elem = next
+	}
+	return removed
+}
+
+// removeElem unlinks elem, moving the last element into its slot. See
+// LRU.removeElem.
+func (c *Cache[K, V]) removeElem(elem uint32) {
+	entry := &c.buf[elem]
+	c.buf[entry.prev].next = entry.next
+	c.buf[entry.next].prev = entry.prev
+	delete(c.elements, entry.key)
+	last := uint32(c.size)
+	if elem != last {
+		moved := c.buf[last]
+		c.buf[elem] = moved
+		c.buf[moved.prev].next = elem
+		c.buf[moved.next].prev = elem
+		c.elements[moved.key] = elem
+	}
+	c.buf[last] = typedEntry[K, V]{}
+	c.size--
+}
+
+func (c *Cache[K, V]) realloc() {
+	nextSize := (len(c.buf) - 1) * 2
+	if nextSize > c.maxSize {
+		nextSize = c.maxSize
+	}
+	newBuf := make([]typedEntry[K, V], nextSize+1)
+	copy(newBuf, c.buf)
+	c.buf = newBuf
+	c.root = &newBuf[0]
+	if nextSize == c.maxSize {
+		// See LRU.realloc, we know we won't need the map to grow any further.
+		elements := make(map[K]uint32, nextSize)
+		for k, v := range c.elements {
+			elements[k] = v
+		}
+		c.elements = elements
+	}
+}
+
+func (c *Cache[K, V]) moveToFront(elem uint32, entry *typedEntry[K, V]) {
+	if c.root.next == elem {
+		// we're already at the front
+		return
+	}
+	if entry.prev != 0 {
+		// remove it from its current spot
+		c.buf[entry.prev].next = entry.next
+		c.buf[entry.next].prev = entry.prev
+	}
+	next := c.root.next
+	entry.prev = 0
+	entry.next = next
+	c.root.next = elem
+	c.buf[next].prev = elem
+}
@@ -0,0 +1,102 @@
+// Copyright 2026 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package lru_test
+
+import (
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/lru"
+)
+
+type CacheSuite struct{}
+
+var _ = gc.Suite(&CacheSuite{})
+
+func simpleFullTypedCache() *lru.Cache[int, string] {
+	cache := lru.NewCache[int, string](10)
+	for i, v := range []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j"} {
+		cache.Add(i, v)
+	}
+	return cache
+}
+
+func (*CacheSuite) TestAddAndGet(c *gc.C) {
+	cache := lru.NewCache[string, int](2)
+	cache.Add("one", 1)
+	cache.Add("two", 2)
+	v, ok := cache.Get("one")
+	c.Check(ok, gc.Equals, true)
+	c.Check(v, gc.Equals, 1)
+	cache.Add("three", 3)
+	_, ok = cache.Peek("two")
+	c.Check(ok, gc.Equals, false)
+	v, ok = cache.Peek("three")
+	c.Check(ok, gc.Equals, true)
+	c.Check(v, gc.Equals, 3)
+	c.Check(cache.Len(), gc.Equals, 2)
+}
+
+func (*CacheSuite) TestGetMissing(c *gc.C) {
+	cache := simpleFullTypedCache()
+	v, ok := cache.Get(100)
+	c.Check(ok, gc.Equals, false)
+	c.Check(v, gc.Equals, "")
+	c.Check(cache.GetWithDefault(100, "default"), gc.Equals, "default")
+	c.Check(cache.GetWithDefault(1, "default"), gc.Equals, "b")
+}
+
+func (*CacheSuite) TestAddEvicts(c *gc.C) {
+	cache := simpleFullTypedCache()
+	cache.Get(0)
+	cache.Add(10, "k")
+	_, ok := cache.Peek(1)
+	c.Check(ok, gc.Equals, false)
+	_, ok = cache.Peek(0)
+	c.Check(ok, gc.Equals, true)
+	c.Check(cache.Len(), gc.Equals, 10)
+}
+
+func (*CacheSuite) TestUpdate(c *gc.C) {
+	cache := lru.NewCache[string, int](10)
+	incr := func(old int, exists bool) (int, bool) {
+		return old + 1, true
+	}
+	cache.Update("count", incr)
+	cache.Update("count", incr)
+	v, _ := cache.Peek("count")
+	c.Check(v, gc.Equals, 2)
+}
+
+func (*CacheSuite) TestRangeAndRemoveIf(c *gc.C) {
+	cache := simpleFullTypedCache()
+	removed := cache.RemoveIf(func(key int, value string) bool {
+		return key >= 5
+	})
+	c.Check(removed, gc.Equals, 5)
+	var keys []int
+	cache.Range(func(key int, value string) bool {
+		keys = append(keys, key)
+		return true
+	})
+	c.Check(keys, gc.DeepEquals, []int{4, 3, 2, 1, 0})
+	keys = nil
+	cache.RangeReverse(func(key int, value string) bool {
+		keys = append(keys, key)
+		return true
+	})
+	c.Check(keys, gc.DeepEquals, []int{0, 1, 2, 3, 4})
+}
+
+func (*CacheSuite) TestGrowsBuffer(c *gc.C) {
+	cache := lru.NewCache[int, int](1000)
+	for i := 0; i < 1500; i++ {
+		cache.Add(i, i*2)
+	}
+	c.Check(cache.Len(), gc.Equals, 1000)
+	v, ok := cache.Peek(1499)
+	c.Check(ok, gc.Equals, true)
+	c.Check(v, gc.Equals, 2998)
+	_, ok = cache.Peek(499)
+	c.Check(ok, gc.Equals, false)
+}
@@ -0,0 +1,71 @@
+// Copyright 2026 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package lru
+
+// Canonicalizer is like StringCache, but for arbitrary comparable values held
+// in an interface{}. Canonicalize returns a previously stored value equal to
+// the one given, so that many identical values (such as small config structs,
+// or pointers to them) collapse into one allocation. Values are compared with
+// ==, so two pointers are only equal if they point to the same thing; store
+// structs by value to have equal contents unify. Passing a value that is not
+// comparable will panic, just as it would for a map key.
+// Note that Canonicalizer is *not* thread safe, some form of mutex is
+// necessary if you want to access it from multiple threads.
+type Canonicalizer struct {
+	lru       *LRU
+	hitCount  int64
+	missCount int64
+}
+
+// canonicalKey wraps values so that they are always compared directly, even
+// if they implement Keyer.
+type canonicalKey struct {
+	v interface{}
+}
+
+// NewCanonicalizer creates a Canonicalizer that will hold no more than 'size'
+// values.
+func NewCanonicalizer(size int) *Canonicalizer {
+	return &Canonicalizer{
+		lru: New(size),
+	}
+}
+
+// Len returns how many values are currently cached
+func (c *Canonicalizer) Len() int {
+	return c.lru.Len()
+}
+
+// HitCounts gives information about accesses to the cache. The total number
+// of calls to Canonicalize can be computed by adding Hit and Miss.
+func (c *Canonicalizer) HitCounts() HitCounts {
+	return HitCounts{
+		Hit:  c.hitCount,
+		Miss: c.missCount,
+	}
+}
+
+// Canonicalize takes a value and returns either a previously cached value
+// that is equal to it, or caches the value and returns it back. It also
+// updates how recently the value was seen, so that values aren't cached
+// forever.
+func (c *Canonicalizer) Canonicalize(v interface{}) interface{} {
+	key := canonicalKey{v: v}
+	if elem, ok := c.lru.elements[key]; ok {
+		entry := &c.lru.buf[elem]
+		c.lru.moveToFront(elem, entry)
+		c.hitCount++
+		return entry.key.(canonicalKey).v
+	}
+	c.missCount++
+	c.lru.insert(key, nil, 1)
+	return v
+}
+
+// Contains returns true if a value equal to v is in the cache. It does not
+// change information about recently-used.
+func (c *Canonicalizer) Contains(v interface{}) bool {
+	_, ok := c.lru.elements[canonicalKey{v: v}]
+	return ok
+}
@@ -0,0 +1,64 @@
+// Copyright 2026 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package lru_test
+
+import (
+	"fmt"
+
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/lru"
+)
+
+type CanonicalizerSuite struct{}
+
+var _ = gc.Suite(&CanonicalizerSuite{})
+
+type config struct {
+	name  string
+	count int
+}
+
+func (*CanonicalizerSuite) TestCanonicalize(c *gc.C) {
+	cfg1 := config{name: fmt.Sprint("na", "me"), count: 1}
+	cfg2 := config{name: fmt.Sprint("na", "me"), count: 1}
+	c.Check(isSameStr(cfg1.name, cfg2.name), gc.Equals, false)
+
+	cache := lru.NewCanonicalizer(10)
+	v1 := cache.Canonicalize(cfg1).(config)
+	v2 := cache.Canonicalize(cfg2).(config)
+	c.Check(v2, gc.Equals, cfg2)
+	c.Check(isSameStr(v1.name, v2.name), gc.Equals, true)
+	c.Check(isSameStr(cfg1.name, v2.name), gc.Equals, true)
+	c.Check(cache.Len(), gc.Equals, 1)
+	c.Check(cache.HitCounts(), gc.Equals, lru.HitCounts{Hit: 1, Miss: 1})
+}
+
+func (*CanonicalizerSuite) TestMixedTypes(c *gc.C) {
+	cache := lru.NewCanonicalizer(10)
+	cache.Canonicalize(1)
+	cache.Canonicalize(int64(1))
+	cache.Canonicalize("1")
+	c.Check(cache.Len(), gc.Equals, 3)
+	c.Check(cache.Contains(1), gc.Equals, true)
+	c.Check(cache.Contains(uint(1)), gc.Equals, false)
+}
+
+func (*CanonicalizerSuite) TestKeyersCompareByValue(c *gc.C) {
+	cache := lru.NewCanonicalizer(10)
+	cache.Canonicalize(machineKey{id: "0", label: "a"})
+	v := cache.Canonicalize(machineKey{id: "0", label: "b"})
+	c.Check(v, gc.Equals, machineKey{id: "0", label: "b"})
+	c.Check(cache.Len(), gc.Equals, 2)
+}
+
+func (*CanonicalizerSuite) TestMaxSize(c *gc.C) {
+	cache := lru.NewCanonicalizer(5)
+	for i := 0; i < 30; i++ {
+		cache.Canonicalize(config{count: i})
+	}
+	c.Check(cache.Len(), gc.Equals, 5)
+	c.Check(cache.Contains(config{count: 24}), gc.Equals, false)
+	c.Check(cache.Contains(config{count: 25}), gc.Equals, true)
+}
@@ -0,0 +1,23 @@
+// Copyright 2026 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package lru
+
+import (
+	"time"
+)
+
+// Clock provides the current time to the cache. See WithClock.
+type Clock interface {
+	Now() time.Time
+}
+
+// WallClock is a Clock that returns the real current time.
+var WallClock Clock = wallClock{}
+
+type wallClock struct{}
+
+// Now is part of the Clock interface.
+func (wallClock) Now() time.Time {
+	return time.Now()
+}
@@ -0,0 +1,57 @@
+// Copyright 2026 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package lru_test
+
+import (
+	"sync"
+	"time"
+
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/lru"
+)
+
+// testClock is a lru.Clock that only moves when it is told to.
+type testClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func newTestClock() *testClock {
+	return &testClock{
+		now: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+func (c *testClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *testClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+type ClockSuite struct{}
+
+var _ = gc.Suite(&ClockSuite{})
+
+func (*ClockSuite) TestWallClock(c *gc.C) {
+	before := time.Now()
+	now := lru.WallClock.Now()
+	c.Check(now.Before(before), gc.Equals, false)
+}
+
+func (*ClockSuite) TestWithClock(c *gc.C) {
+	clock := newTestClock()
+	cache := lru.New(10, lru.WithClock(clock), lru.WithExpiry(time.Minute))
+	cache.Add("a", 1)
+	clock.Advance(time.Minute - time.Nanosecond)
+	checkPeekExists(c, cache, "a", 1)
+	clock.Advance(time.Nanosecond)
+	checkPeekMissing(c, cache, "a")
+}
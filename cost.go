@@ -0,0 +1,169 @@
+// Copyright 2026 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package lru
+
+// NewWithMaxCost creates a new LRU cache where each entry has a cost, such as
+// its size in bytes, and the total cost of the entries is kept within
+// maxCost, rather than the number of entries being limited. Entries are added
+// with AddWithCost; those added with Add (or Update) have a cost of 1. When
+// there isn't room for a new entry, the least recently used entries (or
+// whichever the eviction policy chooses) are evicted until there is.
+func NewWithMaxCost(maxCost int64, options ...Option) *LRU {
+	if maxCost <= 0 {
+		panic("maxCost must be > 0")
+	}
+	// Every entry costs at least 1, so there can't be more entries than
+	// maxCost.
+	return newWithMaxCost(maxCost, 1, options)
+}
+
+// newWithMaxCost creates an LRU with the given maximum cost, which can hold as
+// many entries as fit if each costs minCost.
+func newWithMaxCost(maxCost, minCost int64, options []Option) *LRU {
+	size := int64(maxLRUSize)
+	if maxCost/minCost < size {
+		size = maxCost / minCost
+	}
+	if size < 1 {
+		size = 1
+	}
+	lru := New(int(size), options...)
+	lru.maxCost = maxCost
+	lru.costs = make([]int64, len(lru.buf))
+	return lru
+}
+
+// NewWithMaxBytes creates a new LRU cache that keeps the estimated memory
+// used by its entries within maxBytes. It is NewWithMaxCost, where the cost of
+// an entry added with Add or Update is its size in bytes: an estimate of the
+// overhead of the entry in the cache, plus the size of its key and value.
+// Keys and values that implement Sizer give their own size, and strings and
+// byte slices are counted by their length. The size of other values can be
+// given by WithSizeFunc, otherwise only the overhead is counted. Sizes are
+// worked out again each time an entry is written.
+func NewWithMaxBytes(maxBytes int64, options ...Option) *LRU {
+	if maxBytes <= 0 {
+		panic("maxBytes must be > 0")
+	}
+	lru := newWithMaxCost(maxBytes, entryOverhead, options)
+	lru.maxBytes = true
+	return lru
+}
+
+// AddWithCost adds a new entry into the cache with the given cost, or updates
+// the value and cost of an existing entry, evicting other entries to keep the
+// total cost within the maximum. cost must be > 0. If cost is more than the
+// maximum, the entry can never fit, so it is not added, and any existing
+// entry for key is removed. The cache must have been created with
+// NewWithMaxCost.
+func (lru *LRU) AddWithCost(key, value interface{}, cost int64) {
+	if lru.costs == nil {
+		panic("AddWithCost needs the cache to be created with NewWithMaxCost")
+	}
+	if cost <= 0 {
+		panic("cost must be > 0")
+	}
+	if cost > lru.maxCost {
+		if elem, exists := lru.elements[lru.identity(key)]; exists {
+			lru.removeElem(elem, CapacityEvicted)
+		}
+		return
+	}
+	key = lru.normalizeKey(key)
+	lru.recordUse(key)
+	elem, exists := lru.elements[mapKey(key)]
+	if !exists {
+		lru.insert(key, value, cost)
+		return
+	}
+	lru.touch(elem, &lru.buf[elem])
+	lru.setValue(elem, value)
+	lru.written(elem)
+	lru.setCost(elem, cost)
+}
+
+// UpdateCost changes the cost of the entry for key, such as when its value
+// has grown, without counting as a use of it. If the total cost is now over
+// the maximum, entries are evicted as they would be by AddWithCost, which may
+// include this entry if it is the least recently used. It returns whether key
+// was in the cache. The cache must have been created with NewWithMaxCost.
+func (lru *LRU) UpdateCost(key interface{}, cost int64) bool {
+	if lru.costs == nil {
+		panic("UpdateCost needs the cache to be created with NewWithMaxCost")
+	}
+	if cost <= 0 {
+		panic("cost must be > 0")
+	}
+	elem, exists := lru.elements[lru.identity(key)]
+	if !exists {
+		return false
+	}
+	lru.setCost(elem, cost)
+	return true
+}
+
+// setCost changes the cost of elem, evicting other entries if it no longer
+// fits.
+func (lru *LRU) setCost(elem uint32, cost int64) {
+	if cost > lru.maxCost {
+		lru.removeElem(elem, CapacityEvicted)
+		return
+	}
+	lru.totalCost += cost - lru.costs[elem]
+	lru.costs[elem] = cost
+	if lru.totalCost > lru.maxCost && lru.beginBatch() {
+		defer lru.endBatch(CapacityEvicted)
+	}
+	for lru.totalCost > lru.maxCost {
+		lru.removeElem(lru.victim(), CapacityEvicted)
+		lru.evictions++
+	}
+}
+
+// costOf returns the cost of a new entry added with Add or Update.
+func (lru *LRU) costOf(key, value interface{}) int64 {
+	if lru.maxBytes {
+		return lru.sizeBytes(key, value)
+	}
+	return 1
+}
+
+// Cost returns the total cost of the entries in the cache. Without
+// NewWithMaxCost, every entry costs 1, so it is the same as Len.
+func (lru *LRU) Cost() int64 {
+	if lru.costs == nil {
+		return int64(lru.size)
+	}
+	return lru.totalCost
+}
+
+// makeRoomFor evicts entries until there is room for a new entry for key with
+// the given cost, and returns whether there is.
+func (lru *LRU) makeRoomFor(key interface{}, cost int64) bool {
+	return lru.evictUntil(key, func() bool {
+		return lru.totalCost+cost <= lru.maxCost
+	})
+}
+
+// evictUntil evicts entries, as chosen by the eviction policy, until done
+// returns true, to make room for a new entry for key. The first entry to be
+// evicted has to get past the admission policy, if there is one, and it
+// returns whether it did.
+func (lru *LRU) evictUntil(key interface{}, done func() bool) bool {
+	if done() {
+		return true
+	}
+	if lru.beginBatch() {
+		defer lru.endBatch(CapacityEvicted)
+	}
+	for first := true; lru.size > 0 && !done(); first = false {
+		victim := lru.victim()
+		if first && !lru.admit(key, victim) {
+			return false
+		}
+		lru.removeElem(victim, CapacityEvicted)
+		lru.evictions++
+	}
+	return true
+}
@@ -0,0 +1,197 @@
+// Copyright 2026 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package lru_test
+
+import (
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/lru"
+)
+
+type CostSuite struct{}
+
+var _ = gc.Suite(&CostSuite{})
+
+func (*CostSuite) TestAddWithCost(c *gc.C) {
+	cache := lru.NewWithMaxCost(100)
+	cache.AddWithCost("a", 1, 40)
+	cache.AddWithCost("b", 2, 40)
+	c.Check(cache.Cost(), gc.Equals, int64(80))
+	// c doesn't fit alongside both, so a is evicted.
+	cache.AddWithCost("c", 3, 30)
+	checkPeekMissing(c, cache, "a")
+	c.Check(cache.Cost(), gc.Equals, int64(70))
+	// Using b means c goes next.
+	cache.Get("b")
+	cache.AddWithCost("d", 4, 50)
+	checkPeekMissing(c, cache, "c")
+	checkPeekExists(c, cache, "b", 2)
+	c.Check(cache.Cost(), gc.Equals, int64(90))
+	c.Check(cache.Len(), gc.Equals, 2)
+}
+
+func (*CostSuite) TestAddWithCostEvictsSeveral(c *gc.C) {
+	cache := lru.NewWithMaxCost(100)
+	for i := 0; i < 10; i++ {
+		cache.AddWithCost(i, i, 10)
+	}
+	cache.AddWithCost("big", "big", 75)
+	c.Check(collectKeys(cache.Range), gc.DeepEquals, []interface{}{"big", 9, 8})
+	c.Check(cache.Cost(), gc.Equals, int64(95))
+}
+
+func (*CostSuite) TestAddWithCostUpdate(c *gc.C) {
+	cache := lru.NewWithMaxCost(100)
+	cache.AddWithCost("a", 1, 40)
+	cache.AddWithCost("b", 2, 40)
+	// Growing a pushes out b.
+	cache.AddWithCost("a", 10, 70)
+	checkPeekExists(c, cache, "a", 10)
+	checkPeekMissing(c, cache, "b")
+	c.Check(cache.Cost(), gc.Equals, int64(70))
+	cache.AddWithCost("a", 11, 5)
+	c.Check(cache.Cost(), gc.Equals, int64(5))
+}
+
+func (*CostSuite) TestAddWithCostTooBig(c *gc.C) {
+	cache := lru.NewWithMaxCost(100)
+	cache.AddWithCost("a", 1, 40)
+	cache.AddWithCost("b", 2, 40)
+	cache.AddWithCost("huge", 3, 101)
+	checkPeekMissing(c, cache, "huge")
+	c.Check(cache.Len(), gc.Equals, 2)
+	// Replacing an entry with one that is too big removes it.
+	cache.AddWithCost("a", 3, 101)
+	checkPeekMissing(c, cache, "a")
+	c.Check(cache.Cost(), gc.Equals, int64(40))
+}
+
+func (*CostSuite) TestAddCountsOne(c *gc.C) {
+	cache := lru.NewWithMaxCost(3)
+	cache.AddWithCost("a", 1, 2)
+	cache.Add("b", 2)
+	c.Check(cache.Cost(), gc.Equals, int64(3))
+	cache.Add("c", 3)
+	checkPeekMissing(c, cache, "a")
+	c.Check(cache.Cost(), gc.Equals, int64(2))
+}
+
+func (*CostSuite) TestRemoveIf(c *gc.C) {
+	cache := lru.NewWithMaxCost(1000)
+	for i := 0; i < 20; i++ {
+		cache.AddWithCost(i, i, int64(i+1))
+	}
+	cache.RemoveIf(func(key, _ interface{}) bool { return key.(int)%2 == 0 })
+	// 2 + 4 + ... + 20
+	c.Check(cache.Cost(), gc.Equals, int64(110))
+	for i := 100; i < 200; i++ {
+		cache.AddWithCost(i, i, 37)
+		c.Assert(cache.Cost() <= 1000, gc.Equals, true)
+	}
+}
+
+func (*CostSuite) TestNoMaxCost(c *gc.C) {
+	cache := lru.New(10)
+	cache.Add("a", 1)
+	cache.Add("b", 2)
+	c.Check(cache.Cost(), gc.Equals, int64(2))
+	c.Check(func() { cache.AddWithCost("c", 3, 1) }, gc.PanicMatches, "AddWithCost needs the cache to be created with NewWithMaxCost")
+}
+
+func (*CostSuite) TestSync(c *gc.C) {
+	cache := lru.NewSyncWithMaxCost(100)
+	cache.AddWithCost("a", 1, 60)
+	cache.AddWithCost("b", 2, 60)
+	_, ok := cache.Peek("a")
+	c.Check(ok, gc.Equals, false)
+	c.Check(cache.Cost(), gc.Equals, int64(60))
+}
+
+type sizedValue int
+
+func (v sizedValue) SizeBytes() int {
+	return int(v)
+}
+
+func (*CostSuite) TestMaxBytes(c *gc.C) {
+	cache := lru.NewWithMaxBytes(10000)
+	cache.Add("a", "")
+	overhead := cache.Cost() - 1
+	c.Assert(overhead > 0, gc.Equals, true)
+	// Strings and Sizers are counted by their size.
+	cache.Add("bb", "12345")
+	c.Check(cache.Cost(), gc.Equals, 2*overhead+1+2+5)
+	cache.Add("c", sizedValue(1000))
+	c.Check(cache.Cost(), gc.Equals, 3*overhead+1+2+5+1+1000)
+	// Updating a value changes its size.
+	cache.Add("c", sizedValue(10))
+	c.Check(cache.Cost(), gc.Equals, 3*overhead+1+2+5+1+10)
+	cache.Update("bb", func(interface{}, bool) (interface{}, bool) {
+		return "", true
+	})
+	c.Check(cache.Cost(), gc.Equals, 3*overhead+1+2+1+10)
+}
+
+func (*CostSuite) TestMaxBytesEvicts(c *gc.C) {
+	cache := lru.NewWithMaxBytes(10000)
+	for i := 0; i < 100; i++ {
+		cache.Add(i, sizedValue(1000))
+		c.Assert(cache.Cost() <= 10000, gc.Equals, true)
+	}
+	c.Check(cache.Len() < 10, gc.Equals, true)
+	// Growing a value pushes out others.
+	cache.Add(99, sizedValue(9000))
+	c.Check(cache.Len(), gc.Equals, 1)
+	checkPeekExists(c, cache, 99, sizedValue(9000))
+	// And a value that can't fit is dropped.
+	cache.Add(99, sizedValue(10000))
+	c.Check(cache.Len(), gc.Equals, 0)
+	c.Check(cache.Cost(), gc.Equals, int64(0))
+}
+
+func (*CostSuite) TestWithSizeFunc(c *gc.C) {
+	cache := lru.NewWithMaxBytes(10000, lru.WithSizeFunc(func(value interface{}) int {
+		return len(value.([]int)) * 8
+	}))
+	cache.Add("a", []int{})
+	overhead := cache.Cost() - 1
+	cache.Add("b", []int{1, 2, 3})
+	c.Check(cache.Cost(), gc.Equals, 2*overhead+1+1+24)
+}
+
+func (*CostSuite) TestSizeBytes(c *gc.C) {
+	cache := lru.New(1000)
+	empty := cache.SizeBytes()
+	c.Assert(empty > 0, gc.Equals, true)
+	for i := 0; i < 100; i++ {
+		cache.Add(i, i)
+	}
+	withInts := cache.SizeBytes()
+	c.Check(withInts > empty, gc.Equals, true)
+	// Known sizes of keys and values are counted.
+	cache.Add("key", string(make([]byte, 10000)))
+	c.Check(cache.SizeBytes() >= withInts+10003, gc.Equals, true)
+}
+
+func (*CostSuite) TestUpdateCost(c *gc.C) {
+	cache := lru.NewWithMaxCost(100)
+	cache.AddWithCost("a", 1, 30)
+	cache.AddWithCost("b", 2, 30)
+	cache.AddWithCost("c", 3, 30)
+	c.Check(cache.UpdateCost("c", 10), gc.Equals, true)
+	c.Check(cache.Cost(), gc.Equals, int64(70))
+	// Growing b pushes out a, the least recently used.
+	c.Check(cache.UpdateCost("b", 61), gc.Equals, true)
+	checkPeekMissing(c, cache, "a")
+	c.Check(cache.Cost(), gc.Equals, int64(71))
+	// It doesn't count as a use, so growing c further pushes out b.
+	c.Check(cache.UpdateCost("c", 50), gc.Equals, true)
+	checkPeekMissing(c, cache, "b")
+	c.Check(cache.Cost(), gc.Equals, int64(50))
+	// An entry that no longer fits at all is removed.
+	c.Check(cache.UpdateCost("c", 101), gc.Equals, true)
+	c.Check(cache.Len(), gc.Equals, 0)
+	c.Check(cache.Cost(), gc.Equals, int64(0))
+	c.Check(cache.UpdateCost("missing", 1), gc.Equals, false)
+}
@@ -0,0 +1,323 @@
+// Copyright 2026 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package lru
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// diskSuffix is the suffix of the files a DiskStore writes, so that it can
+// tell them apart from anything else in its directory.
+const diskSuffix = ".lru"
+
+// diskTempPrefix is the prefix of the temporary files a DiskStore writes
+// entries to before renaming them into place.
+const diskTempPrefix = "tmp-"
+
+// ErrUnsupportedKey is returned by a DiskStore for a key whose file name it
+// can't derive, because the key can't be encoded the same way by every
+// process.
+var ErrUnsupportedKey = errors.New("lru: key cannot be stored on disk")
+
+// DiskStore keeps entries in files in a directory, one file per entry,
+// evicting the least recently used files to keep their total size within a
+// limit. Entries are written with encoding/gob, as Save writes them, so the
+// types of keys and values must be registered with gob.Register, unless they
+// are basic types. Keys themselves must be strings, numbers or bools, types
+// registered with RegisterBinary, or Keyers whose CacheKey is one of those,
+// since they name the files; other keys are rejected with ErrUnsupportedKey.
+// The index of files is itself an LRU created with NewWithMaxCost, where the
+// cost of each file is its size.
+// Note that DiskStore is *not* thread safe, some form of mutex is necessary if
+// you want to access it from multiple threads.
+type DiskStore struct {
+	dir   string
+	index *LRU
+}
+
+// NewDiskStore creates a DiskStore that keeps its files in dir, which is
+// created if needed, using no more than maxBytes. Any files already in dir
+// from an earlier DiskStore are kept, with the most recently modified taken
+// to be the most recently used, so the store can outlive the process.
+// Temporary files left by a DiskStore that crashed while writing are removed.
+func NewDiskStore(dir string, maxBytes int64) (*DiskStore, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	d := &DiskStore{dir: dir}
+	d.index = NewWithMaxCost(maxBytes, WithOnEvict(func(key, _ interface{}, reason EvictionReason) {
+		// A replaced file has already been overwritten.
+		if reason != Replaced {
+			os.Remove(d.path(key.(string)))
+		}
+	}))
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	type file struct {
+		name    string
+		size    int64
+		modTime int64
+	}
+	var files []file
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasPrefix(entry.Name(), diskTempPrefix) {
+			os.Remove(filepath.Join(dir, entry.Name()))
+			continue
+		}
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), diskSuffix) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, file{
+			name:    strings.TrimSuffix(entry.Name(), diskSuffix),
+			size:    info.Size(),
+			modTime: info.ModTime().UnixNano(),
+		})
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].modTime < files[j].modTime
+	})
+	for _, f := range files {
+		d.add(f.name, f.size)
+	}
+	return d, nil
+}
+
+// Len returns the number of entries in the store.
+func (d *DiskStore) Len() int {
+	return d.index.Len()
+}
+
+// SizeBytes returns the total size of the store's files.
+func (d *DiskStore) SizeBytes() int64 {
+	return d.index.Cost()
+}
+
+// Put writes value to the store for key, replacing any value already there.
+// If the entry is larger than the store can hold, it isn't kept.
+func (d *DiskStore) Put(key, value interface{}) error {
+	name, err := d.fileName(key)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(&Entry{Key: key, Value: value}); err != nil {
+		return err
+	}
+	if int64(buf.Len()) > d.index.maxCost {
+		d.index.remove(name)
+		return nil
+	}
+	// Write to a temporary file first, and sync it, so that a crash never
+	// leaves a partly written entry.
+	tmp, err := os.CreateTemp(d.dir, diskTempPrefix)
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(buf.Bytes())
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), d.path(name))
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	d.add(name, int64(buf.Len()))
+	return nil
+}
+
+// Get returns the value in the store for key, and whether there is one. It
+// counts as a use of the entry.
+func (d *DiskStore) Get(key interface{}) (interface{}, bool, error) {
+	name, err := d.fileName(key)
+	if err != nil {
+		return nil, false, err
+	}
+	if _, ok := d.index.Get(name); !ok {
+		return nil, false, nil
+	}
+	data, err := os.ReadFile(d.path(name))
+	if os.IsNotExist(err) {
+		d.index.remove(name)
+		return nil, false, nil
+	} else if err != nil {
+		return nil, false, err
+	}
+	var entry Entry
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&entry); err != nil {
+		return nil, false, fmt.Errorf("reading %s: %w", d.path(name), err)
+	}
+	if mapKey(entry.Key) != mapKey(key) {
+		// Another key with the same file name.
+		return nil, false, nil
+	}
+	return entry.Value, true, nil
+}
+
+// Remove removes the entry for key from the store, if there is one, and
+// returns whether there was.
+func (d *DiskStore) Remove(key interface{}) bool {
+	name, err := d.fileName(key)
+	if err != nil {
+		return false
+	}
+	_, ok := d.index.remove(name)
+	return ok
+}
+
+// add records a file of the given size in the index, evicting others if it
+// doesn't fit.
+func (d *DiskStore) add(name string, size int64) {
+	if size <= 0 {
+		size = 1
+	}
+	d.index.AddWithCost(name, nil, size)
+}
+
+// fileName returns the name of the file, without its suffix, that holds the
+// entry for key. It is a hash of the key as WriteTo encodes it, which is the
+// same in every process, so that files can be found again after a restart.
+// Keys that WriteTo would encode with encoding/gob are rejected, since that
+// isn't guaranteed to give the same bytes each time.
+func (d *DiskStore) fileName(key interface{}) (string, error) {
+	buf := []byte{0}
+	if k, ok := mapKey(key).(keyerKey); ok {
+		buf[0], key = 1, k.key
+	}
+	var err error
+	switch key.(type) {
+	case string, bool, int, int8, int16, int32, int64,
+		uint, uint8, uint16, uint32, uint64, float32, float64:
+		buf, err = appendValue(buf, key)
+	default:
+		var ok bool
+		buf, ok, err = appendBinary(buf, key)
+		if !ok && err == nil {
+			err = fmt.Errorf("%w: %T", ErrUnsupportedKey, key)
+		}
+	}
+	if err != nil {
+		return "", err
+	}
+	h := fnv.New64a()
+	h.Write(buf)
+	return fmt.Sprintf("%016x", h.Sum64()), nil
+}
+
+func (d *DiskStore) path(name string) string {
+	return filepath.Join(d.dir, name+diskSuffix)
+}
+
+// TieredCache is an LRU cache in memory, backed by a DiskStore: entries evicted
+// from memory to make room for others are written to disk, and a key that is
+// not in memory is looked for on disk before Get reports a miss. An entry
+// found on disk is moved back into memory. This suits values that are
+// expensive to compute, but cheap to read back from local storage. Errors
+// writing to or reading from disk are logged (see WithLogger), and otherwise
+// treated as though the entry was not there.
+// Note that TieredCache is *not* thread safe, some form of mutex is necessary
+// if you want to access it from multiple threads.
+type TieredCache struct {
+	memory *LRU
+	disk   *DiskStore
+}
+
+// NewTieredCache creates a TieredCache that holds up to size entries in
+// memory, configured by any options given, and the rest in disk. Any
+// WithOnEvict or WithOnEvictBatch callback is still called, after the entries
+// evicted have been written to disk.
+func NewTieredCache(size int, disk *DiskStore, options ...Option) *TieredCache {
+	c := &TieredCache{
+		memory: New(size, options...),
+		disk:   disk,
+	}
+	onEvict, onEvictBatch := c.memory.onEvict, c.memory.onEvictBatch
+	// Without an OnEvict callback, entries evicted one at a time go to the
+	// OnEvictBatch callback, so only one is wrapped then.
+	if onEvict != nil || onEvictBatch == nil {
+		c.memory.onEvict = func(key, value interface{}, reason EvictionReason) {
+			if reason == CapacityEvicted {
+				c.demote(key, value)
+			}
+			if onEvict != nil {
+				onEvict(key, value, reason)
+			}
+		}
+	}
+	if onEvictBatch != nil {
+		c.memory.onEvictBatch = func(entries []Entry, reason EvictionReason) {
+			if reason == CapacityEvicted {
+				for _, entry := range entries {
+					c.demote(entry.Key, entry.Value)
+				}
+			}
+			onEvictBatch(entries, reason)
+		}
+	}
+	return c
+}
+
+// Len returns the number of entries in memory.
+func (c *TieredCache) Len() int {
+	return c.memory.Len()
+}
+
+// Add adds an entry to the cache in memory, replacing any for the same key in
+// memory or on disk.
+func (c *TieredCache) Add(key, value interface{}) {
+	c.disk.Remove(key)
+	c.memory.Add(key, value)
+}
+
+// Get returns the value for key, from memory if it is there, or else from
+// disk, and whether it was found in either. A value found on disk is moved
+// into memory.
+func (c *TieredCache) Get(key interface{}) (interface{}, bool) {
+	if value, ok := c.memory.Get(key); ok {
+		return value, true
+	}
+	value, ok, err := c.disk.Get(key)
+	if err != nil {
+		c.memory.debugf("lru: cannot read %v from disk: %v", key, err)
+	}
+	if !ok {
+		return nil, false
+	}
+	c.disk.Remove(key)
+	c.memory.Add(key, value)
+	return value, true
+}
+
+// Remove removes the entry for key from memory and disk, and returns whether
+// there was one in either.
+func (c *TieredCache) Remove(key interface{}) bool {
+	_, inMemory := c.memory.remove(key)
+	return c.disk.Remove(key) || inMemory
+}
+
+// demote writes an entry evicted from memory to disk.
+func (c *TieredCache) demote(key, value interface{}) {
+	if err := c.disk.Put(key, value); err != nil {
+		c.memory.debugf("lru: cannot write %v to disk: %v", key, err)
+	}
+}
@@ -0,0 +1,199 @@
+// Copyright 2026 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package lru_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/lru"
+)
+
+type DiskSuite struct{}
+
+var _ = gc.Suite(&DiskSuite{})
+
+func (*DiskSuite) TestDiskStore(c *gc.C) {
+	disk, err := lru.NewDiskStore(c.MkDir(), 1<<20)
+	c.Assert(err, gc.IsNil)
+	c.Assert(disk.Put("a", "one"), gc.IsNil)
+	c.Assert(disk.Put(2, []byte("two")), gc.IsNil)
+	c.Check(disk.Len(), gc.Equals, 2)
+	c.Check(disk.SizeBytes() > 0, gc.Equals, true)
+
+	value, ok, err := disk.Get("a")
+	c.Assert(err, gc.IsNil)
+	c.Check(ok, gc.Equals, true)
+	c.Check(value, gc.Equals, "one")
+	value, ok, err = disk.Get(2)
+	c.Assert(err, gc.IsNil)
+	c.Check(ok, gc.Equals, true)
+	c.Check(value, gc.DeepEquals, []byte("two"))
+	_, ok, err = disk.Get("missing")
+	c.Assert(err, gc.IsNil)
+	c.Check(ok, gc.Equals, false)
+
+	c.Check(disk.Remove("a"), gc.Equals, true)
+	c.Check(disk.Remove("a"), gc.Equals, false)
+	_, ok, _ = disk.Get("a")
+	c.Check(ok, gc.Equals, false)
+}
+
+func (*DiskSuite) TestDiskStoreMaxBytes(c *gc.C) {
+	dir := c.MkDir()
+	disk, err := lru.NewDiskStore(dir, 1<<20)
+	c.Assert(err, gc.IsNil)
+	c.Assert(disk.Put("a", "x"), gc.IsNil)
+	size := disk.SizeBytes()
+
+	// Room for two entries of the same size.
+	disk, err = lru.NewDiskStore(c.MkDir(), 2*size)
+	c.Assert(err, gc.IsNil)
+	c.Assert(disk.Put("a", "x"), gc.IsNil)
+	c.Assert(disk.Put("b", "x"), gc.IsNil)
+	_, _, err = disk.Get("a")
+	c.Assert(err, gc.IsNil)
+	c.Assert(disk.Put("c", "x"), gc.IsNil)
+	c.Check(disk.Len(), gc.Equals, 2)
+	_, ok, _ := disk.Get("b")
+	c.Check(ok, gc.Equals, false)
+	_, ok, _ = disk.Get("a")
+	c.Check(ok, gc.Equals, true)
+
+	// Entries that can never fit aren't kept.
+	c.Assert(disk.Put("big", string(make([]byte, 2*size))), gc.IsNil)
+	_, ok, _ = disk.Get("big")
+	c.Check(ok, gc.Equals, false)
+}
+
+func (*DiskSuite) TestDiskStoreReopen(c *gc.C) {
+	dir := c.MkDir()
+	disk, err := lru.NewDiskStore(dir, 1<<20)
+	c.Assert(err, gc.IsNil)
+	c.Assert(disk.Put("a", 1), gc.IsNil)
+	c.Assert(os.WriteFile(filepath.Join(dir, "other"), nil, 0o600), gc.IsNil)
+
+	disk, err = lru.NewDiskStore(dir, 1<<20)
+	c.Assert(err, gc.IsNil)
+	c.Check(disk.Len(), gc.Equals, 1)
+	value, ok, err := disk.Get("a")
+	c.Assert(err, gc.IsNil)
+	c.Check(ok, gc.Equals, true)
+	c.Check(value, gc.Equals, 1)
+}
+
+func (*DiskSuite) TestDiskStoreRemovesTempFiles(c *gc.C) {
+	dir := c.MkDir()
+	tmp := filepath.Join(dir, "tmp-123")
+	c.Assert(os.WriteFile(tmp, []byte("partial"), 0o600), gc.IsNil)
+	disk, err := lru.NewDiskStore(dir, 1<<20)
+	c.Assert(err, gc.IsNil)
+	c.Check(disk.Len(), gc.Equals, 0)
+	_, err = os.Stat(tmp)
+	c.Check(os.IsNotExist(err), gc.Equals, true)
+}
+
+func (*DiskSuite) TestDiskStoreKeys(c *gc.C) {
+	disk, err := lru.NewDiskStore(c.MkDir(), 1<<20)
+	c.Assert(err, gc.IsNil)
+	c.Assert(disk.Put(0, "int"), gc.IsNil)
+	c.Assert(disk.Put("0", "string"), gc.IsNil)
+	c.Check(disk.Len(), gc.Equals, 2)
+	value, ok, err := disk.Get(0)
+	c.Assert(err, gc.IsNil)
+	c.Check(ok, gc.Equals, true)
+	c.Check(value, gc.Equals, "int")
+
+	// A pointer would name a different file in each process.
+	key := new(int)
+	c.Check(disk.Put(key, 1), gc.ErrorMatches, `.*key cannot be stored on disk: \*int`)
+	_, _, err = disk.Get(key)
+	c.Check(errors.Is(err, lru.ErrUnsupportedKey), gc.Equals, true)
+	c.Check(disk.Remove(key), gc.Equals, false)
+	c.Check(disk.Len(), gc.Equals, 2)
+}
+
+func (*DiskSuite) TestTieredCache(c *gc.C) {
+	disk, err := lru.NewDiskStore(c.MkDir(), 1<<20)
+	c.Assert(err, gc.IsNil)
+	var evicted []interface{}
+	cache := lru.NewTieredCache(2, disk, lru.WithOnEvict(func(key, _ interface{}, _ lru.EvictionReason) {
+		evicted = append(evicted, key)
+	}))
+	cache.Add("a", 1)
+	cache.Add("b", 2)
+	cache.Add("c", 3)
+	c.Check(evicted, gc.DeepEquals, []interface{}{"a"})
+	c.Check(cache.Len(), gc.Equals, 2)
+	c.Check(disk.Len(), gc.Equals, 1)
+
+	// a is found on disk, and moved back into memory, which moves b out.
+	value, ok := cache.Get("a")
+	c.Check(ok, gc.Equals, true)
+	c.Check(value, gc.Equals, 1)
+	c.Check(disk.Len(), gc.Equals, 1)
+	_, ok, _ = disk.Get("b")
+	c.Check(ok, gc.Equals, true)
+
+	_, ok = cache.Get("missing")
+	c.Check(ok, gc.Equals, false)
+
+	c.Check(cache.Remove("b"), gc.Equals, true)
+	_, ok = cache.Get("b")
+	c.Check(ok, gc.Equals, false)
+	c.Check(disk.Len(), gc.Equals, 0)
+}
+
+func (*DiskSuite) TestTieredCacheAddReplacesDisk(c *gc.C) {
+	disk, err := lru.NewDiskStore(c.MkDir(), 1<<20)
+	c.Assert(err, gc.IsNil)
+	cache := lru.NewTieredCache(1, disk)
+	cache.Add("a", 1)
+	cache.Add("b", 2)
+	cache.Add("a", 3)
+	value, ok := cache.Get("a")
+	c.Check(ok, gc.Equals, true)
+	c.Check(value, gc.Equals, 3)
+	c.Check(disk.Len(), gc.Equals, 1)
+}
+
+func (*DiskSuite) TestTieredCacheDemotesBatches(c *gc.C) {
+	disk, err := lru.NewDiskStore(c.MkDir(), 1<<20)
+	c.Assert(err, gc.IsNil)
+	var evicted []interface{}
+	cache := lru.NewTieredCache(3, disk, lru.WithLowWatermark(1), lru.WithOnEvictBatch(func(entries []lru.Entry, _ lru.EvictionReason) {
+		for _, entry := range entries {
+			evicted = append(evicted, entry.Key)
+		}
+	}))
+	cache.Add("a", 1)
+	cache.Add("b", 2)
+	cache.Add("c", 3)
+	cache.Add("d", 4)
+	c.Check(evicted, gc.DeepEquals, []interface{}{"a", "b"})
+	c.Check(cache.Len(), gc.Equals, 2)
+	c.Check(disk.Len(), gc.Equals, 2)
+	value, ok := cache.Get("b")
+	c.Check(ok, gc.Equals, true)
+	c.Check(value, gc.Equals, 2)
+}
+
+type unencodable struct {
+	Ch chan int
+}
+
+func (*DiskSuite) TestTieredCacheLogsErrors(c *gc.C) {
+	disk, err := lru.NewDiskStore(c.MkDir(), 1<<20)
+	c.Assert(err, gc.IsNil)
+	logger := &testLogger{}
+	cache := lru.NewTieredCache(1, disk, lru.WithLogger(logger))
+	cache.Add("a", unencodable{})
+	cache.Add("b", 2)
+	_, ok := cache.Get("a")
+	c.Check(ok, gc.Equals, false)
+	c.Check(logger.messages, gc.Not(gc.HasLen), 0)
+}
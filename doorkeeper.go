@@ -0,0 +1,74 @@
+// Copyright 2026 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package lru
+
+import (
+	"hash/maphash"
+)
+
+// doorkeeperHashes is how many bits the doorkeeper sets for each key.
+const doorkeeperHashes = 4
+
+// doorkeeper is a Bloom filter remembering which keys have been seen
+// recently. It is cleared once it has had more keys added than the cache can
+// hold, so that it doesn't fill up, and so that keys have to be seen twice
+// within a similar period to the cache's own lifetime for entries.
+type doorkeeper struct {
+	seed maphash.Seed
+	bits []uint64
+	// mask selects a bit within bits.
+	mask uint64
+	// additions counts keys added since the filter was last cleared, which
+	// happens when it goes over resetAt.
+	additions int
+	resetAt   int
+}
+
+// newDoorkeeper creates a doorkeeper suitable for a cache holding size
+// entries. It uses about 16 bits per entry, which with 4 hashes keeps false
+// positives to around 0.25% when full.
+func newDoorkeeper(size int) *doorkeeper {
+	width := 64
+	for width < size*16 {
+		width *= 2
+	}
+	return &doorkeeper{
+		seed:    maphash.MakeSeed(),
+		bits:    make([]uint64, width/64),
+		mask:    uint64(width - 1),
+		resetAt: size,
+	}
+}
+
+// add records that key has been seen, and returns whether it had already been
+// seen (or, rarely, whether another key set the same bits).
+func (d *doorkeeper) add(key interface{}) bool {
+	h := hashKey(d.seed, key)
+	// Each bit uses a different combination of the two halves of the hash.
+	h1, h2 := h, h>>32|h<<32
+	seen := true
+	for i := uint64(0); i < doorkeeperHashes; i++ {
+		index := (h1 + i*h2) & d.mask
+		word, bit := &d.bits[index/64], uint64(1)<<(index%64)
+		if *word&bit == 0 {
+			seen = false
+			*word |= bit
+		}
+	}
+	if !seen {
+		d.additions++
+		if d.additions > d.resetAt {
+			d.clear()
+		}
+	}
+	return seen
+}
+
+// clear forgets every key.
+func (d *doorkeeper) clear() {
+	for i := range d.bits {
+		d.bits[i] = 0
+	}
+	d.additions = 0
+}
@@ -0,0 +1,123 @@
+// Copyright 2026 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package lru
+
+import "strconv"
+
+// EvictionReason tells the OnEvict callback why a value left the cache.
+type EvictionReason int
+
+const (
+	// CapacityEvicted is for entries evicted to make room for others, or
+	// by TrimTo.
+	CapacityEvicted EvictionReason = iota
+	// Expired is for entries that expired, or were removed by
+	// EvictOlderThan.
+	Expired
+	// Removed is for entries removed with RemoveIf or RetainOnly.
+	Removed
+	// Replaced is for values overwritten by a new value for the same key.
+	// The key is still in the cache.
+	Replaced
+	// Purged is for entries removed by Purge.
+	Purged
+)
+
+// String returns the name of the reason.
+func (r EvictionReason) String() string {
+	switch r {
+	case CapacityEvicted:
+		return "CapacityEvicted"
+	case Expired:
+		return "Expired"
+	case Removed:
+		return "Removed"
+	case Replaced:
+		return "Replaced"
+	case Purged:
+		return "Purged"
+	}
+	return "EvictionReason(" + strconv.Itoa(int(r)) + ")"
+}
+
+// setValue replaces the value of elem, reporting that the old value has left
+// the cache, and passing it to the OnUpdate callback, if there is one.
+func (lru *LRU) setValue(elem uint32, value interface{}) {
+	entry := &lru.buf[elem]
+	old := entry.value
+	entry.value = value
+	lru.left(entry.key, old, Replaced)
+	if lru.onUpdate != nil {
+		lru.onUpdate(entry.key, old, value)
+	}
+	lru.notifyWatchers(WatchEvent{Kind: WatchUpdated, Key: entry.key, Value: value})
+}
+
+// reportsEvictions returns whether anything needs to be told about entries
+// that leave the cache.
+func (lru *LRU) reportsEvictions() bool {
+	return lru.onEvict != nil || lru.onEvictBatch != nil || lru.drained ||
+		len(lru.watchers) > 0 || lru.logger != nil
+}
+
+// left passes a value that has left the cache to the OnEvict or OnEvictBatch
+// callback, if there is one, tells anything watching its key, and keeps it for
+// DrainEvicted if the cache was created WithDrainEvicted.
+func (lru *LRU) left(key, value interface{}, reason EvictionReason) {
+	switch reason {
+	case Expired:
+		lru.expirations++
+	case Replaced:
+		lru.replacements++
+	}
+	switch {
+	case lru.batching:
+		lru.batch = append(lru.batch, Entry{Key: key, Value: value})
+	case lru.onEvict != nil:
+		lru.onEvict(key, value, reason)
+	case lru.onEvictBatch != nil:
+		lru.onEvictBatch([]Entry{{Key: key, Value: value}}, reason)
+	}
+	if reason != Replaced {
+		lru.debugf("lru: evicted %v (%v)", key, reason)
+		lru.notifyWatchers(WatchEvent{Kind: WatchEvicted, Key: key, Value: value, Reason: reason})
+	}
+	if lru.drained && reason != Replaced {
+		lru.evicted = append(lru.evicted, Entry{Key: key, Value: value})
+	}
+}
+
+// DrainEvicted returns the entries that have left the cache since it was last
+// called, for whatever reason, in the order they left, and forgets them. The
+// cache must have been created WithDrainEvicted.
+func (lru *LRU) DrainEvicted() []Entry {
+	if !lru.drained {
+		panic("DrainEvicted needs the cache to be created WithDrainEvicted")
+	}
+	evicted := lru.evicted
+	lru.evicted = nil
+	return evicted
+}
+
+// beginBatch starts collecting the entries that leave the cache, to pass them
+// to the OnEvictBatch callback together, and returns whether it did. It
+// doesn't if there is no OnEvictBatch callback, or if a batch has already
+// been started, in which case the entries go into that batch.
+func (lru *LRU) beginBatch() bool {
+	if lru.onEvictBatch == nil || lru.batching {
+		return false
+	}
+	lru.batching = true
+	return true
+}
+
+// endBatch passes the entries collected since beginBatch to the OnEvictBatch
+// callback.
+func (lru *LRU) endBatch(reason EvictionReason) {
+	batch := lru.batch
+	lru.batching, lru.batch = false, nil
+	if len(batch) > 0 {
+		lru.onEvictBatch(batch, reason)
+	}
+}
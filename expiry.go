@@ -0,0 +1,321 @@
+// Copyright 2026 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package lru
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+// RemoveExpired removes every entry that has expired, and returns how many
+// were removed. Expired entries are otherwise only removed when they are
+// looked up, so this can be used to free up the room they occupy.
+func (lru *LRU) RemoveExpired() int {
+	if lru.meta == nil {
+		return 0
+	}
+	if lru.beginBatch() {
+		defer lru.endBatch(Expired)
+	}
+	return lru.advanceWheel(lru.nowNano())
+}
+
+// EvictOlderThan removes every entry that was last written or returned by Get
+// before t, and returns how many were removed. The cache must have been
+// created with WithAccessTimes (or another option that records them, such as
+// WithExpiry). As entries are normally kept in order of use, only the entries
+// being removed are looked at.
+func (lru *LRU) EvictOlderThan(t time.Time) int {
+	if lru.meta == nil {
+		panic("EvictOlderThan needs the cache to be created WithAccessTimes")
+	}
+	cutoff := t.UnixNano()
+	if lru.policy != policyLRU {
+		// The entries aren't in order of use, so we have to look at all of
+		// them.
+		return lru.removeWhere(func(elem uint32) bool {
+			return lru.meta[elem].accessed < cutoff
+		}, Expired)
+	}
+	if lru.beginBatch() {
+		defer lru.endBatch(Expired)
+	}
+	removed := 0
+	for elem := lru.root.prev; elem != 0 && lru.meta[elem].accessed < cutoff; elem = lru.root.prev {
+		lru.removeElem(elem, Expired)
+		removed++
+	}
+	return removed
+}
+
+// AgeOf returns how long ago the value for key was written, and whether it is
+// in the cache. It does not affect whether the entry was recently accessed.
+// The cache must have been created with WithAccessTimes (or another option
+// that records them, such as WithExpiry).
+func (lru *LRU) AgeOf(key interface{}) (time.Duration, bool) {
+	if lru.meta == nil {
+		panic("AgeOf needs the cache to be created WithAccessTimes")
+	}
+	elem, ok := lru.lookup(key)
+	if !ok {
+		return 0, false
+	}
+	return lru.clock.Now().Sub(time.Unix(0, lru.meta[elem].stored)), true
+}
+
+// LastAccessed returns when key was last written or returned by Get, and
+// whether it is in the cache. Like AgeOf, it needs the cache to record access
+// times.
+func (lru *LRU) LastAccessed(key interface{}) (time.Time, bool) {
+	if lru.meta == nil {
+		panic("LastAccessed needs the cache to be created WithAccessTimes")
+	}
+	elem, ok := lru.lookup(key)
+	if !ok {
+		return time.Time{}, false
+	}
+	return time.Unix(0, lru.meta[elem].accessed), true
+}
+
+// PeekStale is like Peek, but also returns entries that have expired, with
+// expired set to true, so that a stale value can be served when a fresh one
+// can't be had. An expired entry is only kept until it is removed by Get,
+// RemoveExpired or being evicted. Like Peek, it does not affect whether the
+// entry was recently accessed.
+func (lru *LRU) PeekStale(key interface{}) (value interface{}, expired bool, ok bool) {
+	elem, exists := lru.elements[lru.identity(key)]
+	if !exists {
+		return nil, false, false
+	}
+	return lru.buf[elem].value, lru.expiredAt(elem, lru.nowNano()), true
+}
+
+// SetTTL changes when key expires to ttl from now, replacing the expiry it was
+// given by WithExpiry or an earlier call to SetTTL, and returns whether key is
+// in the cache. If ttl is not positive, key no longer expires because of its
+// age, although it can still time out with WithIdleTimeout. Writing a new
+// value for key gives it the cache's usual expiry again. SetTTL does not
+// affect whether the entry was recently accessed.
+func (lru *LRU) SetTTL(key interface{}, ttl time.Duration) bool {
+	elem, ok := lru.lookup(key)
+	if !ok {
+		return false
+	}
+	lru.ensureWheel(ttl)
+	old := lru.deadline(elem)
+	lru.meta[elem].expires = 0
+	if ttl > 0 {
+		lru.meta[elem].expires = lru.clock.Now().Add(ttl).UnixNano()
+	}
+	lru.deadlineChanged(elem, old)
+	return true
+}
+
+// Extend pushes back when key expires by d, and returns whether key is in the
+// cache. An entry that does not expire because of its age is left alone. Like
+// SetTTL, it does not affect whether the entry was recently accessed.
+func (lru *LRU) Extend(key interface{}, d time.Duration) bool {
+	elem, ok := lru.lookup(key)
+	if !ok {
+		return false
+	}
+	if expires := lru.meta[elem].expires; expires != 0 {
+		old := lru.deadline(elem)
+		lru.meta[elem].expires = expires + int64(d)
+		lru.deadlineChanged(elem, old)
+	}
+	return true
+}
+
+// SetRecomputeTime records how long it takes to compute the value for key, and
+// returns whether key is in the cache. With WithEarlyExpiry, this is used to
+// decide how early Get may start treating the entry as expired. It is kept
+// when a new value is written for key, until the entry is removed.
+func (lru *LRU) SetRecomputeTime(key interface{}, d time.Duration) bool {
+	elem, ok := lru.lookup(key)
+	if !ok {
+		return false
+	}
+	lru.ensureTimes()
+	lru.meta[elem].recompute = int64(d)
+	return true
+}
+
+// expiresEarly returns whether Get should treat elem as having expired at now,
+// even though it has not yet. See WithEarlyExpiry.
+func (lru *LRU) expiresEarly(elem uint32, now int64) bool {
+	if lru.earlyBeta <= 0 {
+		return false
+	}
+	recompute := lru.meta[elem].recompute
+	deadline := lru.deadline(elem)
+	if recompute == 0 || deadline == 0 {
+		return false
+	}
+	// This is the XFetch algorithm, from "Optimal Probabilistic Cache
+	// Stampede Prevention" by Vattani, Chierichetti and Lowenstein. We
+	// use 1-Float64 as Float64 can return 0, but not 1.
+	early := -float64(recompute) * lru.earlyBeta * math.Log(1-rand.Float64())
+	return float64(now)+early >= float64(deadline)
+}
+
+// ensureTimes makes sure the cache is tracking the times of its entries, for
+// when they are first needed after the cache has been created. Existing
+// entries are treated as if they had just been written.
+func (lru *LRU) ensureTimes() {
+	if lru.meta != nil {
+		return
+	}
+	lru.ensureMeta()
+	now := lru.clock.Now().UnixNano()
+	for elem := 1; elem <= lru.size; elem++ {
+		lru.meta[elem].stored = now
+		lru.meta[elem].accessed = now
+	}
+}
+
+// ensureWheel makes sure the cache can track expiry times, for when an entry
+// is given one even though the cache was not created with an option that
+// needs them.
+func (lru *LRU) ensureWheel(timeout time.Duration) {
+	lru.ensureTimes()
+	if lru.wheel == nil {
+		if timeout <= 0 {
+			// Nothing is going to expire yet, so any tick will do.
+			timeout = time.Minute
+		}
+		lru.wheel = newTimingWheel(int64(timeout), lru.clock.Now().UnixNano())
+	}
+}
+
+// entryMeta holds optional information about an entry, for options that need
+// it. It is kept in a slice parallel to buf, which is only allocated when one
+// of those options is in use, so that caches without them don't pay for the
+// extra memory.
+type entryMeta struct {
+	// expires is the time (as UnixNano) from which the entry is no longer
+	// served, or 0 if it never expires.
+	expires int64
+	// stored is the time (as UnixNano) the entry's value was last written,
+	// and added the time it was added to the cache.
+	stored int64
+	added  int64
+	// accessed is the time (as UnixNano) the entry was last written or
+	// returned by Get.
+	accessed int64
+	// recompute is how long (in nanoseconds) the value takes to compute, as
+	// set by SetRecomputeTime.
+	recompute int64
+	// revalidating is set once a stale value has been passed to the
+	// revalidate function of WithStaleWhileRevalidate, until the entry is
+	// written again.
+	revalidating bool
+	// wheelSlot is one more than the slot of the timing wheel holding the
+	// entry, or 0 if it is not in the wheel, and wheelPrev and wheelNext
+	// link it to the other entries in that slot.
+	wheelSlot            uint32
+	wheelPrev, wheelNext uint32
+}
+
+// ensureMeta makes sure that meta has been allocated.
+func (lru *LRU) ensureMeta() {
+	if lru.meta == nil {
+		lru.meta = make([]entryMeta, len(lru.buf))
+	}
+}
+
+// nowNano returns the current time as UnixNano, for passing to expiredAt. We
+// avoid asking the clock unless something could actually expire.
+func (lru *LRU) nowNano() int64 {
+	if lru.meta == nil {
+		return 0
+	}
+	return lru.clock.Now().UnixNano()
+}
+
+// expiredAt returns whether elem has expired at the time now.
+func (lru *LRU) expiredAt(elem uint32, now int64) bool {
+	if lru.meta == nil {
+		return false
+	}
+	deadline := lru.deadline(elem)
+	return deadline != 0 && now >= deadline
+}
+
+// deadline returns the time (as UnixNano) from which elem has expired, or 0 if
+// it never expires.
+func (lru *LRU) deadline(elem uint32) int64 {
+	meta := &lru.meta[elem]
+	deadline := meta.expires
+	if lru.idleTimeout > 0 {
+		idle := meta.accessed + int64(lru.idleTimeout)
+		if deadline == 0 || idle < deadline {
+			deadline = idle
+		}
+	}
+	return deadline
+}
+
+// removeAt returns the time (as UnixNano) from which elem can be removed, or 0
+// if it never expires. This is when it expires, unless stale values are
+// being served for a while after that.
+func (lru *LRU) removeAt(elem uint32) int64 {
+	deadline := lru.deadline(elem)
+	if deadline != 0 {
+		deadline += int64(lru.maxStale)
+	}
+	return deadline
+}
+
+// serveStale returns whether Get should return the value of elem, which has
+// expired at now, asking for it to be revalidated if that has not already
+// been done.
+func (lru *LRU) serveStale(elem uint32, now int64) bool {
+	if lru.revalidate == nil || now >= lru.removeAt(elem) {
+		return false
+	}
+	if meta := &lru.meta[elem]; !meta.revalidating {
+		meta.revalidating = true
+		entry := &lru.buf[elem]
+		lru.revalidate(entry.key, entry.value)
+	}
+	return true
+}
+
+// written updates the information about elem when its value has been set.
+func (lru *LRU) written(elem uint32) {
+	if lru.meta == nil {
+		return
+	}
+	old := lru.deadline(elem)
+	now := lru.clock.Now()
+	// A new value replaces any TTL set on the old one.
+	lru.meta[elem].expires = 0
+	if lru.expiry > 0 {
+		lru.meta[elem].expires = now.Add(lru.expiry).UnixNano()
+	}
+	lru.meta[elem].stored = now.UnixNano()
+	lru.meta[elem].accessed = now.UnixNano()
+	lru.meta[elem].revalidating = false
+	lru.deadlineChanged(elem, old)
+}
+
+// accessed updates the information about elem when it has been returned by
+// Get at the time now.
+func (lru *LRU) accessed(elem uint32, now int64) {
+	if lru.meta != nil {
+		lru.meta[elem].accessed = now
+	}
+}
+
+// shortestTimeout returns the shortest of the expiry and idle timeout that
+// are set, or 0 if neither is.
+func (lru *LRU) shortestTimeout() time.Duration {
+	timeout := lru.expiry
+	if lru.idleTimeout > 0 && (timeout == 0 || lru.idleTimeout < timeout) {
+		timeout = lru.idleTimeout
+	}
+	return timeout
+}
@@ -0,0 +1,592 @@
+// Copyright 2026 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package lru_test
+
+import (
+	"math"
+	"math/rand"
+	"time"
+
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/lru"
+)
+
+type ExpirySuite struct {
+	clock *testClock
+}
+
+var _ = gc.Suite(&ExpirySuite{})
+
+func (s *ExpirySuite) SetUpTest(c *gc.C) {
+	s.clock = newTestClock()
+}
+
+func (s *ExpirySuite) TestWithExpiry(c *gc.C) {
+	cache := lru.New(10, lru.WithClock(s.clock), lru.WithExpiry(time.Minute))
+	cache.Add("a", 1)
+	checkPeekExists(c, cache, "a", 1)
+	checkGet(c, cache, "a", 1, true)
+	s.clock.Advance(time.Minute)
+	checkPeekMissing(c, cache, "a")
+	c.Check(cache.Len(), gc.Equals, 1)
+	checkGet(c, cache, "a", nil, false)
+	c.Check(cache.Len(), gc.Equals, 0)
+}
+
+func (s *ExpirySuite) TestExpiryIsFromWrite(c *gc.C) {
+	cache := lru.New(10, lru.WithClock(s.clock), lru.WithExpiry(time.Minute))
+	cache.Add("a", 1)
+	cache.Add("b", 2)
+	s.clock.Advance(30 * time.Second)
+	cache.Get("b")
+	s.clock.Advance(30 * time.Second)
+	// Rewriting the value resets its age
+	cache.Add("a", 3)
+	checkPeekExists(c, cache, "a", 3)
+	checkPeekMissing(c, cache, "b")
+	c.Check(cache.PeekMostRecentN(10), gc.DeepEquals, []lru.Entry{{Key: "a", Value: 3}})
+}
+
+func (s *ExpirySuite) TestUpdateSeesExpiredAsMissing(c *gc.C) {
+	cache := lru.New(10, lru.WithClock(s.clock), lru.WithExpiry(time.Minute))
+	cache.Add("a", 1)
+	s.clock.Advance(time.Minute)
+	cache.Update("a", func(old interface{}, exists bool) (interface{}, bool) {
+		c.Check(exists, gc.Equals, false)
+		c.Check(old, gc.IsNil)
+		return 2, true
+	})
+	checkPeekExists(c, cache, "a", 2)
+	c.Check(cache.Len(), gc.Equals, 1)
+}
+
+func (s *ExpirySuite) TestRemoveIfSkipsExpired(c *gc.C) {
+	cache := lru.New(10, lru.WithClock(s.clock), lru.WithExpiry(time.Minute))
+	cache.Add("a", 1)
+	s.clock.Advance(time.Minute)
+	cache.Add("b", 2)
+	var seen []interface{}
+	removed := cache.RemoveIf(func(key, value interface{}) bool {
+		seen = append(seen, key)
+		return true
+	})
+	c.Check(removed, gc.Equals, 1)
+	c.Check(seen, gc.DeepEquals, []interface{}{"b"})
+}
+
+func (s *ExpirySuite) TestExpiryAfterRemove(c *gc.C) {
+	// Removing entries moves others around in the buffer, make sure their
+	// expiry times move with them.
+	cache := lru.New(10, lru.WithClock(s.clock), lru.WithExpiry(time.Minute))
+	cache.Add("old", 1)
+	s.clock.Advance(time.Minute)
+	cache.Add("a", 2)
+	cache.Add("b", 3)
+	cache.RemoveIf(func(key, value interface{}) bool { return key == "a" })
+	checkPeekMissing(c, cache, "old")
+	checkPeekExists(c, cache, "b", 3)
+}
+
+func (s *ExpirySuite) TestWithIdleTimeout(c *gc.C) {
+	cache := lru.New(10, lru.WithClock(s.clock), lru.WithIdleTimeout(time.Minute))
+	cache.Add("a", 1)
+	cache.Add("b", 2)
+	// Keep "a" alive by using it, "b" is only peeked at.
+	for i := 0; i < 4; i++ {
+		s.clock.Advance(30 * time.Second)
+		checkGet(c, cache, "a", 1, true)
+		cache.Peek("b")
+	}
+	checkPeekExists(c, cache, "a", 1)
+	checkPeekMissing(c, cache, "b")
+	s.clock.Advance(time.Minute)
+	checkGet(c, cache, "a", nil, false)
+}
+
+func (s *ExpirySuite) TestIdleTimeoutAndExpiry(c *gc.C) {
+	cache := lru.New(10, lru.WithClock(s.clock),
+		lru.WithExpiry(90*time.Second), lru.WithIdleTimeout(time.Minute))
+	cache.Add("a", 1)
+	for i := 0; i < 3; i++ {
+		s.clock.Advance(30 * time.Second)
+		cache.Get("a")
+	}
+	// Although "a" was never idle, it was written too long ago.
+	checkPeekMissing(c, cache, "a")
+}
+
+func (s *ExpirySuite) TestRemoveExpired(c *gc.C) {
+	cache := lru.New(10, lru.WithClock(s.clock), lru.WithExpiry(time.Minute))
+	for i := 0; i < 5; i++ {
+		cache.Add(i, i)
+	}
+	s.clock.Advance(time.Minute)
+	cache.Add("fresh", 1)
+	c.Check(cache.Len(), gc.Equals, 6)
+	c.Check(cache.RemoveExpired(), gc.Equals, 5)
+	c.Check(cache.Len(), gc.Equals, 1)
+	checkPeekExists(c, cache, "fresh", 1)
+}
+
+func (s *ExpirySuite) TestRemoveExpiredNoExpiry(c *gc.C) {
+	cache := simpleFullCache()
+	c.Check(cache.RemoveExpired(), gc.Equals, 0)
+	c.Check(cache.Len(), gc.Equals, 10)
+}
+
+func (s *ExpirySuite) TestReaper(c *gc.C) {
+	cache := lru.NewSync(10, lru.WithClock(s.clock), lru.WithExpiry(time.Minute))
+	defer cache.Close()
+	cache.Add("a", 1)
+	cache.StartReaper(time.Millisecond)
+	s.clock.Advance(time.Minute)
+	waitForLen(c, cache.Len, 0)
+	// Close is idempotent, and the cache still works afterwards
+	cache.Close()
+	cache.Close()
+	cache.Add("b", 2)
+	checkSyncPeek(c, cache, "b", 2)
+}
+
+func (s *ExpirySuite) TestShardedReaper(c *gc.C) {
+	cache := lru.NewSharded(4, 100, lru.WithClock(s.clock), lru.WithExpiry(time.Minute))
+	defer cache.Close()
+	for i := 0; i < 20; i++ {
+		cache.Add(i, i)
+	}
+	cache.StartReaper(time.Millisecond)
+	s.clock.Advance(time.Minute)
+	waitForLen(c, cache.Len, 0)
+}
+
+// waitForLen waits for a background goroutine to bring the cache to the
+// expected length.
+func waitForLen(c *gc.C, lenFn func() int, expected int) {
+	deadline := time.Now().Add(10 * time.Second)
+	for lenFn() != expected {
+		if time.Now().After(deadline) {
+			c.Fatalf("cache length never reached %d, still %d", expected, lenFn())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func (s *ExpirySuite) TestRemoveExpiredMatchesScan(c *gc.C) {
+	// RemoveExpired uses a timing wheel rather than looking at every entry,
+	// check that it agrees with what is visible in the cache, with entries
+	// being added, used and removed over widely varying stretches of time.
+	cache := lru.New(200, lru.WithClock(s.clock),
+		lru.WithExpiry(time.Hour), lru.WithIdleTimeout(time.Minute))
+	rand := rand.New(rand.NewSource(1))
+	steps := []time.Duration{0, time.Millisecond, time.Second, 10 * time.Second, time.Minute, 3 * time.Hour, 1000 * time.Hour}
+	for i := 0; i < 20000; i++ {
+		key := rand.Intn(300)
+		switch rand.Intn(5) {
+		case 0, 1:
+			cache.Add(key, i)
+		case 2:
+			cache.Get(key)
+		case 3:
+			cache.RemoveIf(func(k, _ interface{}) bool { return k == key })
+		case 4:
+			// TTLs from well under a tick to far beyond the top level of
+			// the wheel.
+			cache.SetTTL(key, time.Duration(rand.Int63n(int64(1<<uint(rand.Intn(60))))))
+		}
+		if rand.Intn(10) == 0 {
+			s.clock.Advance(steps[rand.Intn(len(steps))] + time.Duration(rand.Intn(1000))*time.Millisecond)
+		}
+		if rand.Intn(20) == 0 {
+			live := 0
+			cache.Range(func(_, _ interface{}) bool {
+				live++
+				return true
+			})
+			expired := cache.Len() - live
+			c.Assert(cache.RemoveExpired(), gc.Equals, expired)
+			c.Assert(cache.Len(), gc.Equals, live)
+		}
+	}
+}
+
+func (s *ExpirySuite) TestEvictOlderThan(c *gc.C) {
+	cache := lru.New(10, lru.WithClock(s.clock), lru.WithAccessTimes())
+	cache.Add("a", 1)
+	cache.Add("b", 2)
+	s.clock.Advance(time.Second)
+	cutover := s.clock.Now()
+	cache.Add("c", 3)
+	cache.Get("a")
+	s.clock.Advance(time.Second)
+	cache.Add("d", 4)
+	// Peek does not count as a use
+	cache.Peek("b")
+	c.Check(cache.EvictOlderThan(cutover), gc.Equals, 1)
+	checkPeekMissing(c, cache, "b")
+	c.Check(collectKeys(cache.Range), gc.DeepEquals, []interface{}{"d", "a", "c"})
+	c.Check(cache.EvictOlderThan(cutover), gc.Equals, 0)
+	c.Check(cache.EvictOlderThan(s.clock.Now().Add(time.Nanosecond)), gc.Equals, 3)
+	c.Check(cache.Len(), gc.Equals, 0)
+}
+
+func (s *ExpirySuite) TestEvictOlderThanWithoutAccessTimes(c *gc.C) {
+	cache := simpleFullCache()
+	c.Check(func() { cache.EvictOlderThan(time.Now()) }, gc.PanicMatches, "EvictOlderThan needs .*")
+}
+
+func (s *ExpirySuite) TestShardedEvictOlderThan(c *gc.C) {
+	cache := lru.NewSharded(4, 100, lru.WithClock(s.clock), lru.WithAccessTimes())
+	for i := 0; i < 20; i++ {
+		cache.Add(i, i)
+	}
+	s.clock.Advance(time.Second)
+	cutover := s.clock.Now()
+	for i := 0; i < 5; i++ {
+		cache.Get(i)
+	}
+	c.Check(cache.EvictOlderThan(cutover), gc.Equals, 15)
+	c.Check(cache.Len(), gc.Equals, 5)
+}
+
+func (s *ExpirySuite) TestAgeOfAndLastAccessed(c *gc.C) {
+	cache := lru.New(10, lru.WithClock(s.clock), lru.WithAccessTimes())
+	added := s.clock.Now()
+	cache.Add("a", 1)
+	cache.Add("b", 2)
+	s.clock.Advance(time.Minute)
+	used := s.clock.Now()
+	cache.Get("a")
+	s.clock.Advance(time.Minute)
+
+	age, ok := cache.AgeOf("a")
+	c.Check(ok, gc.Equals, true)
+	c.Check(age, gc.Equals, 2*time.Minute)
+	accessed, ok := cache.LastAccessed("a")
+	c.Check(ok, gc.Equals, true)
+	c.Check(accessed.Equal(used), gc.Equals, true)
+	accessed, ok = cache.LastAccessed("b")
+	c.Check(ok, gc.Equals, true)
+	c.Check(accessed.Equal(added), gc.Equals, true)
+	// Neither of them counts as a use.
+	c.Check(collectKeys(cache.Range), gc.DeepEquals, []interface{}{"a", "b"})
+
+	// Writing a new value resets both.
+	cache.Add("b", 3)
+	age, _ = cache.AgeOf("b")
+	c.Check(age, gc.Equals, time.Duration(0))
+	accessed, _ = cache.LastAccessed("b")
+	c.Check(accessed.Equal(s.clock.Now()), gc.Equals, true)
+
+	_, ok = cache.AgeOf("missing")
+	c.Check(ok, gc.Equals, false)
+	_, ok = cache.LastAccessed("missing")
+	c.Check(ok, gc.Equals, false)
+}
+
+func (s *ExpirySuite) TestAgeOfExpired(c *gc.C) {
+	cache := lru.New(10, lru.WithClock(s.clock), lru.WithExpiry(time.Minute))
+	cache.Add("a", 1)
+	s.clock.Advance(time.Minute)
+	_, ok := cache.AgeOf("a")
+	c.Check(ok, gc.Equals, false)
+}
+
+func (s *ExpirySuite) TestSyncLastAccessed(c *gc.C) {
+	cache := lru.NewSync(10, lru.WithClock(s.clock), lru.WithAccessTimes())
+	cache.Add("a", 1)
+	s.clock.Advance(time.Minute)
+	cache.Get("a")
+	accessed, ok := cache.LastAccessed("a")
+	c.Check(ok, gc.Equals, true)
+	c.Check(accessed.Equal(s.clock.Now()), gc.Equals, true)
+	age, ok := cache.AgeOf("a")
+	c.Check(ok, gc.Equals, true)
+	c.Check(age, gc.Equals, time.Minute)
+}
+
+func (s *ExpirySuite) TestSetTTL(c *gc.C) {
+	cache := lru.New(10, lru.WithClock(s.clock), lru.WithExpiry(time.Minute))
+	cache.Add("short", 1)
+	cache.Add("long", 2)
+	cache.Add("forever", 3)
+	c.Check(cache.SetTTL("short", time.Second), gc.Equals, true)
+	c.Check(cache.SetTTL("long", time.Hour), gc.Equals, true)
+	c.Check(cache.SetTTL("forever", 0), gc.Equals, true)
+	c.Check(cache.SetTTL("missing", time.Hour), gc.Equals, false)
+	// SetTTL is not a use
+	c.Check(collectKeys(cache.Range), gc.DeepEquals, []interface{}{"forever", "long", "short"})
+
+	s.clock.Advance(time.Second)
+	c.Check(cache.RemoveExpired(), gc.Equals, 1)
+	checkPeekMissing(c, cache, "short")
+	s.clock.Advance(time.Minute)
+	checkPeekExists(c, cache, "long", 2)
+	s.clock.Advance(time.Hour)
+	c.Check(cache.RemoveExpired(), gc.Equals, 1)
+	checkPeekMissing(c, cache, "long")
+	s.clock.Advance(1000 * time.Hour)
+	checkPeekExists(c, cache, "forever", 3)
+
+	// A new value gets the usual expiry.
+	cache.Add("forever", 4)
+	s.clock.Advance(time.Minute)
+	checkPeekMissing(c, cache, "forever")
+	c.Check(cache.SetTTL("forever", time.Hour), gc.Equals, false)
+}
+
+func (s *ExpirySuite) TestSetTTLWithoutExpiry(c *gc.C) {
+	cache := lru.New(10, lru.WithClock(s.clock))
+	cache.Add("a", 1)
+	cache.Add("b", 2)
+	c.Check(cache.SetTTL("a", time.Minute), gc.Equals, true)
+	s.clock.Advance(time.Minute)
+	checkPeekMissing(c, cache, "a")
+	checkPeekExists(c, cache, "b", 2)
+	c.Check(cache.RemoveExpired(), gc.Equals, 1)
+	c.Check(cache.Len(), gc.Equals, 1)
+}
+
+func (s *ExpirySuite) TestExtend(c *gc.C) {
+	cache := lru.New(10, lru.WithClock(s.clock), lru.WithExpiry(time.Minute))
+	cache.Add("a", 1)
+	cache.Add("b", 2)
+	s.clock.Advance(30 * time.Second)
+	c.Check(cache.Extend("a", time.Minute), gc.Equals, true)
+	c.Check(cache.Extend("missing", time.Minute), gc.Equals, false)
+	s.clock.Advance(time.Minute)
+	checkPeekExists(c, cache, "a", 1)
+	checkPeekMissing(c, cache, "b")
+	s.clock.Advance(30 * time.Second)
+	checkPeekMissing(c, cache, "a")
+
+	// Extending by a negative amount brings the expiry forward.
+	cache.Add("c", 3)
+	c.Check(cache.Extend("c", -30*time.Second), gc.Equals, true)
+	s.clock.Advance(30 * time.Second)
+	c.Check(cache.RemoveExpired(), gc.Equals, 3)
+}
+
+func (s *ExpirySuite) TestSyncSetTTL(c *gc.C) {
+	cache := lru.NewSync(10, lru.WithClock(s.clock))
+	cache.Add("a", 1)
+	c.Check(cache.SetTTL("a", time.Minute), gc.Equals, true)
+	c.Check(cache.Extend("a", time.Minute), gc.Equals, true)
+	s.clock.Advance(time.Minute)
+	checkSyncPeek(c, cache, "a", 1)
+	s.clock.Advance(time.Minute)
+	c.Check(cache.RemoveExpired(), gc.Equals, 1)
+}
+
+func (s *ExpirySuite) TestPeekStale(c *gc.C) {
+	cache := lru.New(10, lru.WithClock(s.clock), lru.WithExpiry(time.Minute))
+	cache.Add("a", 1)
+	cache.Add("b", 2)
+	value, expired, ok := cache.PeekStale("a")
+	c.Check(value, gc.Equals, 1)
+	c.Check(expired, gc.Equals, false)
+	c.Check(ok, gc.Equals, true)
+
+	s.clock.Advance(time.Minute)
+	checkPeekMissing(c, cache, "a")
+	value, expired, ok = cache.PeekStale("a")
+	c.Check(value, gc.Equals, 1)
+	c.Check(expired, gc.Equals, true)
+	c.Check(ok, gc.Equals, true)
+	// PeekStale is not a use
+	c.Check(cache.PeekLeastRecentN(10), gc.HasLen, 0)
+	c.Check(cache.Len(), gc.Equals, 2)
+
+	// Once Get has removed it, it is gone.
+	checkGet(c, cache, "a", nil, false)
+	_, _, ok = cache.PeekStale("a")
+	c.Check(ok, gc.Equals, false)
+}
+
+func (s *ExpirySuite) TestSyncPeekStale(c *gc.C) {
+	cache := lru.NewSync(10, lru.WithClock(s.clock), lru.WithExpiry(time.Minute))
+	cache.Add("a", 1)
+	s.clock.Advance(time.Minute)
+	value, expired, ok := cache.PeekStale("a")
+	c.Check(value, gc.Equals, 1)
+	c.Check(expired, gc.Equals, true)
+	c.Check(ok, gc.Equals, true)
+}
+
+func (s *ExpirySuite) TestStaleWhileRevalidate(c *gc.C) {
+	var revalidated []lru.Entry
+	cache := lru.New(10, lru.WithClock(s.clock), lru.WithExpiry(time.Minute),
+		lru.WithStaleWhileRevalidate(time.Minute, func(key, stale interface{}) {
+			revalidated = append(revalidated, lru.Entry{Key: key, Value: stale})
+		}))
+	cache.Add("a", 1)
+	cache.Add("b", 2)
+	s.clock.Advance(time.Minute)
+	checkGet(c, cache, "a", 1, true)
+	checkGet(c, cache, "a", 1, true)
+	c.Check(revalidated, gc.DeepEquals, []lru.Entry{{Key: "a", Value: 1}})
+	// Only Get sees stale values.
+	checkPeekMissing(c, cache, "a")
+	// And they are kept by RemoveExpired.
+	c.Check(cache.RemoveExpired(), gc.Equals, 0)
+
+	// Once written, the entry is fresh, and will be revalidated again when
+	// it next expires.
+	cache.Add("a", 3)
+	checkGet(c, cache, "a", 3, true)
+	s.clock.Advance(time.Minute)
+	checkGet(c, cache, "a", 3, true)
+	c.Check(revalidated, gc.HasLen, 2)
+
+	// "b" was never revalidated, and is now too stale to serve.
+	checkGet(c, cache, "b", nil, false)
+	c.Check(cache.Len(), gc.Equals, 1)
+	s.clock.Advance(time.Minute)
+	c.Check(cache.RemoveExpired(), gc.Equals, 1)
+}
+
+func (s *ExpirySuite) TestStaleWhileRevalidateIdle(c *gc.C) {
+	cache := lru.New(10, lru.WithClock(s.clock), lru.WithIdleTimeout(time.Minute),
+		lru.WithStaleWhileRevalidate(time.Minute, func(key, stale interface{}) {}))
+	cache.Add("a", 1)
+	s.clock.Advance(time.Minute)
+	// Serving a stale value does not keep the entry alive.
+	checkGet(c, cache, "a", 1, true)
+	s.clock.Advance(time.Minute)
+	checkGet(c, cache, "a", nil, false)
+}
+
+func (s *ExpirySuite) TestSyncStaleWhileRevalidate(c *gc.C) {
+	var cache *lru.SyncLRU
+	cache = lru.NewSync(10, lru.WithClock(s.clock), lru.WithExpiry(time.Minute),
+		lru.WithStaleWhileRevalidate(time.Hour, func(key, stale interface{}) {
+			go cache.Add(key, stale.(int)+1)
+		}))
+	cache.Add("a", 1)
+	s.clock.Advance(time.Minute)
+	value, ok := cache.Get("a")
+	c.Check(ok, gc.Equals, true)
+	c.Check(value, gc.Equals, 1)
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		if value, ok := cache.Peek("a"); ok {
+			c.Check(value, gc.Equals, 2)
+			break
+		}
+		if time.Now().After(deadline) {
+			c.Fatalf("value was never refreshed")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func (s *ExpirySuite) TestEarlyExpiry(c *gc.C) {
+	cache := lru.New(10, lru.WithClock(s.clock), lru.WithExpiry(time.Hour), lru.WithEarlyExpiry(1))
+	cache.Add("a", 1)
+	cache.Add("b", 2)
+	c.Check(cache.SetRecomputeTime("a", time.Minute), gc.Equals, true)
+	c.Check(cache.SetRecomputeTime("missing", time.Minute), gc.Equals, false)
+	// Far from expiring, an early miss is vanishingly unlikely.
+	for i := 0; i < 100; i++ {
+		checkGet(c, cache, "a", 1, true)
+	}
+	// With ln(2) recompute times to go, half of all Gets should miss.
+	remaining := math.Ln2 * float64(time.Minute)
+	s.clock.Advance(time.Hour - time.Duration(remaining))
+	misses := 0
+	for i := 0; i < 1000; i++ {
+		if _, ok := cache.Get("a"); !ok {
+			misses++
+		}
+		// Entries without a recompute time never miss early.
+		checkGet(c, cache, "b", 2, true)
+	}
+	c.Check(misses > 400 && misses < 600, gc.Equals, true, gc.Commentf("%d misses", misses))
+	// Early misses don't remove the entry.
+	checkPeekExists(c, cache, "a", 1)
+
+	// The recompute time is kept when the value is rewritten.
+	cache.Add("a", 3)
+	s.clock.Advance(time.Hour - time.Second)
+	misses = 0
+	for i := 0; i < 100; i++ {
+		if _, ok := cache.Get("a"); !ok {
+			misses++
+		}
+	}
+	c.Check(misses > 90, gc.Equals, true, gc.Commentf("%d misses", misses))
+}
+
+func (s *ExpirySuite) TestSyncEarlyExpiry(c *gc.C) {
+	cache := lru.NewSync(10, lru.WithClock(s.clock), lru.WithExpiry(time.Hour), lru.WithEarlyExpiry(1))
+	cache.Add("a", 1)
+	c.Check(cache.SetRecomputeTime("a", time.Hour), gc.Equals, true)
+	s.clock.Advance(time.Hour - time.Second)
+	misses := 0
+	for i := 0; i < 100; i++ {
+		if _, ok := cache.Get("a"); !ok {
+			misses++
+		}
+	}
+	c.Check(misses > 90, gc.Equals, true, gc.Commentf("%d misses", misses))
+}
+
+func (s *ExpirySuite) TestEvictionAges(c *gc.C) {
+	cache := lru.New(2, lru.WithClock(s.clock), lru.WithEvictionAges())
+	cache.Add("a", 1)
+	cache.Add("b", 2)
+	s.clock.Advance(time.Second)
+	cache.Get("a")
+	s.clock.Advance(time.Second)
+	// b has been there for 2s without being used.
+	cache.Add("c", 3)
+	s.clock.Advance(time.Second)
+	// a has been there for 3s, and was used 2s ago.
+	cache.Add("d", 4)
+	// Removing an entry doesn't count.
+	cache.RemoveIf(func(key, value interface{}) bool { return key == "c" })
+	ages := cache.EvictionAges()
+	c.Check(ages.Lifetime.Count, gc.Equals, int64(2))
+	c.Check(ages.Lifetime.Mean(), gc.Equals, 2500*time.Millisecond)
+	c.Check(ages.Lifetime.Percentile(0.5), gc.Equals, lru.BucketBound(21))
+	c.Check(ages.Lifetime.Percentile(1), gc.Equals, lru.BucketBound(22))
+	c.Check(ages.Idle.Mean(), gc.Equals, 2*time.Second)
+	c.Check(ages.Idle.Percentile(0.99), gc.Equals, lru.BucketBound(21))
+	c.Check(lru.BucketBound(21) > 2*time.Second, gc.Equals, true)
+	c.Check(lru.BucketBound(20) > 2*time.Second, gc.Equals, false)
+}
+
+func (s *ExpirySuite) TestEvictionAgesNeedsOption(c *gc.C) {
+	cache := lru.New(2)
+	c.Check(func() { cache.EvictionAges() }, gc.PanicMatches, "EvictionAges needs the cache to be created WithEvictionAges")
+}
+
+func (s *ExpirySuite) TestEntryAges(c *gc.C) {
+	cache := lru.New(10, lru.WithClock(s.clock), lru.WithExpiry(time.Hour))
+	cache.Add("old", 1)
+	s.clock.Advance(time.Hour - time.Second)
+	cache.Add("a", 2)
+	s.clock.Advance(time.Second)
+	// old has expired, and isn't counted.
+	cache.Add("b", 3)
+	ages := cache.EntryAges()
+	c.Check(ages.Age.Count, gc.Equals, int64(2))
+	c.Check(ages.Age.Mean(), gc.Equals, 500*time.Millisecond)
+	c.Check(ages.Age.Percentile(0.5), gc.Equals, lru.BucketBound(0))
+	c.Check(ages.Age.Percentile(1), gc.Equals, lru.BucketBound(20))
+
+	s.clock.Advance(time.Second)
+	cache.Get("a")
+	cache.Update("b", func(old interface{}, exists bool) (interface{}, bool) {
+		return 4, true
+	})
+	ages = cache.EntryAges()
+	c.Check(ages.Age.Mean(), gc.Equals, 1500*time.Millisecond)
+	c.Check(ages.Idle.Mean(), gc.Equals, time.Duration(0))
+}
+
+func (s *ExpirySuite) TestEntryAgesNeedsAccessTimes(c *gc.C) {
+	cache := lru.New(2)
+	c.Check(func() { cache.EntryAges() }, gc.PanicMatches, "EntryAges needs the cache to be created WithAccessTimes")
+}
@@ -0,0 +1,24 @@
+// Copyright 2026 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package lru
+
+import "expvar"
+
+// PublishExpvar publishes the cache's Stats as an expvar variable with the
+// given name, so they are served with the rest of the process's expvars. The
+// stats are read each time the variable is. Like expvar.Publish, it panics if
+// the name is already in use.
+func (s *SyncLRU) PublishExpvar(name string) {
+	expvar.Publish(name, expvar.Func(func() interface{} {
+		return s.Stats()
+	}))
+}
+
+// PublishExpvar publishes the totals of the shards' Stats as an expvar
+// variable with the given name. See SyncLRU.PublishExpvar.
+func (s *ShardedLRU) PublishExpvar(name string) {
+	expvar.Publish(name, expvar.Func(func() interface{} {
+		return s.Stats()
+	}))
+}
@@ -0,0 +1,58 @@
+// Copyright 2026 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package lru
+
+import "sync/atomic"
+
+// WithGhostKeys remembers the keys (but not the values) of the n entries most
+// recently evicted to make room for others, so that EstimatedHitCounts can
+// estimate how many more Gets would find their key if the cache could hold n
+// more entries. Setting n to the size of the cache estimates the effect of
+// doubling it. Remembering a key costs about as much as an entry with a nil
+// value. n must be more than 0, since remembering every key evicted would use
+// ever more memory.
+func WithGhostKeys(n int) Option {
+	if n <= 0 {
+		panic("n must be > 0")
+	}
+	return func(lru *LRU) {
+		lru.ghost = New(n)
+	}
+}
+
+// EstimatedHitCounts estimates what HitCounts would be if the cache could
+// hold as many more entries as it remembers keys for, by counting the Gets
+// that missed, but would have found their key if it hadn't been evicted. It
+// is an estimate, as a larger cache would also have evicted different
+// entries. The cache must have been created WithGhostKeys.
+func (lru *LRU) EstimatedHitCounts() HitCounts {
+	if lru.ghost == nil {
+		panic("EstimatedHitCounts needs the cache to be created WithGhostKeys")
+	}
+	counts := lru.HitCounts()
+	ghostHits := atomic.LoadInt64(&lru.ghostHits)
+	counts.Hit += ghostHits
+	counts.Miss -= ghostHits
+	return counts
+}
+
+// countGhost records a Get that missed, if it would have found key had it not
+// been evicted. It only reads the ghost keys, so it is safe under a read lock.
+func (lru *LRU) countGhost(key interface{}) {
+	if lru.ghost == nil {
+		return
+	}
+	if _, ok := lru.ghost.elements[lru.identity(key)]; ok {
+		atomic.AddInt64(&lru.ghostHits, 1)
+	}
+}
+
+// evicting is called when elem is about to be evicted to make room for other
+// entries.
+func (lru *LRU) evicting(elem uint32) {
+	lru.recordEvictionAge(elem)
+	if lru.ghost != nil {
+		lru.ghost.Add(lru.buf[elem].key, nil)
+	}
+}
@@ -0,0 +1,107 @@
+// Copyright 2026 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package lru
+
+import (
+	"hash/maphash"
+	"math"
+	"reflect"
+)
+
+// hashKey returns a hash for key, such that keys that are == to each other
+// hash to the same value. Common key types are hashed directly, anything else
+// is hashed field by field with hashValue.
+func hashKey(seed maphash.Seed, key interface{}) uint64 {
+	switch k := key.(type) {
+	case string:
+		var h maphash.Hash
+		h.SetSeed(seed)
+		h.WriteString(k)
+		return h.Sum64()
+	case int:
+		return mixHash(seed, uint64(k))
+	case int64:
+		return mixHash(seed, uint64(k))
+	case int32:
+		return mixHash(seed, uint64(k))
+	case uint:
+		return mixHash(seed, uint64(k))
+	case uint64:
+		return mixHash(seed, k)
+	case uint32:
+		return mixHash(seed, uint64(k))
+	default:
+		var h maphash.Hash
+		h.SetSeed(seed)
+		hashValue(&h, reflect.ValueOf(key))
+		return h.Sum64()
+	}
+}
+
+// hashValue writes v to h, in the same way for values that are == to each
+// other. Pointers, channels and funcs are == only if they are the same, so
+// they are hashed by address, not by what they point to, which may change
+// while they are in the cache. Slices and maps can't be keys, so they are
+// not hashed.
+func hashValue(h *maphash.Hash, v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Invalid:
+		// A nil interface.
+		h.WriteByte(0)
+	case reflect.Bool:
+		if v.Bool() {
+			h.WriteByte(1)
+		} else {
+			h.WriteByte(0)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		writeUint64(h, uint64(v.Int()))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		writeUint64(h, v.Uint())
+	case reflect.Float32, reflect.Float64:
+		writeFloat(h, v.Float())
+	case reflect.Complex64, reflect.Complex128:
+		c := v.Complex()
+		writeFloat(h, real(c))
+		writeFloat(h, imag(c))
+	case reflect.String:
+		h.WriteString(v.String())
+	case reflect.Ptr, reflect.Chan, reflect.Func, reflect.UnsafePointer:
+		writeUint64(h, uint64(v.Pointer()))
+	case reflect.Interface:
+		hashValue(h, v.Elem())
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			hashValue(h, v.Index(i))
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			hashValue(h, v.Field(i))
+		}
+	}
+}
+
+func writeUint64(h *maphash.Hash, v uint64) {
+	var b [8]byte
+	for i := range b {
+		b[i] = byte(v >> (8 * i))
+	}
+	h.Write(b[:])
+}
+
+// writeFloat writes f to h, treating -0 as 0, as == does.
+func writeFloat(h *maphash.Hash, f float64) {
+	if f == 0 {
+		f = 0
+	}
+	writeUint64(h, math.Float64bits(f))
+}
+
+// mixHash hashes an integer key, so that sequential keys are spread evenly.
+func mixHash(seed maphash.Seed, v uint64) uint64 {
+	var h maphash.Hash
+	h.SetSeed(seed)
+	writeUint64(&h, v)
+	return h.Sum64()
+}
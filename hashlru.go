@@ -0,0 +1,212 @@
+// Copyright 2026 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package lru
+
+import (
+	"fmt"
+)
+
+// HashLRU is an LRU cache for keys that can't be used as Go map keys, such as
+// slices, maps, or structs containing them. Rather than a map, it indexes its
+// buffer with an open-addressed hash table, using the caller supplied hash
+// and equal functions to identify keys.
+// Note that HashLRU is *not* thread safe, some form of mutex is necessary if
+// you want to access it from multiple threads.
+type HashLRU struct {
+	size    int
+	maxSize int
+	buf     []hashEntry
+	root    *hashEntry
+	// table holds offsets into buf, 0 marks an empty slot. It is always a
+	// power of two in size, and kept at most half full.
+	table []uint32
+	hash  func(key interface{}) uint64
+	equal func(a, b interface{}) bool
+}
+
+type hashEntry struct {
+	prev, next uint32
+	hash       uint64
+	key        interface{}
+	value      interface{}
+}
+
+// NewWithHasher creates a HashLRU that will hold no more than the given number
+// of items. hash must return the same value for any two keys that equal
+// reports as being the same.
+func NewWithHasher(size int, hash func(key interface{}) uint64, equal func(a, b interface{}) bool) *HashLRU {
+	if size > maxLRUSize || size <= 0 {
+		panic("size must not be <= 0 or >= 2^32")
+	}
+	initialBufSize := size + 1
+	if initialBufSize > 100 {
+		initialBufSize = 101
+	}
+	c := &HashLRU{
+		maxSize: size,
+		buf:     make([]hashEntry, initialBufSize),
+		table:   make([]uint32, tableSizeFor(initialBufSize)),
+		hash:    hash,
+		equal:   equal,
+	}
+	c.root = &c.buf[0]
+	return c
+}
+
+// tableSizeFor returns the smallest power of two that can hold n entries while
+// staying at most half full.
+func tableSizeFor(n int) int {
+	size := 8
+	for size < n*2 {
+		size *= 2
+	}
+	return size
+}
+
+// Len gives the number of items in the cache
+func (c *HashLRU) Len() int {
+	return c.size
+}
+
+// Add a new entry into the cache
+func (c *HashLRU) Add(key, value interface{}) {
+	h := c.hash(key)
+	slot, elem := c.find(key, h)
+	if elem != 0 {
+		entry := &c.buf[elem]
+		c.moveToFront(elem, entry)
+		entry.value = value
+		return
+	}
+	if c.size < c.maxSize {
+		c.size++
+		elem = uint32(c.size)
+		if c.size >= len(c.buf) {
+			c.realloc()
+			// the table may have been rebuilt
+			slot, _ = c.find(key, h)
+		}
+	} else {
+		// reuse the least recently used element
+		elem = c.root.prev
+		c.deleteSlot(c.slotOf(elem))
+		// removing from the table can shift other entries around
+		slot, _ = c.find(key, h)
+	}
+	if elem >= uint32(len(c.buf)) {
+		panic(fmt.Sprintf("element %d outside of buffer range: %d", elem, len(c.buf)))
+	}
+	entry := &c.buf[elem]
+	entry.hash = h
+	entry.key = key
+	entry.value = value
+	c.table[slot] = elem
+	c.moveToFront(elem, entry)
+}
+
+// Get returns the Value associated with key, and a boolean as to whether it
+// actually exists in the cache. If it does exist in the cache, then it is
+// treated as recently accessed.
+func (c *HashLRU) Get(key interface{}) (interface{}, bool) {
+	if _, elem := c.find(key, c.hash(key)); elem != 0 {
+		entry := &c.buf[elem]
+		c.moveToFront(elem, entry)
+		return entry.value, true
+	}
+	return nil, false
+}
+
+// Peek is just like Get() except it doesn't affect if it was 'recently accessed'
+func (c *HashLRU) Peek(key interface{}) (interface{}, bool) {
+	if _, elem := c.find(key, c.hash(key)); elem != 0 {
+		return c.buf[elem].value, true
+	}
+	return nil, false
+}
+
+// find returns the table slot holding key along with its element, or the
+// empty slot where it would be inserted and an element of 0.
+func (c *HashLRU) find(key interface{}, h uint64) (int, uint32) {
+	mask := uint64(len(c.table) - 1)
+	for i := h & mask; ; i = (i + 1) & mask {
+		elem := c.table[i]
+		if elem == 0 {
+			return int(i), 0
+		}
+		if entry := &c.buf[elem]; entry.hash == h && c.equal(entry.key, key) {
+			return int(i), elem
+		}
+	}
+}
+
+// slotOf returns the table slot that refers to elem.
+func (c *HashLRU) slotOf(elem uint32) int {
+	mask := uint64(len(c.table) - 1)
+	for i := c.buf[elem].hash & mask; ; i = (i + 1) & mask {
+		if c.table[i] == elem {
+			return int(i)
+		}
+	}
+}
+
+// deleteSlot clears slot i, shifting back any following entries that would
+// otherwise become unreachable, so we never need tombstones.
+func (c *HashLRU) deleteSlot(i int) {
+	mask := len(c.table) - 1
+	for j := (i + 1) & mask; c.table[j] != 0; j = (j + 1) & mask {
+		home := int(c.buf[c.table[j]].hash) & mask
+		// The entry at j can only move to i if its home slot is not
+		// (cyclically) in the range (i, j].
+		if i <= j {
+			if i < home && home <= j {
+				continue
+			}
+		} else if i < home || home <= j {
+			continue
+		}
+		c.table[i] = c.table[j]
+		i = j
+	}
+	c.table[i] = 0
+}
+
+func (c *HashLRU) realloc() {
+	// See LRU.realloc for why we reserve buf[0].
+	nextSize := (len(c.buf) - 1) * 2
+	if nextSize > c.maxSize {
+		nextSize = c.maxSize
+	}
+	newBuf := make([]hashEntry, nextSize+1)
+	copy(newBuf, c.buf)
+	c.buf = newBuf
+	c.root = &newBuf[0]
+	if tableSize := tableSizeFor(nextSize + 1); tableSize > len(c.table) {
+		c.table = make([]uint32, tableSize)
+		mask := uint64(tableSize - 1)
+		for elem := c.root.next; elem != 0; elem = c.buf[elem].next {
+			i := c.buf[elem].hash & mask
+			for c.table[i] != 0 {
+				i = (i + 1) & mask
+			}
+			c.table[i] = elem
+		}
+	}
+}
+
+func (c *HashLRU) moveToFront(elem uint32, entry *hashEntry) {
+	if c.root.next == elem {
+		// we're already at the front
+		return
+	}
+	if entry.prev != 0 {
+		// remove it from its current spot
+		c.buf[entry.prev].next = entry.next
+		c.buf[entry.next].prev = entry.prev
+	}
+	next := c.root.next
+	entry.prev = 0
+	entry.next = next
+	c.root.next = elem
+	c.buf[next].prev = elem
+}
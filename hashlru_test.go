@@ -0,0 +1,79 @@
+// Copyright 2026 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package lru_test
+
+import (
+	"hash/fnv"
+	"math/rand"
+	"reflect"
+
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/lru"
+)
+
+type HashLRUSuite struct{}
+
+var _ = gc.Suite(&HashLRUSuite{})
+
+func hashInts(key interface{}) uint64 {
+	h := fnv.New64a()
+	for _, i := range key.([]int) {
+		h.Write([]byte{byte(i), byte(i >> 8), byte(i >> 16), byte(i >> 24)})
+	}
+	return h.Sum64()
+}
+
+func equalInts(a, b interface{}) bool {
+	return reflect.DeepEqual(a, b)
+}
+
+func (*HashLRUSuite) TestSliceKeys(c *gc.C) {
+	cache := lru.NewWithHasher(2, hashInts, equalInts)
+	cache.Add([]int{1, 2}, "a")
+	cache.Add([]int{3, 4}, "b")
+	v, ok := cache.Get([]int{1, 2})
+	c.Check(ok, gc.Equals, true)
+	c.Check(v, gc.Equals, "a")
+	cache.Add([]int{5}, "c")
+	_, ok = cache.Peek([]int{3, 4})
+	c.Check(ok, gc.Equals, false)
+	v, ok = cache.Peek([]int{5})
+	c.Check(ok, gc.Equals, true)
+	c.Check(v, gc.Equals, "c")
+	cache.Add([]int{5}, "d")
+	v, _ = cache.Peek([]int{5})
+	c.Check(v, gc.Equals, "d")
+	c.Check(cache.Len(), gc.Equals, 2)
+}
+
+func (*HashLRUSuite) TestCollisions(c *gc.C) {
+	// A deliberately poor hash, so that most keys collide and we exercise
+	// the probing and removal logic.
+	poorHash := func(key interface{}) uint64 {
+		return uint64(key.([]int)[0] % 3)
+	}
+	const size = 50
+	cache := lru.NewWithHasher(size, poorHash, equalInts)
+	model := lru.New(size)
+	for i := 0; i < 5000; i++ {
+		k := rand.Intn(150)
+		if rand.Intn(2) == 0 {
+			cache.Add([]int{k}, i)
+			model.Add(k, i)
+		} else {
+			v, ok := cache.Get([]int{k})
+			expectV, expectOK := model.Get(k)
+			c.Assert(ok, gc.Equals, expectOK, gc.Commentf("key %d", k))
+			c.Assert(v, gc.Equals, expectV, gc.Commentf("key %d", k))
+		}
+		c.Assert(cache.Len(), gc.Equals, model.Len())
+	}
+	for k := 0; k < 150; k++ {
+		v, ok := cache.Peek([]int{k})
+		expectV, expectOK := model.Peek(k)
+		c.Check(ok, gc.Equals, expectOK, gc.Commentf("key %d", k))
+		c.Check(v, gc.Equals, expectV, gc.Commentf("key %d", k))
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright 2026 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package lru
+
+import (
+	"math"
+	"time"
+)
+
+// histogramBuckets is the number of buckets in a DurationHistogram. The first
+// holds durations under a microsecond, and each after that holds durations up
+// to twice as long as the one before, so the last but one goes up to about 4
+// years, and the last holds anything longer.
+const histogramBuckets = 48
+
+// DurationHistogram counts durations in buckets that double in width, so that
+// it takes a fixed amount of space however many durations it has seen, and
+// gives percentiles to within a factor of two.
+type DurationHistogram struct {
+	// Count is the number of durations that have been added, and Sum their
+	// total.
+	Count int64
+	Sum   time.Duration
+	// Buckets[i] counts the durations that are less than BucketBound(i),
+	// and not less than BucketBound(i-1).
+	Buckets [histogramBuckets]int64
+}
+
+// BucketBound returns the upper bound of bucket i of a DurationHistogram.
+// The bound of the last bucket is the largest possible duration.
+func BucketBound(i int) time.Duration {
+	if i >= histogramBuckets-1 {
+		return 1<<63 - 1
+	}
+	return time.Microsecond << uint(i)
+}
+
+// add counts d in the histogram.
+func (h *DurationHistogram) add(d time.Duration) {
+	if d < 0 {
+		d = 0
+	}
+	i := 0
+	for i < histogramBuckets-1 && d >= BucketBound(i) {
+		i++
+	}
+	h.Buckets[i]++
+	h.Count++
+	h.Sum += d
+}
+
+// merge adds the counts from other into h.
+func (h *DurationHistogram) merge(other DurationHistogram) {
+	h.Count += other.Count
+	h.Sum += other.Sum
+	for i, n := range other.Buckets {
+		h.Buckets[i] += n
+	}
+}
+
+// Mean returns the average of the durations, or 0 if there are none.
+func (h DurationHistogram) Mean() time.Duration {
+	if h.Count == 0 {
+		return 0
+	}
+	return h.Sum / time.Duration(h.Count)
+}
+
+// Percentile returns the upper bound of the bucket holding the duration that
+// the given fraction (between 0 and 1) of durations are no longer than, so
+// Percentile(0.5) is more than the median, but no more than twice it. It returns
+// 0 if there are no durations.
+func (h DurationHistogram) Percentile(p float64) time.Duration {
+	if h.Count == 0 {
+		return 0
+	}
+	rank := int64(math.Ceil(p * float64(h.Count)))
+	if rank < 1 {
+		rank = 1
+	}
+	var seen int64
+	for i, n := range h.Buckets {
+		seen += n
+		if seen >= rank {
+			return BucketBound(i)
+		}
+	}
+	return BucketBound(histogramBuckets - 1)
+}
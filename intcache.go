@@ -0,0 +1,19 @@
+// Copyright 2026 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package lru
+
+// IntCache is a Cache keyed by int64 IDs. Keys are stored as native integers in
+// both the map and the buffer, so unlike LRU, adding an entry does not need to
+// allocate to box the key into an interface{}.
+type IntCache[V any] struct {
+	Cache[int64, V]
+}
+
+// NewIntCache creates a new IntCache that will hold no more than the given
+// number of items.
+func NewIntCache[V any](size int) *IntCache[V] {
+	c := &IntCache[V]{}
+	c.init(size)
+	return c
+}
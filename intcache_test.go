@@ -0,0 +1,41 @@
+// Copyright 2026 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package lru_test
+
+import (
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/lru"
+)
+
+type IntCacheSuite struct{}
+
+var _ = gc.Suite(&IntCacheSuite{})
+
+func (*IntCacheSuite) TestAddGetEvict(c *gc.C) {
+	cache := lru.NewIntCache[string](3)
+	cache.Add(1, "a")
+	cache.Add(2, "b")
+	cache.Add(3, "c")
+	v, ok := cache.Get(1)
+	c.Check(ok, gc.Equals, true)
+	c.Check(v, gc.Equals, "a")
+	cache.Add(4, "d")
+	_, ok = cache.Peek(2)
+	c.Check(ok, gc.Equals, false)
+	v, ok = cache.Peek(4)
+	c.Check(ok, gc.Equals, true)
+	c.Check(v, gc.Equals, "d")
+	c.Check(cache.Len(), gc.Equals, 3)
+}
+
+func (*IntCacheSuite) TestLargeKeys(c *gc.C) {
+	cache := lru.NewIntCache[int](10)
+	cache.Add(1<<62, 1)
+	cache.Add(-1<<62, 2)
+	v, _ := cache.Get(1 << 62)
+	c.Check(v, gc.Equals, 1)
+	v, _ = cache.Get(-1 << 62)
+	c.Check(v, gc.Equals, 2)
+}
@@ -0,0 +1,66 @@
+// Copyright 2026 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package lru
+
+// Interner is the generic form of StringCache. Use Intern() to get a saved
+// version of a value, such that
+//
+//	x := interner.Intern(v1)
+//	y := interner.Intern(v2)
+//
+// Now x and y are the same copy of the value if v1 == v2, so any strings or
+// pointers they contain share the same underlying memory.
+// Interner holds no more than its configured number of values, discarding the
+// least recently interned values to make room.
+// Note that Interner is *not* thread safe, some form of mutex is necessary if
+// you want to access it from multiple threads.
+type Interner[T comparable] struct {
+	cache     Cache[T, struct{}]
+	hitCount  int64
+	missCount int64
+}
+
+// NewInterner creates an Interner that will hold no more than 'size' values.
+func NewInterner[T comparable](size int) *Interner[T] {
+	in := &Interner[T]{}
+	in.cache.init(size)
+	return in
+}
+
+// Len returns how many values are currently cached
+func (in *Interner[T]) Len() int {
+	return in.cache.Len()
+}
+
+// HitCounts gives information about accesses to the interner. The total
+// number of calls to Intern can be computed by adding Hit and Miss.
+func (in *Interner[T]) HitCounts() HitCounts {
+	return HitCounts{
+		Hit:  in.hitCount,
+		Miss: in.missCount,
+	}
+}
+
+// Intern takes a value, and returns either the cached copy of an equal value,
+// or caches the value and returns it back. It also updates how recently the
+// value was seen, so that values aren't cached forever.
+func (in *Interner[T]) Intern(v T) T {
+	c := &in.cache
+	if elem, ok := c.elements[v]; ok {
+		entry := &c.buf[elem]
+		c.moveToFront(elem, entry)
+		in.hitCount++
+		return entry.key
+	}
+	in.missCount++
+	c.insert(v, struct{}{})
+	return v
+}
+
+// Contains returns true if an equal value is in the interner. It does not
+// change information about recently-used.
+func (in *Interner[T]) Contains(v T) bool {
+	_, ok := in.cache.elements[v]
+	return ok
+}
@@ -0,0 +1,62 @@
+// Copyright 2026 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package lru_test
+
+import (
+	"fmt"
+
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/lru"
+)
+
+type InternerSuite struct{}
+
+var _ = gc.Suite(&InternerSuite{})
+
+type version struct {
+	major, minor int
+	tag          string
+}
+
+func (*InternerSuite) TestInternStruct(c *gc.C) {
+	v1 := version{major: 2, minor: 9, tag: fmt.Sprintf("beta%d", 1)}
+	v2 := version{major: 2, minor: 9, tag: fmt.Sprintf("beta%d", 1)}
+	c.Check(isSameStr(v1.tag, v2.tag), gc.Equals, false)
+
+	interner := lru.NewInterner[version](10)
+	v3 := interner.Intern(v1)
+	c.Check(isSameStr(v1.tag, v3.tag), gc.Equals, true)
+	v4 := interner.Intern(v2)
+	c.Check(v4, gc.Equals, v2)
+	c.Check(isSameStr(v1.tag, v4.tag), gc.Equals, true)
+	c.Check(interner.Len(), gc.Equals, 1)
+	c.Check(interner.Contains(v2), gc.Equals, true)
+	c.Check(interner.HitCounts(), gc.Equals, lru.HitCounts{Hit: 1, Miss: 1})
+}
+
+func (*InternerSuite) TestInternMaxSize(c *gc.C) {
+	interner := lru.NewInterner[int](5)
+	for i := 0; i < 30; i++ {
+		c.Check(interner.Intern(i), gc.Equals, i)
+	}
+	c.Check(interner.Len(), gc.Equals, 5)
+	for i := 0; i < 25; i++ {
+		c.Check(interner.Contains(i), gc.Equals, false)
+	}
+	for i := 25; i < 30; i++ {
+		c.Check(interner.Contains(i), gc.Equals, true)
+	}
+}
+
+func (*InternerSuite) TestInternRecency(c *gc.C) {
+	interner := lru.NewInterner[string](3)
+	interner.Intern("a")
+	interner.Intern("b")
+	interner.Intern("c")
+	interner.Intern("a")
+	interner.Intern("d")
+	c.Check(interner.Contains("a"), gc.Equals, true)
+	c.Check(interner.Contains("b"), gc.Equals, false)
+}
@@ -0,0 +1,91 @@
+// Copyright 2026 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package lru
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// jsonEntry is how an Entry is written by MarshalJSON.
+type jsonEntry struct {
+	Key   interface{} `json:"key"`
+	Value interface{} `json:"value"`
+}
+
+// MarshalJSON implements json.Marshaler. The cache is written as an array of
+// objects with "key" and "value" fields, from the least recently used entry to
+// the most, as Save writes them. Expired entries are left out.
+func (lru *LRU) MarshalJSON() ([]byte, error) {
+	entries := make([]jsonEntry, 0, lru.size)
+	lru.RangeReverse(func(key, value interface{}) bool {
+		entries = append(entries, jsonEntry{Key: key, Value: value})
+		return true
+	})
+	return json.Marshal(entries)
+}
+
+// UnmarshalJSON implements json.Unmarshaler. It adds the entries written by
+// MarshalJSON to the cache, in order, as Load does. Keys and values are
+// decoded as encoding/json decodes into an interface{}, so numbers become
+// float64s, for example. The cache must already have been created with New.
+func (lru *LRU) UnmarshalJSON(data []byte) error {
+	if lru.buf == nil {
+		return errors.New("lru: UnmarshalJSON needs a cache created with New")
+	}
+	var entries []jsonEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		lru.Add(entry.Key, entry.Value)
+	}
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler. See LRU.MarshalJSON.
+func (s *SyncLRU) MarshalJSON() ([]byte, error) {
+	s.lock()
+	defer s.mu.Unlock()
+	return s.lru.MarshalJSON()
+}
+
+// UnmarshalJSON implements json.Unmarshaler. See LRU.UnmarshalJSON.
+func (s *SyncLRU) UnmarshalJSON(data []byte) error {
+	if s.lru == nil {
+		return errors.New("lru: UnmarshalJSON needs a cache created with NewSync")
+	}
+	s.lock()
+	defer s.mu.Unlock()
+	err := s.lru.UnmarshalJSON(data)
+	s.notifyAllWaiters()
+	return err
+}
+
+// MarshalJSON implements json.Marshaler. The cache is written as an array of
+// its strings, from the least recently used to the most.
+func (sc *StringCache) MarshalJSON() ([]byte, error) {
+	values := make([]string, 0, sc.size)
+	for elem := sc.root.prev; elem != 0; elem = sc.buf[elem].prev {
+		values = append(values, sc.buf[elem].value)
+	}
+	return json.Marshal(values)
+}
+
+// UnmarshalJSON implements json.Unmarshaler. It interns the strings written by
+// MarshalJSON, in order, so that they have the same recency order. The cache
+// must already have been created with NewStringCache.
+func (sc *StringCache) UnmarshalJSON(data []byte) error {
+	if sc.buf == nil {
+		return errors.New("lru: UnmarshalJSON needs a cache created with NewStringCache")
+	}
+	var values []string
+	if err := json.Unmarshal(data, &values); err != nil {
+		return err
+	}
+	for _, v := range values {
+		sc.Intern(v)
+	}
+	return nil
+}
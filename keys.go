@@ -0,0 +1,30 @@
+// Copyright 2026 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package lru
+
+// Key2 is a composite key made up of two values. Since it is a plain
+// comparable struct, the cache hashes and compares its fields directly, which
+// avoids formatting the parts into a string on every lookup. Used as the key
+// type of a Cache, lookups do not allocate at all.
+type Key2[T1, T2 comparable] struct {
+	First  T1
+	Second T2
+}
+
+// MakeKey2 returns the Key2 for the given parts.
+func MakeKey2[T1, T2 comparable](first T1, second T2) Key2[T1, T2] {
+	return Key2[T1, T2]{First: first, Second: second}
+}
+
+// Key3 is a composite key made up of three values. See Key2.
+type Key3[T1, T2, T3 comparable] struct {
+	First  T1
+	Second T2
+	Third  T3
+}
+
+// MakeKey3 returns the Key3 for the given parts.
+func MakeKey3[T1, T2, T3 comparable](first T1, second T2, third T3) Key3[T1, T2, T3] {
+	return Key3[T1, T2, T3]{First: first, Second: second, Third: third}
+}
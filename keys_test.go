@@ -0,0 +1,93 @@
+// Copyright 2026 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package lru_test
+
+import (
+	"fmt"
+	"testing"
+
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/lru"
+)
+
+type KeysSuite struct{}
+
+var _ = gc.Suite(&KeysSuite{})
+
+func (*KeysSuite) TestKey2InLRU(c *gc.C) {
+	cache := lru.New(10)
+	cache.Add(lru.MakeKey2("model", "app"), 1)
+	cache.Add(lru.MakeKey2("model", "unit"), 2)
+	checkPeekExists(c, cache, lru.MakeKey2("model", "app"), 1)
+	checkPeekExists(c, cache, lru.MakeKey2("model", "unit"), 2)
+	checkPeekMissing(c, cache, lru.MakeKey2("app", "model"))
+}
+
+func (*KeysSuite) TestKey3InCache(c *gc.C) {
+	cache := lru.NewCache[lru.Key3[string, string, int], string](10)
+	cache.Add(lru.MakeKey3("model", "app", 1), "a")
+	cache.Add(lru.MakeKey3("model", "app", 2), "b")
+	v, ok := cache.Get(lru.MakeKey3(fmt.Sprint("mod", "el"), "app", 2))
+	c.Check(ok, gc.Equals, true)
+	c.Check(v, gc.Equals, "b")
+	_, ok = cache.Get(lru.MakeKey3("model", "app", 3))
+	c.Check(ok, gc.Equals, false)
+}
+
+func (*KeysSuite) TestKey2LookupDoesNotAllocate(c *gc.C) {
+	cache := lru.NewCache[lru.Key2[string, int], int](10)
+	model := "model"
+	cache.Add(lru.MakeKey2(model, 1), 1)
+	allocs := testing.AllocsPerRun(100, func() {
+		cache.Get(lru.MakeKey2(model, 1))
+	})
+	c.Check(allocs, gc.Equals, float64(0))
+}
+
+type machineKey struct {
+	id    string
+	label string
+}
+
+func (k machineKey) CacheKey() interface{} {
+	return k.id
+}
+
+func (*KeysSuite) TestKeyerIdentity(c *gc.C) {
+	cache := lru.New(10)
+	cache.Add(machineKey{id: "0", label: "first"}, "a")
+	checkPeekExists(c, cache, machineKey{id: "0", label: "other"}, "a")
+	cache.Add(machineKey{id: "0", label: "second"}, "b")
+	c.Check(cache.Len(), gc.Equals, 1)
+	checkGet(c, cache, machineKey{id: "0"}, "b", true)
+	checkGet(c, cache, machineKey{id: "1", label: "first"}, nil, false)
+}
+
+func (*KeysSuite) TestKeyersDoNotMatchPlainKeys(c *gc.C) {
+	cache := lru.New(10)
+	cache.Add("0", "plain")
+	checkGet(c, cache, machineKey{id: "0"}, nil, false)
+	cache.Add(machineKey{id: "0"}, "keyer")
+	c.Check(cache.Len(), gc.Equals, 2)
+	checkGet(c, cache, "0", "plain", true)
+	checkGet(c, cache, machineKey{id: "0", label: "other"}, "keyer", true)
+	// The keys handed back are the ones that were added.
+	c.Check(collectKeys(cache.Range), gc.DeepEquals, []interface{}{machineKey{id: "0"}, "0"})
+}
+
+func (*KeysSuite) TestKeyerEviction(c *gc.C) {
+	cache := lru.New(2)
+	cache.Add(machineKey{id: "0"}, "a")
+	cache.Add(machineKey{id: "1"}, "b")
+	cache.Add(machineKey{id: "2"}, "c")
+	c.Check(cache.Len(), gc.Equals, 2)
+	checkPeekMissing(c, cache, machineKey{id: "0"})
+	removed := cache.RetainOnly(map[interface{}]struct{}{
+		machineKey{id: "2", label: "different"}: {},
+	})
+	c.Check(removed, gc.Equals, 1)
+	checkPeekMissing(c, cache, machineKey{id: "1"})
+	checkPeekExists(c, cache, machineKey{id: "2"}, "c")
+}
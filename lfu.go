@@ -0,0 +1,213 @@
+// Copyright 2026 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package lru
+
+import (
+	"fmt"
+)
+
+// LFUCache is a least-frequently-used cache. When it is full, adding a new
+// entry evicts the entry that has been used the fewest times, and of those,
+// the one that has gone longest without being used.
+// Entries are grouped into buckets by how many times they have been used, and
+// the buckets are kept in a list in order of use count, so every operation is
+// O(1). Like LRU, entries are stored in a flat buffer and linked by offset,
+// and the buckets are stored in a second buffer the same way.
+// Note that LFUCache is *not* thread safe, some form of mutex is necessary if
+// you want to access it from multiple threads.
+type LFUCache struct {
+	size     int
+	maxSize  int
+	buf      []lfuEntry
+	elements map[interface{}]uint32
+	// buckets[0] is the root of the list of buckets, which is ordered from
+	// the fewest uses to the most.
+	buckets []lfuBucket
+	// freeBucket is the first of a list of unused buckets, linked by next.
+	freeBucket uint32
+}
+
+type lfuEntry struct {
+	// prev and next link the entries in the same bucket into a ring.
+	prev, next uint32
+	bucket     uint32
+	key        interface{}
+	value      interface{}
+}
+
+type lfuBucket struct {
+	prev, next uint32
+	uses       uint64
+	// head is the most recently used entry in the bucket, its prev is the
+	// least recently used.
+	head uint32
+}
+
+// NewLFU creates an LFUCache that will hold no more than the given number of
+// items.
+func NewLFU(size int) *LFUCache {
+	if size > maxLRUSize || size <= 0 {
+		panic("size must not be <= 0 or >= 2^32")
+	}
+	initialBufSize := size + 1
+	if initialBufSize > 100 {
+		initialBufSize = 101
+	}
+	return &LFUCache{
+		maxSize:  size,
+		buf:      make([]lfuEntry, initialBufSize),
+		elements: make(map[interface{}]uint32, initialBufSize),
+		buckets:  make([]lfuBucket, 1, 8),
+	}
+}
+
+// Len gives the number of items in the cache
+func (c *LFUCache) Len() int {
+	return c.size
+}
+
+// Add a new entry into the cache. Adding a key that is already in the cache
+// counts as a use of it.
+func (c *LFUCache) Add(key, value interface{}) {
+	if elem, exists := c.elements[mapKey(key)]; exists {
+		c.buf[elem].value = value
+		c.used(elem)
+		return
+	}
+	var elem uint32
+	if c.size < c.maxSize {
+		c.size++
+		elem = uint32(c.size)
+		if c.size >= len(c.buf) {
+			c.realloc()
+		}
+	} else {
+		// evict the least recently used of the least frequently used
+		bucket := c.buckets[0].next
+		elem = c.buckets[bucket].head
+		elem = c.buf[elem].prev
+		c.unlink(elem)
+		delete(c.elements, mapKey(c.buf[elem].key))
+	}
+	if elem >= uint32(len(c.buf)) {
+		panic(fmt.Sprintf("element %d outside of buffer range: %d", elem, len(c.buf)))
+	}
+	entry := &c.buf[elem]
+	entry.key = key
+	entry.value = value
+	c.elements[mapKey(key)] = elem
+	first := c.buckets[0].next
+	if first == 0 || c.buckets[first].uses != 1 {
+		first = c.newBucket(0, 1)
+	}
+	c.link(elem, first)
+}
+
+// Get returns the Value associated with key, and a boolean as to whether it
+// actually exists in the cache. If it does exist in the cache, then it counts
+// as a use of it.
+func (c *LFUCache) Get(key interface{}) (interface{}, bool) {
+	if elem, exists := c.elements[mapKey(key)]; exists {
+		c.used(elem)
+		return c.buf[elem].value, true
+	}
+	return nil, false
+}
+
+// Peek is just like Get() except it doesn't count as a use.
+func (c *LFUCache) Peek(key interface{}) (interface{}, bool) {
+	if elem, exists := c.elements[mapKey(key)]; exists {
+		return c.buf[elem].value, true
+	}
+	return nil, false
+}
+
+// Uses returns how many times key has been added or returned by Get since it
+// was last added to the cache, or 0 if it is not in the cache.
+func (c *LFUCache) Uses(key interface{}) uint64 {
+	if elem, exists := c.elements[mapKey(key)]; exists {
+		return c.buckets[c.buf[elem].bucket].uses
+	}
+	return 0
+}
+
+// used moves elem into the bucket for one more use.
+func (c *LFUCache) used(elem uint32) {
+	bucket := c.buf[elem].bucket
+	uses := c.buckets[bucket].uses + 1
+	next := c.buckets[bucket].next
+	if next == 0 || c.buckets[next].uses != uses {
+		next = c.newBucket(bucket, uses)
+	}
+	c.unlink(elem)
+	c.link(elem, next)
+}
+
+// link puts elem at the head of bucket.
+func (c *LFUCache) link(elem, bucket uint32) {
+	entry := &c.buf[elem]
+	entry.bucket = bucket
+	head := c.buckets[bucket].head
+	if head == 0 {
+		entry.prev, entry.next = elem, elem
+	} else {
+		tail := c.buf[head].prev
+		entry.prev, entry.next = tail, head
+		c.buf[tail].next = elem
+		c.buf[head].prev = elem
+	}
+	c.buckets[bucket].head = elem
+}
+
+// unlink removes elem from its bucket, freeing the bucket if it is now empty.
+func (c *LFUCache) unlink(elem uint32) {
+	entry := &c.buf[elem]
+	bucket := &c.buckets[entry.bucket]
+	if entry.next == elem {
+		c.freeBucketAt(entry.bucket)
+	} else {
+		c.buf[entry.prev].next = entry.next
+		c.buf[entry.next].prev = entry.prev
+		if bucket.head == elem {
+			bucket.head = entry.next
+		}
+	}
+	entry.prev, entry.next, entry.bucket = 0, 0, 0
+}
+
+// newBucket adds an empty bucket for the given number of uses after prev.
+func (c *LFUCache) newBucket(prev uint32, uses uint64) uint32 {
+	bucket := c.freeBucket
+	if bucket != 0 {
+		c.freeBucket = c.buckets[bucket].next
+	} else {
+		bucket = uint32(len(c.buckets))
+		c.buckets = append(c.buckets, lfuBucket{})
+	}
+	next := c.buckets[prev].next
+	c.buckets[bucket] = lfuBucket{prev: prev, next: next, uses: uses}
+	c.buckets[prev].next = bucket
+	c.buckets[next].prev = bucket
+	return bucket
+}
+
+// freeBucketAt removes bucket from the list, and adds it to the free list.
+func (c *LFUCache) freeBucketAt(bucket uint32) {
+	b := &c.buckets[bucket]
+	c.buckets[b.prev].next = b.next
+	c.buckets[b.next].prev = b.prev
+	*b = lfuBucket{next: c.freeBucket}
+	c.freeBucket = bucket
+}
+
+func (c *LFUCache) realloc() {
+	// See LRU.realloc for why we reserve buf[0].
+	nextSize := (len(c.buf) - 1) * 2
+	if nextSize > c.maxSize {
+		nextSize = c.maxSize
+	}
+	newBuf := make([]lfuEntry, nextSize+1)
+	copy(newBuf, c.buf)
+	c.buf = newBuf
+}
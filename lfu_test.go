@@ -0,0 +1,94 @@
+// Copyright 2026 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package lru_test
+
+import (
+	"math/rand"
+
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/lru"
+)
+
+type LFUSuite struct{}
+
+var _ = gc.Suite(&LFUSuite{})
+
+func (*LFUSuite) TestAddGet(c *gc.C) {
+	cache := lru.NewLFU(10)
+	cache.Add("a", 1)
+	value, ok := cache.Get("a")
+	c.Check(ok, gc.Equals, true)
+	c.Check(value, gc.Equals, 1)
+	cache.Add("a", 2)
+	value, ok = cache.Peek("a")
+	c.Check(ok, gc.Equals, true)
+	c.Check(value, gc.Equals, 2)
+	c.Check(cache.Uses("a"), gc.Equals, uint64(3))
+	c.Check(cache.Uses("b"), gc.Equals, uint64(0))
+	_, ok = cache.Get("b")
+	c.Check(ok, gc.Equals, false)
+	c.Check(cache.Len(), gc.Equals, 1)
+}
+
+func (*LFUSuite) TestEvictsLeastFrequent(c *gc.C) {
+	cache := lru.NewLFU(3)
+	cache.Add("a", 1)
+	cache.Add("b", 2)
+	cache.Add("c", 3)
+	for i := 0; i < 3; i++ {
+		cache.Get("a")
+	}
+	cache.Get("c")
+	// "b" has been used least
+	cache.Add("d", 4)
+	_, ok := cache.Peek("b")
+	c.Check(ok, gc.Equals, false)
+	// "d" has only been added, so it goes next, and then "c".
+	cache.Add("e", 5)
+	_, ok = cache.Peek("d")
+	c.Check(ok, gc.Equals, false)
+	cache.Get("e")
+	cache.Get("e")
+	cache.Add("f", 6)
+	_, ok = cache.Peek("c")
+	c.Check(ok, gc.Equals, false)
+	for _, key := range []string{"a", "e", "f"} {
+		_, ok := cache.Peek(key)
+		c.Check(ok, gc.Equals, true, gc.Commentf("missing %q", key))
+	}
+}
+
+func (*LFUSuite) TestTiesEvictLeastRecent(c *gc.C) {
+	cache := lru.NewLFU(3)
+	cache.Add("a", 1)
+	cache.Add("b", 2)
+	cache.Add("c", 3)
+	cache.Get("b")
+	cache.Get("a")
+	cache.Get("c")
+	// All have been used twice, "b" longest ago.
+	cache.Add("d", 4)
+	_, ok := cache.Peek("b")
+	c.Check(ok, gc.Equals, false)
+	c.Check(cache.Len(), gc.Equals, 3)
+}
+
+func (*LFUSuite) TestSkewedWorkload(c *gc.C) {
+	// With a heavily skewed workload, the popular keys stay cached even
+	// when there are many more unpopular ones.
+	cache := lru.NewLFU(100)
+	zipf := rand.NewZipf(rand.New(rand.NewSource(1)), 1.2, 1, 100000)
+	for i := 0; i < 100000; i++ {
+		key := zipf.Uint64()
+		if _, ok := cache.Get(key); !ok {
+			cache.Add(key, key)
+		}
+		c.Assert(cache.Len() <= 100, gc.Equals, true)
+	}
+	for key := uint64(0); key < 10; key++ {
+		_, ok := cache.Peek(key)
+		c.Check(ok, gc.Equals, true, gc.Commentf("missing %d", key))
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright 2026 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package lru
+
+import (
+	"time"
+)
+
+// LocalLRU is a small private cache in front of a shared SyncLRU, for use by a
+// single goroutine with a very high read rate. Reads are served from the local
+// cache without any locking, falling back to the shared cache on a miss.
+// Writes go to the local cache immediately, and are merged into the shared
+// cache periodically, at which point the local cache is also refreshed from
+// the shared one. This means a LocalLRU may serve values that are up to one
+// sync interval out of date, and writes may take that long to be visible to
+// other goroutines.
+// Note that LocalLRU is *not* thread safe, each goroutine should have its own.
+type LocalLRU struct {
+	shared   *SyncLRU
+	local    *LRU
+	writes   []Entry
+	interval time.Duration
+	lastSync time.Time
+}
+
+// NewLocal creates a LocalLRU holding up to size items, which syncs with s
+// whenever interval has passed since the last sync, as measured by the clock
+// the shared cache was configured with.
+func (s *SyncLRU) NewLocal(size int, interval time.Duration) *LocalLRU {
+	return &LocalLRU{
+		shared:   s,
+		local:    New(size),
+		interval: interval,
+		lastSync: s.lru.clock.Now(),
+	}
+}
+
+// maybeSync syncs with the shared cache if enough time has passed, or there
+// are too many writes waiting to be merged.
+func (l *LocalLRU) maybeSync() {
+	if len(l.writes) >= l.local.maxSize || l.shared.lru.clock.Now().Sub(l.lastSync) >= l.interval {
+		l.Sync()
+	}
+}
+
+// Get returns the value associated with key, looking in the shared cache if
+// it is not held locally. If it does exist, then it is treated as recently
+// accessed.
+func (l *LocalLRU) Get(key interface{}) (interface{}, bool) {
+	l.maybeSync()
+	if value, ok := l.local.Get(key); ok {
+		return value, true
+	}
+	value, ok := l.shared.Get(key)
+	if ok {
+		l.local.Add(key, value)
+	}
+	return value, ok
+}
+
+// Add a new entry into the local cache. It will be added to the shared cache
+// at the next sync.
+func (l *LocalLRU) Add(key, value interface{}) {
+	l.local.Add(key, value)
+	l.writes = append(l.writes, Entry{Key: key, Value: value})
+	l.maybeSync()
+}
+
+// Sync merges any local writes into the shared cache, and refreshes the local
+// cache from it. The entries held locally are treated as recently accessed in
+// the shared cache, so that keys only read locally are not evicted from it,
+// but this isn't counted towards the shared cache's statistics.
+func (l *LocalLRU) Sync() {
+	local := l.local.PeekLeastRecentN(l.local.Len())
+	l.local.RemoveIf(func(_, _ interface{}) bool { return true })
+	writes := l.writes
+	l.writes = l.writes[:0]
+	l.shared.Do(func(shared *LRU) {
+		for _, entry := range writes {
+			shared.Add(entry.Key, entry.Value)
+		}
+		for _, entry := range local {
+			if elem, ok := shared.lookup(entry.Key); ok {
+				shared.refresh(elem)
+				l.local.Add(entry.Key, shared.buf[elem].value)
+			}
+		}
+	})
+	l.lastSync = l.shared.lru.clock.Now()
+}
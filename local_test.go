@@ -0,0 +1,125 @@
+// Copyright 2026 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package lru_test
+
+import (
+	"sync"
+	"time"
+
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/lru"
+)
+
+type LocalLRUSuite struct{}
+
+var _ = gc.Suite(&LocalLRUSuite{})
+
+func (*LocalLRUSuite) TestReadsThroughToShared(c *gc.C) {
+	shared := lru.NewSync(10)
+	shared.Add("a", 1)
+	local := shared.NewLocal(5, time.Hour)
+	v, ok := local.Get("a")
+	c.Check(ok, gc.Equals, true)
+	c.Check(v, gc.Equals, 1)
+	_, ok = local.Get("b")
+	c.Check(ok, gc.Equals, false)
+}
+
+func (*LocalLRUSuite) TestWritesMergedOnSync(c *gc.C) {
+	shared := lru.NewSync(10)
+	local := shared.NewLocal(5, time.Hour)
+	local.Add("a", 1)
+	v, ok := local.Get("a")
+	c.Check(ok, gc.Equals, true)
+	c.Check(v, gc.Equals, 1)
+	_, ok = shared.Peek("a")
+	c.Check(ok, gc.Equals, false)
+	local.Sync()
+	checkSyncPeek(c, shared, "a", 1)
+}
+
+func (*LocalLRUSuite) TestRefreshedOnSync(c *gc.C) {
+	shared := lru.NewSync(10)
+	shared.Add("a", 1)
+	shared.Add("b", 2)
+	local := shared.NewLocal(5, time.Hour)
+	local.Get("a")
+	local.Get("b")
+	shared.Add("a", 10)
+	shared.Do(func(l *lru.LRU) {
+		l.RemoveIf(func(key, _ interface{}) bool { return key == "b" })
+	})
+	// Still stale until we sync
+	v, _ := local.Get("a")
+	c.Check(v, gc.Equals, 1)
+	local.Sync()
+	v, _ = local.Get("a")
+	c.Check(v, gc.Equals, 10)
+	_, ok := local.Get("b")
+	c.Check(ok, gc.Equals, false)
+}
+
+func (*LocalLRUSuite) TestLocalReadsKeepSharedEntriesAlive(c *gc.C) {
+	shared := lru.NewSync(3)
+	shared.Add("a", 1)
+	local := shared.NewLocal(3, time.Hour)
+	local.Get("a")
+	shared.ResetStats()
+	for i := 0; i < 10; i++ {
+		// "a" is only ever read locally, while other goroutines churn
+		// through the shared cache.
+		local.Get("a")
+		shared.Add(i, i)
+		shared.Add(i+100, i)
+		local.Sync()
+	}
+	v, ok := shared.Peek("a")
+	c.Check(ok, gc.Equals, true)
+	c.Check(v, gc.Equals, 1)
+	stats := shared.Stats()
+	c.Check(stats.Hits, gc.Equals, int64(0))
+	c.Check(stats.Misses, gc.Equals, int64(0))
+	c.Check(shared.HitCounts(), gc.Equals, lru.HitCounts{})
+}
+
+func (*LocalLRUSuite) TestSyncsAfterInterval(c *gc.C) {
+	shared := lru.NewSync(10)
+	local := shared.NewLocal(5, time.Nanosecond)
+	local.Add("a", 1)
+	time.Sleep(time.Millisecond)
+	local.Get("a")
+	checkSyncPeek(c, shared, "a", 1)
+}
+
+func (*LocalLRUSuite) TestConcurrentLocals(c *gc.C) {
+	shared := lru.NewSync(100)
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			local := shared.NewLocal(10, time.Millisecond)
+			for j := 0; j < 1000; j++ {
+				local.Add(j%20, i)
+				local.Get((j + 5) % 20)
+			}
+			local.Sync()
+		}(i)
+	}
+	wg.Wait()
+	c.Check(shared.Len(), gc.Equals, 20)
+}
+
+func (*LocalLRUSuite) TestSyncUsesSharedClock(c *gc.C) {
+	clock := newTestClock()
+	shared := lru.NewSync(10, lru.WithClock(clock))
+	local := shared.NewLocal(5, time.Minute)
+	local.Add("a", 1)
+	_, ok := shared.Peek("a")
+	c.Check(ok, gc.Equals, false)
+	clock.Advance(time.Minute)
+	local.Get("a")
+	checkSyncPeek(c, shared, "a", 1)
+}
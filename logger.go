@@ -0,0 +1,34 @@
+// Copyright 2026 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package lru
+
+// Logger receives debug messages from a cache, about things like evictions
+// and the cache resizing its buffers, so they can go wherever the rest of the
+// application's logging goes. It is satisfied by loggo.Logger. Messages are
+// logged while the cache is in the middle of an operation (with the lock held,
+// for a SyncLRU), so the logger must not use the cache.
+type Logger interface {
+	Debugf(format string, args ...interface{})
+}
+
+// WithLogger sets a Logger for the cache to send debug messages to.
+func WithLogger(logger Logger) Option {
+	return func(lru *LRU) {
+		lru.logger = logger
+	}
+}
+
+// WithStringLogger sets a Logger for a StringCache to send debug messages to,
+// such as when Validate finds a problem.
+func WithStringLogger(logger Logger) StringOption {
+	return func(sc *StringCache) {
+		sc.logger = logger
+	}
+}
+
+func (lru *LRU) debugf(format string, args ...interface{}) {
+	if lru.logger != nil {
+		lru.logger.Debugf(format, args...)
+	}
+}
@@ -7,6 +7,7 @@ package lru
 
 import (
 	"fmt"
+	"time"
 )
 
 // maxLRUSize is the largest we can fit in a buffer with a 32-bit unsigned offset
@@ -14,11 +15,164 @@ const maxLRUSize = (1<<32 - 1)
 
 // LRU implements a least-recently-used cache, evicting items from the cache if they have not been accessed in a while.
 type LRU struct {
-	size     int
-	maxSize  int
-	buf      []cacheEntry
-	elements map[interface{}]uint32
-	root     *cacheEntry
+	size    int
+	maxSize int
+	// unbounded is set when the cache was created with a size of 0, in
+	// which case maxSize is as large as the buffer can be, until a limit is
+	// set with setMaxSize.
+	unbounded bool
+	buf       []cacheEntry
+	elements  map[interface{}]uint32
+	root      *cacheEntry
+
+	normalize func(key interface{}) interface{}
+
+	// meta is parallel to buf, and is only allocated if an option needs to
+	// track more information about each entry.
+	meta        []entryMeta
+	expiry      time.Duration
+	idleTimeout time.Duration
+	clock       Clock
+	// wheel indexes entries by when they expire, if they can.
+	wheel *timingWheel
+	// maxStale and revalidate are set by WithStaleWhileRevalidate.
+	maxStale   time.Duration
+	revalidate func(key, stale interface{})
+	// earlyBeta is set by WithEarlyExpiry.
+	earlyBeta float64
+	// sketch estimates how often keys are used, for WithTinyLFU.
+	sketch *frequencySketch
+	// doorkeeper remembers keys that have been turned away, for
+	// WithDoorkeeper.
+	doorkeeper *doorkeeper
+	// policy decides which entry is evicted, and rng is the state of the
+	// random number generator used by policies that need one.
+	policy evictionPolicy
+	rng    uint64
+	// marks holds a bit for each entry, for policies that need one, and hand
+	// is the next entry to be looked at for eviction by WithSIEVE.
+	marks []uint64
+	hand  uint32
+	// mid is the first entry of the old part of the list, and oldLen is how
+	// many entries are in it, for WithMidpointInsertion.
+	mid      uint32
+	oldLen   int
+	oldRatio float64
+	// stamps holds when each entry was last used, counted in uses of the
+	// cache, and samples is how many entries are compared to pick one to
+	// evict, for WithSampledEviction.
+	stamps  []uint64
+	uses    uint64
+	samples int
+	// accessCounts holds how many times Get has found each entry, for
+	// WithAccessCounts.
+	accessCounts []uint64
+	// ghost remembers the keys of entries recently evicted, and ghostHits
+	// counts the Gets that missed but found their key in it, for
+	// WithGhostKeys. ghostHits is updated atomically, like hitCount.
+	ghost     *LRU
+	ghostHits int64
+	// profiler is set by WithMRCProfiler.
+	profiler *MRCProfiler
+	// evictionAges records how old entries were when they were evicted, for
+	// WithEvictionAges.
+	evictionAges *EvictionAges
+	// priorities holds the priority of each entry, once any entry has been
+	// added with AddWithPriority.
+	priorities []Priority
+	// costs holds the cost of each entry, and totalCost their sum, which is
+	// kept within maxCost, for NewWithMaxCost.
+	costs     []int64
+	totalCost int64
+	maxCost   int64
+	// maxBytes is whether costs are sizes in bytes, worked out by sizeBytes,
+	// for NewWithMaxBytes, and sizeOf is set by WithSizeFunc.
+	maxBytes bool
+	sizeOf   func(value interface{}) int
+	// lowWatermark is how many entries to evict down to when the cache is
+	// full, for WithLowWatermark.
+	lowWatermark int
+	// noEviction is set by WithNoEviction.
+	noEviction bool
+
+	// evictions counts entries dropped to make room for new ones,
+	// expirations those removed because they expired, and replacements
+	// values replaced by new values for the same key.
+	evictions    int64
+	expirations  int64
+	replacements int64
+	// hitCount and missCount count calls to Get, and peekHitCount and
+	// peekMissCount calls to Peek. They are updated atomically, as Peek
+	// (and Get, via SyncLRU) is called with only a read lock held.
+	hitCount      int64
+	missCount     int64
+	peekHitCount  int64
+	peekMissCount int64
+
+	// onEvict is called with each entry that leaves the cache, see
+	// WithOnEvict.
+	onEvict func(key, value interface{}, reason EvictionReason)
+	// onAdd and onUpdate are called when entries are added and their
+	// values replaced, see WithOnAdd and WithOnUpdate.
+	onAdd    func(key, value interface{})
+	onUpdate func(key, old, new interface{})
+	// onEvictBatch is called with the entries removed together by a
+	// single operation, which are collected in batch while batching is
+	// set, see WithOnEvictBatch.
+	onEvictBatch func(entries []Entry, reason EvictionReason)
+	batching     bool
+	batch        []Entry
+	// logger is set by WithLogger.
+	logger Logger
+	// watchers holds the channels returned by Watch for each key.
+	watchers map[interface{}][]chan WatchEvent
+	// drained is set by WithDrainEvicted, in which case evicted holds the
+	// entries that have left the cache since DrainEvicted was last called.
+	drained bool
+	evicted []Entry
+}
+
+// Entry is a key and value pair held by the cache.
+type Entry struct {
+	Key   interface{}
+	Value interface{}
+}
+
+// Keyer can be implemented by key types that want to control their identity in
+// the cache. Two keys whose CacheKey values are equal are treated as the same
+// key, regardless of any other fields they have. CacheKey must return a
+// comparable value, and is called on every cache operation involving the key
+// (including when it is evicted), so it should be cheap.
+type Keyer interface {
+	CacheKey() interface{}
+}
+
+// normalizeKey applies the normalizer configured with WithNormalizer, if any.
+func (lru *LRU) normalizeKey(key interface{}) interface{} {
+	if lru.normalize != nil {
+		return lru.normalize(key)
+	}
+	return key
+}
+
+// identity returns the value that elements is indexed by for a key passed in
+// by a caller.
+func (lru *LRU) identity(key interface{}) interface{} {
+	return mapKey(lru.normalizeKey(key))
+}
+
+// mapKey returns the value used to index the cache for key.
+func mapKey(key interface{}) interface{} {
+	if k, ok := key.(Keyer); ok {
+		return keyerKey{k.CacheKey()}
+	}
+	return key
+}
+
+// keyerKey indexes the cache for a Keyer, so that its CacheKey can't be
+// mistaken for a plain key that happens to be equal to it.
+type keyerKey struct {
+	key interface{}
 }
 
 type cacheEntry struct {
@@ -27,22 +181,43 @@ type cacheEntry struct {
 	value      interface{}
 }
 
+// initialBufSize returns the length of the buffer for a new cache that will
+// hold no more than size items. Small caches get a buffer for all of them
+// straight away, larger ones grow theirs as needed.
+func initialBufSize(size int) int {
+	if size >= 100 {
+		return 101
+	}
+	return size + 1
+}
+
 // Create a new LRU cache that will hold no more than the given number of items,
-func New(size int) *LRU {
-	if size > maxLRUSize || size <= 0 {
-		panic("size must not be <= 0 or >= 2^32")
+// configured by any options given. A size of 0 makes the cache unbounded: it
+// never evicts entries to make room for new ones, and it is up to the caller
+// to remove entries, such as with TrimTo.
+func New(size int, options ...Option) *LRU {
+	if size > maxLRUSize || size < 0 {
+		panic("size must not be < 0 or >= 2^32")
 	}
-	initialBufSize := size + 1
-	if initialBufSize > 100 {
-		initialBufSize = 101
+	unbounded := size == 0
+	if unbounded {
+		size = maxLRUSize
 	}
 	lru := &LRU{
-		size:     0,
-		maxSize:  size,
-		buf:      make([]cacheEntry, initialBufSize),
-		elements: make(map[interface{}]uint32, initialBufSize),
+		unbounded: unbounded,
+		size:      0,
+		maxSize:   size,
+		buf:       make([]cacheEntry, initialBufSize(size)),
+		elements:  make(map[interface{}]uint32, initialBufSize(size)),
+		clock:     WallClock,
 	}
 	lru.root = &lru.buf[0]
+	for _, option := range options {
+		option(lru)
+	}
+	if timeout := lru.shortestTimeout(); timeout > 0 {
+		lru.wheel = newTimingWheel(int64(timeout), lru.clock.Now().UnixNano())
+	}
 	return lru
 }
 
@@ -53,61 +228,462 @@ func (lru *LRU) Len() int {
 
 // Add a new entry into the LRU cache
 func (lru *LRU) Add(key, value interface{}) {
-	elem, exists := lru.elements[key]
+	key = lru.normalizeKey(key)
+	lru.recordUse(key)
+	elem, exists := lru.elements[mapKey(key)]
 	if exists {
-		entry := &lru.buf[elem]
-		lru.moveToFront(elem, entry)
-		// Update the value
-		entry.value = value
+		lru.touch(elem, &lru.buf[elem])
+		lru.setValue(elem, value)
+		lru.written(elem)
+		if lru.maxBytes {
+			lru.setCost(elem, lru.sizeBytes(key, value))
+		}
 	} else {
-		// We are adding an element, make sure there is room
-		if lru.size < lru.maxSize {
-			// grab the next element
-			lru.size++
-			elem = uint32(lru.size)
-			if lru.size >= len(lru.buf) {
-				lru.realloc()
-			}
-		} else {
-			// reuse the least recently used element
-			// Note: if we ever support Remove(), we can keep the 'removed'
-			// items on a separate linked list of locations that are available.
-			// Still allowing us to avoid allocating/freeing records frequently.
-			elem = lru.root.prev
-			delete(lru.elements, lru.buf[elem].key)
+		lru.insert(key, value, lru.costOf(key, value))
+	}
+}
+
+// TryAdd is like Add, but returns whether the key is now in the cache. A new
+// key isn't added if the cache is full and created WithNoEviction, or if an
+// admission policy (such as WithTinyLFU) turns it away.
+func (lru *LRU) TryAdd(key, value interface{}) bool {
+	key = lru.normalizeKey(key)
+	lru.recordUse(key)
+	elem, exists := lru.elements[mapKey(key)]
+	if !exists {
+		return lru.insert(key, value, lru.costOf(key, value))
+	}
+	lru.touch(elem, &lru.buf[elem])
+	lru.setValue(elem, value)
+	lru.written(elem)
+	if lru.maxBytes {
+		lru.setCost(elem, lru.sizeBytes(key, value))
+		_, exists = lru.elements[mapKey(key)]
+	}
+	return exists
+}
+
+// Update looks up key and passes its current value (or nil if it is not
+// present) to fn. If fn returns write=true, the returned value is stored
+// under key, adding the entry if it did not exist. The entry is only treated
+// as recently accessed once, and only if it is written. If write is false,
+// the cache is left untouched.
+func (lru *LRU) Update(key interface{}, fn func(old interface{}, exists bool) (new interface{}, write bool)) {
+	key = lru.normalizeKey(key)
+	elem, exists := lru.elements[mapKey(key)]
+	var old interface{}
+	// An expired entry is still in the buffer, and can be overwritten in
+	// place, but fn should see it as missing.
+	live := exists && !lru.expiredAt(elem, lru.nowNano())
+	if live {
+		old = lru.buf[elem].value
+	}
+	value, write := fn(old, live)
+	if !write {
+		return
+	}
+	lru.recordUse(key)
+	if exists {
+		lru.touch(elem, &lru.buf[elem])
+		lru.setValue(elem, value)
+		lru.written(elem)
+		if lru.maxBytes {
+			lru.setCost(elem, lru.sizeBytes(key, value))
+		}
+	} else {
+		lru.insert(key, value, lru.costOf(key, value))
+	}
+}
+
+// insert adds a key that is known not to be in the cache, evicting an entry
+// (normally the least recently used) if the cache is full (unless WithTinyLFU decides not to
+// admit the new key). It returns whether the key was added.
+func (lru *LRU) insert(key, value interface{}, cost int64) bool {
+	if lru.noEviction && (lru.size >= lru.maxSize || (lru.costs != nil && lru.totalCost+cost > lru.maxCost)) {
+		return false
+	}
+	if lru.costs != nil && !lru.makeRoomFor(key, cost) {
+		return false
+	}
+	if lru.lowWatermark > 0 && lru.size >= lru.maxSize {
+		low := lru.lowWatermark
+		if !lru.evictUntil(key, func() bool { return lru.size <= low }) {
+			return false
 		}
-		if elem >= uint32(len(lru.buf)) {
-			panic(fmt.Sprintf("element %d outside of buffer range: %d", elem, len(lru.buf)))
+	}
+	if lru.ghost != nil {
+		lru.ghost.remove(key)
+	}
+	var elem uint32
+	var evicted *Entry
+	// We are adding an element, make sure there is room
+	if lru.size < lru.maxSize {
+		// grab the next element
+		lru.size++
+		elem = uint32(lru.size)
+		if lru.size >= len(lru.buf) {
+			lru.realloc()
 		}
-		entry := &lru.buf[elem]
-		entry.key = key
-		entry.value = value
-		lru.elements[key] = elem
-		lru.moveToFront(elem, entry)
+	} else {
+		// reuse the least recently used element
+		// Note: removing entries keeps buf[1:size+1] fully populated (see
+		// removeElem), so we never need a separate free list.
+		elem = lru.victim()
+		if !lru.admit(key, elem) {
+			return false
+		}
+		if lru.reportsEvictions() {
+			evicted = &Entry{Key: lru.buf[elem].key, Value: lru.buf[elem].value}
+		}
+		lru.evicting(elem)
+		delete(lru.elements, mapKey(lru.buf[elem].key))
+		lru.unschedule(elem)
+		lru.policyRemoving(elem, &lru.buf[elem])
+		lru.evictions++
+	}
+	if elem >= uint32(len(lru.buf)) {
+		panic(fmt.Sprintf("element %d outside of buffer range: %d", elem, len(lru.buf)))
+	}
+	entry := &lru.buf[elem]
+	entry.key = key
+	entry.value = value
+	lru.elements[mapKey(key)] = elem
+	lru.setMarked(elem, false)
+	lru.place(elem, entry)
+	if lru.priorities != nil {
+		lru.priorities[elem] = PriorityNormal
+	}
+	if lru.accessCounts != nil {
+		lru.accessCounts[elem] = 0
+	}
+	if lru.costs != nil {
+		lru.costs[elem] = cost
+		lru.totalCost += cost
 	}
+	if lru.meta != nil {
+		lru.meta[elem] = entryMeta{}
+	}
+	lru.written(elem)
+	if lru.meta != nil {
+		lru.meta[elem].added = lru.meta[elem].stored
+	}
+	if evicted != nil {
+		lru.left(evicted.Key, evicted.Value, CapacityEvicted)
+	}
+	if lru.onAdd != nil {
+		lru.onAdd(key, value)
+	}
+	lru.notifyWatchers(WatchEvent{Kind: WatchAdded, Key: key, Value: value})
+	return true
+}
+
+// evictsOneLRU returns whether insert, adding a key to a full cache, always
+// evicts the least recently used entry, and only that one, to make room for
+// it: nothing refuses the new entry, or evicts any more, or picks a different
+// victim.
+func (lru *LRU) evictsOneLRU() bool {
+	return !lru.noEviction && lru.costs == nil && lru.lowWatermark == 0 &&
+		!lru.filtersAdmission() && lru.evictsLRU()
 }
 
 // Get returns the Value associated with key, and a boolean as to whether it actually exists in the cache.
 // If it does exist in the cache, then it is treated as recently accessed.
 func (lru *LRU) Get(key interface{}) (interface{}, bool) {
-	elem, exists := lru.elements[key]
+	lru.profile(key)
+	value, ok := lru.get(key)
+	lru.countGet(ok)
+	if !ok {
+		lru.countGhost(key)
+	}
+	return value, ok
+}
+
+// get is Get, without counting the call in HitCounts.
+func (lru *LRU) get(key interface{}) (interface{}, bool) {
+	key = lru.normalizeKey(key)
+	elem, exists := lru.elements[mapKey(key)]
 	if exists {
+		now := lru.nowNano()
+		if lru.expiredAt(elem, now) {
+			if !lru.serveStale(elem, now) {
+				lru.removeElem(elem, Expired)
+				return nil, false
+			}
+			// A stale hit is still a use, but mustn't refresh an idle
+			// timeout.
+			entry := &lru.buf[elem]
+			lru.touch(elem, entry)
+			lru.countAccess(elem)
+			return entry.value, true
+		}
+		if lru.expiresEarly(elem, now) {
+			return nil, false
+		}
 		entry := &lru.buf[elem]
-		lru.moveToFront(elem, entry)
+		lru.touch(elem, entry)
+		lru.accessed(elem, now)
+		lru.recordUse(key)
+		lru.countAccess(elem)
 		return entry.value, true
 	} else {
 		return nil, false
 	}
 }
 
+// GetWithDefault is like Get, but returns def if key is not in the cache. def
+// is not added to the cache.
+func (lru *LRU) GetWithDefault(key, def interface{}) interface{} {
+	if value, ok := lru.Get(key); ok {
+		return value
+	}
+	return def
+}
+
 // Peek is just like Get() except it doesn't affect if it was 'recently accessed'
 func (lru *LRU) Peek(key interface{}) (interface{}, bool) {
-	if elem, exists := lru.elements[key]; exists {
+	if elem, exists := lru.lookup(key); exists {
+		lru.countPeek(true)
 		return lru.buf[elem].value, true
 	}
+	lru.countPeek(false)
 	return nil, false
 }
 
+// lookup returns where key is stored in buf, if it is in the cache and has
+// not expired. It does not modify the cache.
+func (lru *LRU) lookup(key interface{}) (uint32, bool) {
+	elem, exists := lru.elements[lru.identity(key)]
+	if !exists || lru.expiredAt(elem, lru.nowNano()) {
+		return 0, false
+	}
+	return elem, true
+}
+
+// refresh marks elem as used, as get does, without counting it in the stats.
+func (lru *LRU) refresh(elem uint32) {
+	lru.touch(elem, &lru.buf[elem])
+	lru.accessed(elem, lru.nowNano())
+}
+
+// PeekMostRecentN returns up to n entries, starting with the most recently
+// used. It does not affect how recently any entry was accessed.
+func (lru *LRU) PeekMostRecentN(n int) []Entry {
+	return lru.peekN(n, lru.Range)
+}
+
+// PeekLeastRecentN returns up to n entries, starting with the least recently
+// used, which is the next entry to be evicted. It does not affect how recently
+// any entry was accessed.
+func (lru *LRU) PeekLeastRecentN(n int) []Entry {
+	return lru.peekN(n, lru.RangeReverse)
+}
+
+func (lru *LRU) peekN(n int, rangeFn func(func(key, value interface{}) bool)) []Entry {
+	if n > lru.size {
+		n = lru.size
+	}
+	if n <= 0 {
+		return nil
+	}
+	entries := make([]Entry, 0, n)
+	rangeFn(func(key, value interface{}) bool {
+		entries = append(entries, Entry{Key: key, Value: value})
+		return len(entries) < n
+	})
+	return entries
+}
+
+// Range calls fn for each entry in the cache, starting with the most recently
+// used, until fn returns false. It does not affect how recently any entry was
+// accessed. Expired entries are skipped. fn must not modify the cache.
+func (lru *LRU) Range(fn func(key, value interface{}) bool) {
+	now := lru.nowNano()
+	for elem := lru.root.next; elem != 0; elem = lru.buf[elem].next {
+		if lru.expiredAt(elem, now) {
+			continue
+		}
+		entry := &lru.buf[elem]
+		if !fn(entry.key, entry.value) {
+			return
+		}
+	}
+}
+
+// RangeReverse is like Range, but starts with the least recently used entry.
+// Adding the entries to an empty cache in the order they are visited recreates
+// the same recency order.
+func (lru *LRU) RangeReverse(fn func(key, value interface{}) bool) {
+	now := lru.nowNano()
+	for elem := lru.root.prev; elem != 0; elem = lru.buf[elem].prev {
+		if lru.expiredAt(elem, now) {
+			continue
+		}
+		entry := &lru.buf[elem]
+		if !fn(entry.key, entry.value) {
+			return
+		}
+	}
+}
+
+// RemoveIf removes every entry for which fn returns true, and returns how many
+// entries were removed. Expired entries are not passed to fn. fn must not
+// modify the cache.
+func (lru *LRU) RemoveIf(fn func(key, value interface{}) bool) int {
+	now := lru.nowNano()
+	return lru.removeWhere(func(elem uint32) bool {
+		entry := &lru.buf[elem]
+		return !lru.expiredAt(elem, now) && fn(entry.key, entry.value)
+	}, Removed)
+}
+
+// removeWhere removes every element for which match returns true, for the
+// given reason, and returns how many were removed.
+func (lru *LRU) removeWhere(match func(elem uint32) bool, reason EvictionReason) int {
+	if lru.beginBatch() {
+		defer lru.endBatch(reason)
+	}
+	removed := 0
+	for elem := lru.root.next; elem != 0; {
+		next := lru.buf[elem].next
+		if match(elem) {
+			if next == uint32(lru.size) {
+				// removeElem is about to move the last element into this slot
+				next = elem
+			}
+			lru.removeElem(elem, reason)
+			removed++
+		}
+		elem = next
+	}
+	return removed
+}
+
+// RetainOnly removes every entry whose key is not in keys, and returns how
+// many entries were removed.
+func (lru *LRU) RetainOnly(keys map[interface{}]struct{}) int {
+	keep := keys
+	for k := range keys {
+		if _, ok := k.(Keyer); ok || lru.normalize != nil {
+			// We need to compare keys the way the cache does, rather than
+			// by the values that were passed in.
+			keep = make(map[interface{}]struct{}, len(keys))
+			for k := range keys {
+				keep[lru.identity(k)] = struct{}{}
+			}
+			break
+		}
+	}
+	return lru.RemoveIf(func(key, _ interface{}) bool {
+		_, ok := keep[mapKey(key)]
+		return !ok
+	})
+}
+
+// TrimTo evicts entries until no more than n remain, and returns how many were
+// evicted. Entries are chosen as they would be to make room for a new one, so
+// normally the least recently used go first. The cache can fill up again
+// afterwards, its maximum size is not changed.
+func (lru *LRU) TrimTo(n int) int {
+	if n < 0 {
+		panic("n must be >= 0")
+	}
+	if lru.beginBatch() {
+		defer lru.endBatch(CapacityEvicted)
+	}
+	removed := 0
+	for lru.size > n {
+		lru.removeElem(lru.victim(), CapacityEvicted)
+		removed++
+	}
+	return removed
+}
+
+// Purge removes every entry from the cache, passing each to the OnEvict
+// callback, if there is one, with the reason Purged. The buffers are kept for
+// the cache to fill up again, see Compact to free them.
+func (lru *LRU) Purge() {
+	if lru.beginBatch() {
+		defer lru.endBatch(Purged)
+	}
+	for lru.size > 0 {
+		lru.removeElem(lru.root.prev, Purged)
+	}
+}
+
+// setMaxSize changes how many entries the cache can hold, evicting entries if
+// it holds more than that.
+func (lru *LRU) setMaxSize(size int) {
+	lru.debugf("lru: max size changed from %d to %d", lru.maxSize, size)
+	lru.maxSize = size
+	lru.unbounded = false
+	lru.TrimTo(size)
+}
+
+// remove removes key from the cache, returning the value it had, and whether
+// it was there.
+func (lru *LRU) remove(key interface{}) (interface{}, bool) {
+	elem, exists := lru.elements[lru.identity(key)]
+	if !exists {
+		return nil, false
+	}
+	value := lru.buf[elem].value
+	lru.removeElem(elem, Removed)
+	return value, true
+}
+
+// removeOldest removes the least recently used entry, returning it, and
+// whether there was one.
+func (lru *LRU) removeOldest() (Entry, bool) {
+	elem := lru.root.prev
+	if elem == 0 {
+		return Entry{}, false
+	}
+	entry := Entry{Key: lru.buf[elem].key, Value: lru.buf[elem].value}
+	lru.removeElem(elem, CapacityEvicted)
+	return entry, true
+}
+
+// removeElem unlinks elem from the list and forgets its key, then reports that
+// the entry has left the cache for the given reason. To keep the used part of the
+// buffer contiguous, the last element in the buffer is moved into the freed
+// slot. The slot that is no longer used is cleared, so that the cache doesn't
+// keep the removed key and value from being garbage collected.
+func (lru *LRU) removeElem(elem uint32, reason EvictionReason) {
+	if reason == CapacityEvicted {
+		lru.evicting(elem)
+	}
+	entry := &lru.buf[elem]
+	key, value := entry.key, entry.value
+	lru.buf[entry.prev].next = entry.next
+	lru.buf[entry.next].prev = entry.prev
+	delete(lru.elements, mapKey(entry.key))
+	lru.unschedule(elem)
+	lru.policyRemoving(elem, entry)
+	last := uint32(lru.size)
+	if elem != last {
+		moved := lru.buf[last]
+		lru.buf[elem] = moved
+		lru.buf[moved.prev].next = elem
+		lru.buf[moved.next].prev = elem
+		lru.elements[mapKey(moved.key)] = elem
+		if lru.meta != nil {
+			lru.meta[elem] = lru.meta[last]
+			lru.wheelMoved(elem)
+		}
+		lru.policyMoved(last, elem)
+	}
+	lru.buf[last] = cacheEntry{}
+	if lru.meta != nil {
+		lru.meta[last] = entryMeta{}
+	}
+	lru.setMarked(last, false)
+	if lru.priorities != nil {
+		lru.priorities[last] = PriorityNormal
+	}
+	lru.size--
+	lru.left(key, value, reason)
+}
+
 func (lru *LRU) realloc() {
 	// We save 1 slot at the beginning for root, this makes 'offset = 0' an invalid value
 	// which makes debugging much easier, and we need start and end pointers anyway.
@@ -115,20 +691,79 @@ func (lru *LRU) realloc() {
 	if nextSize > lru.maxSize {
 		nextSize = lru.maxSize
 	}
-	newBuf := make([]cacheEntry, nextSize+1)
-	copy(newBuf, lru.buf)
-	lru.buf = newBuf
-	lru.root = &newBuf[0]
+	lru.resizeBuffers(nextSize)
+	lru.debugf("lru: grew buffer to %d entries", nextSize)
 	if nextSize == lru.maxSize {
 		// We let the map grow using normal go growth, but when we hit maxSize,
 		// we know that we won't ever hold more entries than that, so we don't
 		// want to have it grow arbitrarily larger.
-		elements := make(map[interface{}]uint32, nextSize)
-		for k, v := range lru.elements {
-			elements[k] = v
-		}
-		lru.elements = elements
+		lru.rebuildMap(nextSize)
+	}
+}
+
+// resizeBuffers reallocates buf, and the slices parallel to it, to hold size
+// entries. It must be at least lru.size.
+func (lru *LRU) resizeBuffers(size int) {
+	newBuf := make([]cacheEntry, size+1)
+	copy(newBuf, lru.buf)
+	lru.buf = newBuf
+	if lru.marks != nil {
+		newMarks := make([]uint64, len(newBuf)/64+1)
+		copy(newMarks, lru.marks)
+		lru.marks = newMarks
+	}
+	lru.root = &newBuf[0]
+	if lru.costs != nil {
+		newCosts := make([]int64, size+1)
+		copy(newCosts, lru.costs)
+		lru.costs = newCosts
+	}
+	if lru.stamps != nil {
+		newStamps := make([]uint64, size+1)
+		copy(newStamps, lru.stamps)
+		lru.stamps = newStamps
+	}
+	if lru.accessCounts != nil {
+		newCounts := make([]uint64, size+1)
+		copy(newCounts, lru.accessCounts)
+		lru.accessCounts = newCounts
+	}
+	if lru.priorities != nil {
+		newPriorities := make([]Priority, size+1)
+		copy(newPriorities, lru.priorities)
+		lru.priorities = newPriorities
+	}
+	if lru.meta != nil {
+		newMeta := make([]entryMeta, size+1)
+		copy(newMeta, lru.meta)
+		lru.meta = newMeta
+	}
+}
+
+// rebuildMap copies elements into a new map with room for size entries.
+func (lru *LRU) rebuildMap(size int) {
+	elements := make(map[interface{}]uint32, size)
+	for k, v := range lru.elements {
+		elements[k] = v
+	}
+	lru.elements = elements
+}
+
+// Compact reallocates the cache's buffers and map to fit the entries it
+// currently holds, releasing the memory they grew to when the cache was
+// fuller, such as before a call to RemoveIf or RemoveExpired. They grow again
+// as needed, as they did when the cache was new. It is O(n), so it is best
+// called after removing a lot of entries, rather than routinely.
+func (lru *LRU) Compact() {
+	size := lru.size
+	if size < initialBufSize(lru.maxSize)-1 {
+		size = initialBufSize(lru.maxSize) - 1
+	}
+	if size+1 < len(lru.buf) {
+		lru.resizeBuffers(size)
+		lru.debugf("lru: compacted buffer to %d entries", size)
 	}
+	lru.rebuildMap(lru.size)
 }
 
 func (lru *LRU) moveToFront(elem uint32, entry *cacheEntry) {
@@ -4,7 +4,9 @@
 package lru_test
 
 import (
+	"runtime"
 	"testing"
+	"time"
 
 	gc "gopkg.in/check.v1"
 
@@ -129,3 +131,504 @@ func (s *LRUSuite) TestLRULen(c *gc.C) {
 		}
 	}
 }
+
+func (s *LRUSuite) TestLRUUpdateExisting(c *gc.C) {
+	cache := simpleFullCache()
+	cache.Update(1, func(old interface{}, exists bool) (interface{}, bool) {
+		c.Check(exists, gc.Equals, true)
+		c.Check(old, gc.Equals, "a")
+		return "aa", true
+	})
+	checkPeekExists(c, cache, 1, "aa")
+	// 1 was promoted, so 2 is now the least recently used
+	cache.Add("x", "y")
+	checkPeekExists(c, cache, 1, "aa")
+	checkPeekMissing(c, cache, 2)
+}
+
+func (s *LRUSuite) TestLRUUpdateMissing(c *gc.C) {
+	cache := simpleFullCache()
+	cache.Update("new", func(old interface{}, exists bool) (interface{}, bool) {
+		c.Check(exists, gc.Equals, false)
+		c.Check(old, gc.IsNil)
+		return "value", true
+	})
+	checkPeekExists(c, cache, "new", "value")
+	checkPeekMissing(c, cache, 1)
+	c.Check(cache.Len(), gc.Equals, 10)
+}
+
+func (s *LRUSuite) TestLRUUpdateNoWrite(c *gc.C) {
+	cache := simpleFullCache()
+	cache.Update(1, func(old interface{}, exists bool) (interface{}, bool) {
+		return "ignored", false
+	})
+	cache.Update("new", func(old interface{}, exists bool) (interface{}, bool) {
+		return "ignored", false
+	})
+	checkPeekExists(c, cache, 1, "a")
+	checkPeekMissing(c, cache, "new")
+	// 1 was not promoted, so it is still the first to go
+	cache.Add("x", "y")
+	checkPeekMissing(c, cache, 1)
+}
+
+func (s *LRUSuite) TestLRURemoveIf(c *gc.C) {
+	cache := simpleFullCache()
+	removed := cache.RemoveIf(func(key, value interface{}) bool {
+		return key.(int)%2 == 0
+	})
+	c.Check(removed, gc.Equals, 5)
+	c.Check(cache.Len(), gc.Equals, 5)
+	for _, k := range []int{0, 2, 4, 6, 8} {
+		checkPeekMissing(c, cache, k)
+	}
+	checkPeekExists(c, cache, 1, "a")
+	checkPeekExists(c, cache, 3, "c")
+	checkPeekExists(c, cache, 5, "e")
+	checkPeekExists(c, cache, 7, "g")
+	checkPeekExists(c, cache, 9, "i")
+	// Fill the cache back up, and make sure recency order was preserved
+	for i := 10; i < 16; i++ {
+		cache.Add(i, i)
+	}
+	c.Check(cache.Len(), gc.Equals, 10)
+	checkPeekMissing(c, cache, 1)
+	checkPeekExists(c, cache, 3, "c")
+	checkPeekExists(c, cache, 15, 15)
+}
+
+func (s *LRUSuite) TestLRURemoveIfAll(c *gc.C) {
+	cache := simpleFullCache()
+	removed := cache.RemoveIf(func(key, value interface{}) bool {
+		return true
+	})
+	c.Check(removed, gc.Equals, 10)
+	c.Check(cache.Len(), gc.Equals, 0)
+	cache.Add("a", "b")
+	checkPeekExists(c, cache, "a", "b")
+	c.Check(cache.Len(), gc.Equals, 1)
+}
+
+func (s *LRUSuite) TestLRURemoveIfNone(c *gc.C) {
+	cache := simpleFullCache()
+	removed := cache.RemoveIf(func(key, value interface{}) bool {
+		return false
+	})
+	c.Check(removed, gc.Equals, 0)
+	c.Check(cache.Len(), gc.Equals, 10)
+}
+
+func (s *LRUSuite) TestLRURetainOnly(c *gc.C) {
+	cache := simpleFullCache()
+	removed := cache.RetainOnly(map[interface{}]struct{}{
+		1:       {},
+		5:       {},
+		"other": {},
+	})
+	c.Check(removed, gc.Equals, 8)
+	c.Check(cache.Len(), gc.Equals, 2)
+	checkPeekExists(c, cache, 1, "a")
+	checkPeekExists(c, cache, 5, "e")
+	checkPeekMissing(c, cache, 2)
+	checkPeekMissing(c, cache, "other")
+}
+
+func collectKeys(rangeFn func(func(key, value interface{}) bool)) []interface{} {
+	var keys []interface{}
+	rangeFn(func(key, value interface{}) bool {
+		keys = append(keys, key)
+		return true
+	})
+	return keys
+}
+
+func (s *LRUSuite) TestLRURange(c *gc.C) {
+	cache := simpleFullCache()
+	cache.Get(3)
+	c.Check(collectKeys(cache.Range), gc.DeepEquals,
+		[]interface{}{3, 0, 9, 8, 7, 6, 5, 4, 2, 1})
+}
+
+func (s *LRUSuite) TestLRURangeReverse(c *gc.C) {
+	cache := simpleFullCache()
+	cache.Get(3)
+	c.Check(collectKeys(cache.RangeReverse), gc.DeepEquals,
+		[]interface{}{1, 2, 4, 5, 6, 7, 8, 9, 0, 3})
+}
+
+func (s *LRUSuite) TestLRURangeReverseRecreates(c *gc.C) {
+	cache := simpleFullCache()
+	cache.Get(5)
+	cache.Get(1)
+	copied := lru.New(10)
+	cache.RangeReverse(func(key, value interface{}) bool {
+		copied.Add(key, value)
+		return true
+	})
+	c.Check(collectKeys(copied.Range), gc.DeepEquals, collectKeys(cache.Range))
+}
+
+func (s *LRUSuite) TestLRURangeStops(c *gc.C) {
+	cache := simpleFullCache()
+	var keys []interface{}
+	cache.RangeReverse(func(key, value interface{}) bool {
+		keys = append(keys, key)
+		return len(keys) < 3
+	})
+	c.Check(keys, gc.DeepEquals, []interface{}{1, 2, 3})
+}
+
+func (s *LRUSuite) TestLRUPeekMostRecentN(c *gc.C) {
+	cache := simpleFullCache()
+	cache.Get(3)
+	c.Check(cache.PeekMostRecentN(3), gc.DeepEquals, []lru.Entry{
+		{Key: 3, Value: "c"},
+		{Key: 0, Value: "j"},
+		{Key: 9, Value: "i"},
+	})
+	c.Check(cache.PeekMostRecentN(20), gc.HasLen, 10)
+	c.Check(cache.PeekMostRecentN(0), gc.HasLen, 0)
+}
+
+func (s *LRUSuite) TestLRUPeekLeastRecentN(c *gc.C) {
+	cache := simpleFullCache()
+	cache.Get(1)
+	c.Check(cache.PeekLeastRecentN(2), gc.DeepEquals, []lru.Entry{
+		{Key: 2, Value: "b"},
+		{Key: 3, Value: "c"},
+	})
+	// Peeking does not change the eviction order
+	cache.Add("x", "y")
+	checkPeekMissing(c, cache, 2)
+	checkPeekExists(c, cache, 3, "c")
+}
+
+func (s *LRUSuite) TestLRUGetWithDefault(c *gc.C) {
+	cache := simpleFullCache()
+	c.Check(cache.GetWithDefault(1, "default"), gc.Equals, "a")
+	c.Check(cache.GetWithDefault("nope", "default"), gc.Equals, "default")
+	checkPeekMissing(c, cache, "nope")
+	// 1 was accessed, so 2 is evicted first
+	cache.Add("x", "y")
+	checkPeekExists(c, cache, 1, "a")
+	checkPeekMissing(c, cache, 2)
+}
+
+func (s *LRUSuite) TestLRUAddWithPriority(c *gc.C) {
+	cache := lru.New(4)
+	cache.AddWithPriority("a", 1, lru.PriorityHigh)
+	cache.AddWithPriority("b", 2, lru.PriorityLow)
+	cache.Add("c", 3)
+	cache.Add("d", 4)
+	// a is the least recently used, but b has a lower priority.
+	cache.Add("e", 5)
+	checkPeekMissing(c, cache, "b")
+	// Of the normal priority entries, c is the least recently used.
+	cache.Add("f", 6)
+	checkPeekMissing(c, cache, "c")
+	checkPeekExists(c, cache, "a", 1)
+	// Lowering a's priority makes it next to go.
+	cache.AddWithPriority("a", 10, lru.PriorityLow)
+	cache.Add("g", 7)
+	checkPeekMissing(c, cache, "a")
+	c.Check(cache.Len(), gc.Equals, 4)
+}
+
+func (s *LRUSuite) TestLRUAddWithPriorityWindow(c *gc.C) {
+	cache := lru.New(100)
+	cache.AddWithPriority("high", 0, lru.PriorityHigh)
+	for i := 0; i < 99; i++ {
+		cache.Add(i, i)
+	}
+	// Normal priority entries are evicted ahead of it.
+	for i := 100; i < 200; i++ {
+		cache.Add(i, i)
+	}
+	checkPeekExists(c, cache, "high", 0)
+	// Until the least recently used entries are all high priority too.
+	for i := 200; i < 300; i++ {
+		cache.AddWithPriority(i, i, lru.PriorityHigh)
+	}
+	checkPeekMissing(c, cache, "high")
+}
+
+func (s *LRUSuite) TestLRUAddWithPriorityRemove(c *gc.C) {
+	cache := lru.New(10)
+	for i := 0; i < 10; i++ {
+		cache.AddWithPriority(i, i, lru.Priority(i%3-1))
+	}
+	// Removing entries moves others around in the buffer, their priorities
+	// need to go with them.
+	cache.RemoveIf(func(key, _ interface{}) bool { return key.(int) < 3 })
+	for i := 10; i < 13; i++ {
+		cache.Add(i, i)
+	}
+	cache.Add(13, 13)
+	// 3 and 6 are low priority, so 3 is evicted first, then 6.
+	checkPeekMissing(c, cache, 3)
+	cache.Add(14, 14)
+	checkPeekMissing(c, cache, 6)
+	checkPeekExists(c, cache, 4, 4)
+}
+
+func (s *LRUSuite) TestLRUCompact(c *gc.C) {
+	cache := lru.New(10000, lru.WithExpiry(time.Hour), lru.WithSIEVE())
+	for i := 0; i < 10000; i++ {
+		cache.Add(i, i)
+	}
+	full := cache.SizeBytes()
+	cache.RemoveIf(func(key, _ interface{}) bool { return key.(int) >= 10 })
+	cache.Compact()
+	c.Check(cache.SizeBytes() < full/10, gc.Equals, true)
+	for i := 0; i < 10; i++ {
+		checkGet(c, cache, i, i, true)
+	}
+	// It grows again as needed.
+	for i := 10; i < 20000; i++ {
+		cache.Add(i, i)
+	}
+	c.Check(cache.Len(), gc.Equals, 10000)
+	checkPeekExists(c, cache, 19999, 19999)
+	c.Check(cache.RemoveExpired(), gc.Equals, 0)
+}
+
+func (s *LRUSuite) TestLRUTrimTo(c *gc.C) {
+	cache := simpleFullCache()
+	checkGet(c, cache, 1, "a", true)
+	c.Check(cache.TrimTo(3), gc.Equals, 7)
+	c.Check(collectKeys(cache.Range), gc.DeepEquals, []interface{}{1, 0, 9})
+	c.Check(cache.TrimTo(5), gc.Equals, 0)
+	// It fills up to its original size again.
+	for i := 10; i < 20; i++ {
+		cache.Add(i, i)
+	}
+	c.Check(cache.Len(), gc.Equals, 10)
+	c.Check(cache.TrimTo(0), gc.Equals, 10)
+	c.Check(cache.Len(), gc.Equals, 0)
+}
+
+func (s *LRUSuite) TestLRUUnboundedTrimTo(c *gc.C) {
+	cache := lru.New(0)
+	for i := 0; i < 1000; i++ {
+		cache.Add(i, i)
+	}
+	c.Check(cache.TrimTo(10), gc.Equals, 990)
+	checkPeekMissing(c, cache, 989)
+	checkPeekExists(c, cache, 990, 990)
+}
+
+func (s *LRUSuite) TestLRUUnbounded(c *gc.C) {
+	cache := lru.New(0, lru.WithTinyLFU())
+	for i := 0; i < 10000; i++ {
+		cache.Add(i, i)
+	}
+	c.Check(cache.Len(), gc.Equals, 10000)
+	checkPeekExists(c, cache, 0, 0)
+	checkPeekExists(c, cache, 9999, 9999)
+}
+
+func (s *LRUSuite) TestLRUNegativeSize(c *gc.C) {
+	c.Check(func() { lru.New(-1) }, gc.PanicMatches, "size must not be < 0 .*")
+}
+
+func (s *LRUSuite) TestLRUWatch(c *gc.C) {
+	cache := lru.New(2)
+	events, stop := cache.Watch("a")
+	cache.Add("a", 1)
+	cache.Add("b", 2)
+	cache.Add("a", 3)
+	cache.Add("c", 4)
+	cache.Add("d", 5)
+	c.Check(<-events, gc.Equals, lru.WatchEvent{Kind: lru.WatchAdded, Key: "a", Value: 1})
+	c.Check(<-events, gc.Equals, lru.WatchEvent{Kind: lru.WatchUpdated, Key: "a", Value: 3})
+	c.Check(<-events, gc.Equals, lru.WatchEvent{
+		Kind:   lru.WatchEvicted,
+		Key:    "a",
+		Value:  3,
+		Reason: lru.CapacityEvicted,
+	})
+	stop()
+	stop()
+	cache.Add("a", 6)
+	_, ok := <-events
+	c.Check(ok, gc.Equals, false)
+}
+
+func (s *LRUSuite) TestLRUWatchDropsOldest(c *gc.C) {
+	cache := lru.New(2)
+	events, stop := cache.Watch("a")
+	defer stop()
+	for i := 0; i < 20; i++ {
+		cache.Add("a", i)
+	}
+	var values []interface{}
+	for len(events) > 0 {
+		values = append(values, (<-events).Value)
+	}
+	c.Check(values, gc.DeepEquals, []interface{}{12, 13, 14, 15, 16, 17, 18, 19})
+}
+
+// waitForFinalizer runs the garbage collector until finalized is closed.
+func waitForFinalizer(c *gc.C, finalized chan struct{}) {
+	for i := 0; i < 100; i++ {
+		runtime.GC()
+		select {
+		case <-finalized:
+			return
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	c.Fatalf("value was not garbage collected")
+}
+
+// finalizedValue returns a value that closes the returned channel when it is
+// garbage collected.
+func finalizedValue() (*[]byte, chan struct{}) {
+	value := new([]byte)
+	*value = make([]byte, 1024)
+	finalized := make(chan struct{})
+	runtime.SetFinalizer(value, func(*[]byte) { close(finalized) })
+	return value, finalized
+}
+
+func (s *LRUSuite) TestLRUReleasesEvicted(c *gc.C) {
+	cache := lru.New(3)
+	value, finalized := finalizedValue()
+	cache.Add("big", value)
+	value = nil
+	for i := 0; i < 3; i++ {
+		cache.Add(i, i)
+	}
+	waitForFinalizer(c, finalized)
+}
+
+func (s *LRUSuite) TestLRUReleasesRemoved(c *gc.C) {
+	cache := lru.New(10)
+	cache.Add(0, 0)
+	value, finalized := finalizedValue()
+	cache.Add("big", value)
+	value = nil
+	cache.Add(1, 1)
+	// Removing an entry from the middle of the buffer moves the last
+	// entry into its place, the slot that is freed up must not keep a
+	// reference.
+	cache.RemoveIf(func(key, _ interface{}) bool { return key == "big" })
+	waitForFinalizer(c, finalized)
+	cache.Add(2, 2)
+	checkPeekExists(c, cache, 1, 1)
+}
+
+func (s *LRUSuite) TestLRUHitCounts(c *gc.C) {
+	cache := lru.New(2)
+	cache.Add("a", 1)
+	cache.Get("a")
+	cache.Get("a")
+	cache.Get("b")
+	cache.Peek("a")
+	cache.Peek("b")
+	cache.Peek("c")
+	c.Check(cache.HitCounts(), gc.Equals, lru.HitCounts{Hit: 2, Miss: 1})
+	c.Check(cache.PeekHitCounts(), gc.Equals, lru.HitCounts{Hit: 1, Miss: 2})
+}
+
+func (s *LRUSuite) TestLRUStats(c *gc.C) {
+	clock := newTestClock()
+	cache := lru.New(3, lru.WithClock(clock), lru.WithExpiry(time.Minute))
+	for i := 0; i < 5; i++ {
+		cache.Add(i, i)
+	}
+	cache.Add(4, 40)
+	cache.Get(4)
+	cache.Get(0)
+	clock.Advance(2 * time.Minute)
+	cache.Get(3)
+	c.Check(cache.Stats(), gc.Equals, lru.Stats{
+		Len:          2,
+		Cap:          3,
+		Hits:         1,
+		Misses:       2,
+		Evictions:    2,
+		Expirations:  1,
+		Replacements: 1,
+	})
+	c.Check(lru.New(0).Stats().Cap, gc.Equals, 0)
+}
+
+func (s *LRUSuite) TestLRUAccessCounts(c *gc.C) {
+	cache := lru.New(3, lru.WithAccessCounts())
+	cache.Add("a", 1)
+	cache.Add("b", 2)
+	cache.Add("c", 3)
+	for i := 0; i < 3; i++ {
+		cache.Get("a")
+	}
+	cache.Get("c")
+	cache.Peek("b")
+	// Replacing the value keeps the count.
+	cache.Add("a", 4)
+	count, ok := cache.AccessCount("a")
+	c.Check(count, gc.Equals, uint64(3))
+	c.Check(ok, gc.Equals, true)
+	_, ok = cache.AccessCount("d")
+	c.Check(ok, gc.Equals, false)
+	// Removing "b" moves the last entry into its place.
+	cache.RemoveIf(func(key, _ interface{}) bool { return key == "b" })
+	c.Check(cache.AccessCounts(), gc.DeepEquals, []lru.KeyCount{
+		{Key: "a", Count: 3},
+		{Key: "c", Count: 1},
+	})
+	cache.Add("d", 5)
+	cache.Add("e", 6)
+	c.Check(cache.AccessCounts(), gc.DeepEquals, []lru.KeyCount{
+		{Key: "e", Count: 0},
+		{Key: "d", Count: 0},
+		{Key: "a", Count: 3},
+	})
+}
+
+func (s *LRUSuite) TestLRUAccessCountNeedsOption(c *gc.C) {
+	cache := lru.New(3)
+	c.Check(func() { cache.AccessCount("a") }, gc.PanicMatches, "AccessCount needs the cache to be created WithAccessCounts")
+}
+
+func (s *LRUSuite) TestLRUResetStats(c *gc.C) {
+	cache := lru.New(2)
+	for i := 0; i < 3; i++ {
+		cache.Add(i, i)
+	}
+	cache.Get(2)
+	cache.Get(0)
+	c.Check(cache.ResetStats(), gc.Equals, lru.Stats{
+		Len:       2,
+		Cap:       2,
+		Hits:      1,
+		Misses:    1,
+		Evictions: 1,
+	})
+	c.Check(cache.Stats(), gc.Equals, lru.Stats{Len: 2, Cap: 2})
+}
+
+func (s *LRUSuite) TestEstimatedHitCounts(c *gc.C) {
+	cache := lru.New(2, lru.WithGhostKeys(2))
+	for _, key := range []string{"a", "b", "c", "d"} {
+		cache.Add(key, key)
+	}
+	// a would have been found in a cache twice the size.
+	checkGet(c, cache, "a", nil, false)
+	checkGet(c, cache, "x", nil, false)
+	checkGet(c, cache, "c", "c", true)
+	c.Check(cache.HitCounts(), gc.Equals, lru.HitCounts{Hit: 1, Miss: 2})
+	c.Check(cache.EstimatedHitCounts(), gc.Equals, lru.HitCounts{Hit: 2, Miss: 1})
+	// Adding a back means it is no longer a ghost, and evicts d.
+	cache.Add("a", "a")
+	checkGet(c, cache, "d", nil, false)
+	c.Check(cache.EstimatedHitCounts(), gc.Equals, lru.HitCounts{Hit: 3, Miss: 1})
+	cache.ResetStats()
+	c.Check(cache.EstimatedHitCounts(), gc.Equals, lru.HitCounts{})
+}
+
+func (s *LRUSuite) TestGhostKeysMustBeBounded(c *gc.C) {
+	c.Check(func() { lru.WithGhostKeys(0) }, gc.PanicMatches, "n must be > 0")
+	c.Check(func() { lru.WithGhostKeys(-1) }, gc.PanicMatches, "n must be > 0")
+}
@@ -0,0 +1,220 @@
+// Copyright 2026 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package lru
+
+import (
+	"container/heap"
+	"time"
+)
+
+// LRUKCache is a cache using the LRU-K algorithm. Rather than evicting the
+// entry whose most recent use was longest ago, it evicts the entry whose K-th
+// most recent use was longest ago, so an entry has to be used K times to be
+// ranked alongside the entries that are used regularly. Entries that have been
+// used fewer than K times are evicted first, least recently used first. With K
+// of 2 (LRU-2), a single scan can't push out the working set.
+// Uses that come in quick succession, such as a read followed by an update of
+// the same key, can be treated as a single use with WithCorrelatedPeriod, so
+// that they don't make an entry look more popular than it is.
+// Entries are kept in a heap ordered by their K-th most recent use, so each
+// operation is O(log n).
+// Note that LRUKCache is *not* thread safe, some form of mutex is necessary if
+// you want to access it from multiple threads.
+type LRUKCache struct {
+	k          int
+	correlated time.Duration
+	clock      Clock
+	maxSize    int
+	entries    []lrukEntry
+	// history holds the times (counted in uses of the cache) of the last k
+	// uses of each entry, k at a time, as a ring indexed by use number.
+	history  []uint64
+	elements map[interface{}]uint32
+	// order is a heap of indexes into entries, with the next to be evicted
+	// first.
+	order lrukHeap
+	// uses counts uses of the cache, and is used as a logical clock to order
+	// the uses of entries.
+	uses uint64
+}
+
+type lrukEntry struct {
+	key   interface{}
+	value interface{}
+	// refs is how many (uncorrelated) times the entry has been used.
+	refs uint64
+	// lastUsed is when the entry was last used (as UnixNano), for deciding
+	// whether uses are correlated.
+	lastUsed  int64
+	heapIndex int
+}
+
+// LRUKOption configures an LRUKCache.
+type LRUKOption func(*LRUKCache)
+
+// WithCorrelatedPeriod treats uses of an entry that come within period of the
+// previous one as part of the same use.
+func WithCorrelatedPeriod(period time.Duration) LRUKOption {
+	return func(c *LRUKCache) {
+		c.correlated = period
+	}
+}
+
+// WithLRUKClock sets the clock used by WithCorrelatedPeriod, which defaults
+// to WallClock.
+func WithLRUKClock(clock Clock) LRUKOption {
+	return func(c *LRUKCache) {
+		c.clock = clock
+	}
+}
+
+// NewLRUK creates an LRUKCache that will hold no more than the given number
+// of items, ranking them by their k-th most recent use.
+func NewLRUK(size, k int, options ...LRUKOption) *LRUKCache {
+	if size > maxLRUSize || size <= 0 {
+		panic("size must not be <= 0 or >= 2^32")
+	}
+	if k <= 0 {
+		panic("k must be > 0")
+	}
+	c := &LRUKCache{
+		k:        k,
+		clock:    WallClock,
+		maxSize:  size,
+		elements: make(map[interface{}]uint32),
+	}
+	c.order.cache = c
+	for _, option := range options {
+		option(c)
+	}
+	return c
+}
+
+// Len gives the number of items in the cache
+func (c *LRUKCache) Len() int {
+	return len(c.entries)
+}
+
+// Add a new entry into the cache. Adding a key that is already in the cache
+// counts as a use of it.
+func (c *LRUKCache) Add(key, value interface{}) {
+	id := mapKey(key)
+	if elem, exists := c.elements[id]; exists {
+		c.entries[elem].value = value
+		c.used(elem)
+		return
+	}
+	var elem uint32
+	if len(c.entries) < c.maxSize {
+		elem = uint32(len(c.entries))
+		c.entries = append(c.entries, lrukEntry{})
+		for i := 0; i < c.k; i++ {
+			c.history = append(c.history, 0)
+		}
+	} else {
+		elem = heap.Pop(&c.order).(uint32)
+		delete(c.elements, mapKey(c.entries[elem].key))
+	}
+	c.entries[elem] = lrukEntry{key: key, value: value}
+	c.elements[id] = elem
+	c.recordUse(elem)
+	heap.Push(&c.order, elem)
+}
+
+// Get returns the Value associated with key, and a boolean as to whether it
+// actually exists in the cache. If it does exist in the cache, then it counts
+// as a use of it.
+func (c *LRUKCache) Get(key interface{}) (interface{}, bool) {
+	if elem, exists := c.elements[mapKey(key)]; exists {
+		c.used(elem)
+		return c.entries[elem].value, true
+	}
+	return nil, false
+}
+
+// Peek is just like Get() except it doesn't count as a use.
+func (c *LRUKCache) Peek(key interface{}) (interface{}, bool) {
+	if elem, exists := c.elements[mapKey(key)]; exists {
+		return c.entries[elem].value, true
+	}
+	return nil, false
+}
+
+// used records a use of elem, and updates its place in the heap.
+func (c *LRUKCache) used(elem uint32) {
+	c.recordUse(elem)
+	heap.Fix(&c.order, c.entries[elem].heapIndex)
+}
+
+// recordUse adds a use of elem to its history, unless it is correlated with
+// the previous one, in which case that is moved forward instead.
+func (c *LRUKCache) recordUse(elem uint32) {
+	c.uses++
+	entry := &c.entries[elem]
+	history := c.history[int(elem)*c.k : int(elem+1)*c.k]
+	var now int64
+	if c.correlated > 0 {
+		now = c.clock.Now().UnixNano()
+		if entry.refs > 0 && now-entry.lastUsed < int64(c.correlated) {
+			history[(entry.refs-1)%uint64(c.k)] = c.uses
+			entry.lastUsed = now
+			return
+		}
+	}
+	history[entry.refs%uint64(c.k)] = c.uses
+	entry.refs++
+	entry.lastUsed = now
+}
+
+// evictBefore returns whether elem a should be evicted before elem b.
+func (c *LRUKCache) evictBefore(a, b uint32) bool {
+	aFull := c.entries[a].refs >= uint64(c.k)
+	bFull := c.entries[b].refs >= uint64(c.k)
+	if aFull != bFull {
+		// Entries without a full history go first.
+		return !aFull
+	}
+	return c.rankedUse(a) < c.rankedUse(b)
+}
+
+// rankedUse returns the use of elem that it is ranked by, which is its k-th
+// most recent, or its most recent if it has not been used k times.
+func (c *LRUKCache) rankedUse(elem uint32) uint64 {
+	refs := c.entries[elem].refs
+	back := uint64(c.k)
+	if refs < back {
+		back = 1
+	}
+	return c.history[int(elem)*c.k+int((refs-back)%uint64(c.k))]
+}
+
+// lrukHeap implements heap.Interface over the entries of an LRUKCache.
+type lrukHeap struct {
+	cache *LRUKCache
+	elems []uint32
+}
+
+func (h *lrukHeap) Len() int { return len(h.elems) }
+
+func (h *lrukHeap) Less(i, j int) bool {
+	return h.cache.evictBefore(h.elems[i], h.elems[j])
+}
+
+func (h *lrukHeap) Swap(i, j int) {
+	h.elems[i], h.elems[j] = h.elems[j], h.elems[i]
+	h.cache.entries[h.elems[i]].heapIndex = i
+	h.cache.entries[h.elems[j]].heapIndex = j
+}
+
+func (h *lrukHeap) Push(x interface{}) {
+	elem := x.(uint32)
+	h.cache.entries[elem].heapIndex = len(h.elems)
+	h.elems = append(h.elems, elem)
+}
+
+func (h *lrukHeap) Pop() interface{} {
+	elem := h.elems[len(h.elems)-1]
+	h.elems = h.elems[:len(h.elems)-1]
+	return elem
+}
@@ -0,0 +1,111 @@
+// Copyright 2026 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package lru_test
+
+import (
+	"time"
+
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/lru"
+)
+
+type LRUKSuite struct{}
+
+var _ = gc.Suite(&LRUKSuite{})
+
+func checkLRUKKeys(c *gc.C, cache *lru.LRUKCache, present []interface{}, missing []interface{}) {
+	for _, key := range present {
+		_, ok := cache.Peek(key)
+		c.Check(ok, gc.Equals, true, gc.Commentf("missing %v", key))
+	}
+	for _, key := range missing {
+		_, ok := cache.Peek(key)
+		c.Check(ok, gc.Equals, false, gc.Commentf("unexpected %v", key))
+	}
+}
+
+func (*LRUKSuite) TestAddGet(c *gc.C) {
+	cache := lru.NewLRUK(10, 2)
+	cache.Add("a", 1)
+	value, ok := cache.Get("a")
+	c.Check(ok, gc.Equals, true)
+	c.Check(value, gc.Equals, 1)
+	cache.Add("a", 2)
+	value, _ = cache.Peek("a")
+	c.Check(value, gc.Equals, 2)
+	_, ok = cache.Get("b")
+	c.Check(ok, gc.Equals, false)
+	c.Check(cache.Len(), gc.Equals, 1)
+}
+
+func (*LRUKSuite) TestMaxSize(c *gc.C) {
+	cache := lru.NewLRUK(10, 2)
+	for i := 0; i < 100; i++ {
+		cache.Add(i, i)
+		if i%3 == 0 {
+			cache.Get(i)
+		}
+		c.Assert(cache.Len() <= 10, gc.Equals, true)
+	}
+	c.Check(cache.Len(), gc.Equals, 10)
+}
+
+func (*LRUKSuite) TestEvictsByKthUse(c *gc.C) {
+	cache := lru.NewLRUK(3, 2)
+	cache.Add("a", 1)
+	cache.Add("b", 2)
+	cache.Get("a")
+	cache.Add("c", 3)
+	cache.Get("b")
+	cache.Get("c")
+	cache.Get("a")
+	// The uses are now a=3,7 b=2,5 c=4,6. Although "b" was used more
+	// recently than "a", its second to last use is the oldest, so it makes
+	// way for "d".
+	cache.Add("d", 4)
+	// "d" has only been used once, so it is the next to go.
+	cache.Add("e", 5)
+	checkLRUKKeys(c, cache, []interface{}{"a", "c", "e"}, []interface{}{"b", "d"})
+}
+
+func (*LRUKSuite) TestScanResistance(c *gc.C) {
+	cache := lru.NewLRUK(100, 2)
+	for i := 0; i < 50; i++ {
+		cache.Add(i, i)
+		cache.Get(i)
+	}
+	for i := 1000; i < 2000; i++ {
+		cache.Add(i, i)
+	}
+	for i := 0; i < 50; i++ {
+		_, ok := cache.Peek(i)
+		c.Check(ok, gc.Equals, true, gc.Commentf("lost %d", i))
+	}
+	c.Check(cache.Len(), gc.Equals, 100)
+}
+
+func (*LRUKSuite) TestCorrelatedPeriod(c *gc.C) {
+	clock := newTestClock()
+	cache := lru.NewLRUK(2, 2, lru.WithCorrelatedPeriod(time.Second), lru.WithLRUKClock(clock))
+	cache.Add("a", 1)
+	clock.Advance(time.Minute)
+	cache.Add("b", 2)
+	// Reading "b" straight after writing it is a single use.
+	cache.Get("b")
+	clock.Advance(time.Minute)
+	cache.Get("a")
+	// So "b" only has one use, and goes first.
+	cache.Add("c", 3)
+	checkLRUKKeys(c, cache, []interface{}{"a", "c"}, []interface{}{"b"})
+
+	// Without the correlated period, "b" would have had two uses.
+	cache = lru.NewLRUK(2, 2)
+	cache.Add("a", 1)
+	cache.Add("b", 2)
+	cache.Get("b")
+	cache.Get("a")
+	cache.Add("c", 3)
+	checkLRUKKeys(c, cache, []interface{}{"b", "c"}, []interface{}{"a"})
+}
@@ -0,0 +1,20 @@
+// Copyright 2026 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package lru
+
+// LRUString is a Cache keyed by strings. Like StringCache, it specializes its
+// storage for strings: the map is a map[string]uint32 rather than being keyed
+// by interface{}, so Get neither boxes the key nor needs a type assertion on
+// the result.
+type LRUString[V any] struct {
+	Cache[string, V]
+}
+
+// NewLRUString creates a new LRUString that will hold no more than the given
+// number of items.
+func NewLRUString[V any](size int) *LRUString[V] {
+	c := &LRUString[V]{}
+	c.init(size)
+	return c
+}
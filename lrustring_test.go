@@ -0,0 +1,56 @@
+// Copyright 2026 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package lru_test
+
+import (
+	"fmt"
+
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/lru"
+)
+
+type LRUStringSuite struct{}
+
+var _ = gc.Suite(&LRUStringSuite{})
+
+func (*LRUStringSuite) TestAddGetEvict(c *gc.C) {
+	cache := lru.NewLRUString[int](3)
+	cache.Add("a", 1)
+	cache.Add("b", 2)
+	cache.Add("c", 3)
+	v, ok := cache.Get("a")
+	c.Check(ok, gc.Equals, true)
+	c.Check(v, gc.Equals, 1)
+	cache.Add("d", 4)
+	_, ok = cache.Peek("b")
+	c.Check(ok, gc.Equals, false)
+	c.Check(cache.Len(), gc.Equals, 3)
+}
+
+func (*BenchmarkLRUSuite) BenchmarkGetStrLRU(c *gc.C) {
+	cache := lru.New(1000)
+	keys := make([]string, 1000)
+	for i := range keys {
+		keys[i] = fmt.Sprint(i + 1e7)
+		cache.Add(keys[i], i)
+	}
+	c.ResetTimer()
+	for i := 0; i < c.N; i++ {
+		cache.Get(keys[i%1000])
+	}
+}
+
+func (*BenchmarkLRUSuite) BenchmarkGetStrLRUString(c *gc.C) {
+	cache := lru.NewLRUString[int](1000)
+	keys := make([]string, 1000)
+	for i := range keys {
+		keys[i] = fmt.Sprint(i + 1e7)
+		cache.Add(keys[i], i)
+	}
+	c.ResetTimer()
+	for i := 0; i < c.N; i++ {
+		cache.Get(keys[i%1000])
+	}
+}
@@ -0,0 +1,110 @@
+// Copyright 2026 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package lru
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// binaryTypes holds the types registered with RegisterBinary, by name and by
+// type.
+var binaryTypes = struct {
+	sync.RWMutex
+	byName map[string]reflect.Type
+	byType map[reflect.Type]string
+}{
+	byName: make(map[string]reflect.Type),
+	byType: make(map[reflect.Type]string),
+}
+
+// RegisterBinary records the type of value, which must implement
+// encoding.BinaryMarshaler, and whose pointer must implement
+// encoding.BinaryUnmarshaler (or which must itself, if it is a pointer), so
+// that WriteTo writes values of that type with MarshalBinary, and ReadFrom
+// reads them back with UnmarshalBinary, rather than using encoding/gob. The
+// type is identified in the stream by its package path and name, so it must
+// be a named type, or a pointer to one. Like gob.Register, it is meant to be
+// called when a program starts, and panics if the type can't be used.
+func RegisterBinary(value encoding.BinaryMarshaler) {
+	t := reflect.TypeOf(value)
+	if _, ok := reflect.New(t).Interface().(encoding.BinaryUnmarshaler); !ok {
+		if t.Kind() != reflect.Ptr {
+			panic(fmt.Sprintf("lru: RegisterBinary: *%v does not implement encoding.BinaryUnmarshaler", t))
+		}
+		if _, ok := value.(encoding.BinaryUnmarshaler); !ok {
+			panic(fmt.Sprintf("lru: RegisterBinary: %v does not implement encoding.BinaryUnmarshaler", t))
+		}
+	}
+	name := binaryTypeName(t)
+	if name == "" {
+		panic(fmt.Sprintf("lru: RegisterBinary: %v is not a named type", t))
+	}
+	binaryTypes.Lock()
+	defer binaryTypes.Unlock()
+	binaryTypes.byName[name] = t
+	binaryTypes.byType[t] = name
+}
+
+// binaryTypeName returns the name a type is registered with, or "" if it
+// can't be registered.
+func binaryTypeName(t reflect.Type) string {
+	prefix := ""
+	if t.Kind() == reflect.Ptr {
+		prefix, t = "*", t.Elem()
+	}
+	if t.Name() == "" {
+		return ""
+	}
+	return prefix + t.PkgPath() + "." + t.Name()
+}
+
+// appendBinary appends v to buf with its MarshalBinary method, and returns
+// whether it did, which it does if its type was registered with
+// RegisterBinary.
+func appendBinary(buf []byte, v interface{}) ([]byte, bool, error) {
+	marshaler, ok := v.(encoding.BinaryMarshaler)
+	if !ok {
+		return buf, false, nil
+	}
+	binaryTypes.RLock()
+	name, ok := binaryTypes.byType[reflect.TypeOf(v)]
+	binaryTypes.RUnlock()
+	if !ok {
+		return buf, false, nil
+	}
+	data, err := marshaler.MarshalBinary()
+	if err != nil {
+		return nil, false, err
+	}
+	buf = appendUvarint(append(buf, tagBinary), uint64(len(name)))
+	buf = append(buf, name...)
+	buf = appendUvarint(buf, uint64(len(data)))
+	return append(buf, data...), true, nil
+}
+
+// unmarshalBinary returns a new value of the type registered with name, read
+// from data with its UnmarshalBinary method.
+func unmarshalBinary(name string, data []byte) (interface{}, error) {
+	binaryTypes.RLock()
+	t, ok := binaryTypes.byName[name]
+	binaryTypes.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("lru: type %s not registered with RegisterBinary", name)
+	}
+	if t.Kind() == reflect.Ptr {
+		v := reflect.New(t.Elem())
+		if err := v.Interface().(encoding.BinaryUnmarshaler).UnmarshalBinary(data); err != nil {
+			return nil, err
+		}
+		return v.Interface(), nil
+	}
+	v := reflect.New(t)
+	if err := v.Interface().(encoding.BinaryUnmarshaler).UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+	return v.Elem().Interface(), nil
+}
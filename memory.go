@@ -0,0 +1,313 @@
+// Copyright 2026 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package lru
+
+import (
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemoryConfig configures a MemoryController.
+type MemoryConfig struct {
+	// Limit is how much memory, in bytes, the process should stay within.
+	// If it is 0, the limit is read from the GOMEMLIMIT environment
+	// variable.
+	Limit int64
+
+	// High is the share of Limit above which the cache is shrunk, and Low
+	// is the share below which it is allowed to grow again. They default
+	// to 0.9 and 0.7.
+	High, Low float64
+
+	// MinSize is the fewest entries the cache is shrunk to, which defaults
+	// to 1. MaxSize is the most entries it can grow back to, which defaults
+	// to the size it was created with.
+	MinSize, MaxSize int
+
+	// Interval is how often memory use is checked, which defaults to a
+	// second.
+	Interval time.Duration
+
+	// Usage returns how much memory the process is using, in bytes. It
+	// defaults to the memory obtained from the OS by the Go runtime, less
+	// what it has returned, as given by runtime.ReadMemStats, which is
+	// close to what GOMEMLIMIT is compared against.
+	Usage func() int64
+}
+
+// MemoryController changes how many entries a SyncLRU can hold, depending on
+// how much memory the process is using. When memory use goes over the high
+// mark, the cache is halved in size, evicting the least recently used
+// entries, down to MinSize. When it drops below the low mark, the cache is
+// allowed to grow by a quarter at a time, up to MaxSize; it fills up again as
+// entries are added.
+type MemoryController struct {
+	cache  *SyncLRU
+	config MemoryConfig
+
+	// mu guards size.
+	mu   sync.Mutex
+	size int
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewMemoryController starts a goroutine that checks memory use every
+// interval, and resizes cache as needed. Close must be called to stop it.
+func NewMemoryController(cache *SyncLRU, config MemoryConfig) *MemoryController {
+	if config.Limit == 0 {
+		config.Limit = memLimitFromEnv()
+	}
+	if config.Limit <= 0 {
+		panic("MemoryController needs a Limit, or GOMEMLIMIT to be set")
+	}
+	if config.High == 0 {
+		config.High = 0.9
+	}
+	if config.Low == 0 {
+		config.Low = 0.7
+	}
+	if config.Low > config.High {
+		panic("Low must not be more than High")
+	}
+	cache.mu.RLock()
+	size := cache.lru.maxSize
+	cache.mu.RUnlock()
+	if config.MaxSize == 0 {
+		config.MaxSize = size
+	}
+	if config.MinSize == 0 {
+		config.MinSize = 1
+	}
+	if config.MinSize < 0 || config.MinSize > config.MaxSize || config.MaxSize > maxLRUSize {
+		panic("MinSize must be > 0 and no more than MaxSize")
+	}
+	if config.Interval == 0 {
+		config.Interval = time.Second
+	}
+	if config.Usage == nil {
+		config.Usage = memoryInUse
+	}
+	c := &MemoryController{
+		cache:  cache,
+		config: config,
+		size:   size,
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	go c.loop()
+	return c
+}
+
+func (c *MemoryController) loop() {
+	defer close(c.done)
+	ticker := time.NewTicker(c.config.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			c.Check()
+		case <-c.stop:
+			return
+		}
+	}
+}
+
+// Check looks at memory use straight away, rather than waiting for the next
+// interval, and resizes the cache if needed. It returns how many entries the
+// cache can now hold.
+func (c *MemoryController) Check() int {
+	used := float64(c.config.Usage())
+	limit := float64(c.config.Limit)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	size := c.size
+	switch {
+	case used > c.config.High*limit:
+		size /= 2
+	case used < c.config.Low*limit:
+		size += size/4 + 1
+	}
+	if size < c.config.MinSize {
+		size = c.config.MinSize
+	}
+	if size > c.config.MaxSize {
+		size = c.config.MaxSize
+	}
+	if size != c.size {
+		c.size = size
+		c.cache.setMaxSize(size)
+	}
+	return size
+}
+
+// Size returns how many entries the cache can currently hold.
+func (c *MemoryController) Size() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.size
+}
+
+// Close stops the controller, and waits for its goroutine to finish. The
+// cache keeps the size it had.
+func (c *MemoryController) Close() {
+	select {
+	case <-c.stop:
+	default:
+		close(c.stop)
+	}
+	<-c.done
+}
+
+// memoryInUse returns the memory the Go runtime has obtained from the OS and
+// not returned.
+func memoryInUse() int64 {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	return int64(stats.Sys - stats.HeapReleased)
+}
+
+// memLimitFromEnv parses GOMEMLIMIT, returning 0 if it is not set, or is
+// "off".
+func memLimitFromEnv() int64 {
+	value := os.Getenv("GOMEMLIMIT")
+	if value == "" || value == "off" {
+		return 0
+	}
+	units := []struct {
+		suffix string
+		scale  int64
+	}{
+		{"TiB", 1 << 40},
+		{"GiB", 1 << 30},
+		{"MiB", 1 << 20},
+		{"KiB", 1 << 10},
+		{"B", 1},
+	}
+	scale := int64(1)
+	for _, unit := range units {
+		if strings.HasSuffix(value, unit.suffix) {
+			value = strings.TrimSuffix(value, unit.suffix)
+			scale = unit.scale
+			break
+		}
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || n < 0 {
+		return 0
+	}
+	return n * scale
+}
+
+// GCConfig configures a GCTrimmer.
+type GCConfig struct {
+	// Fraction is the share of the entries in the cache that are evicted
+	// each time, between 0 and 1.
+	Fraction float64
+
+	// MinInterval is how soon one garbage collection has to follow another
+	// for the cache to be trimmed, so that it is only trimmed when the
+	// garbage collector is busy. If it is 0, the cache is trimmed after
+	// every garbage collection.
+	MinInterval time.Duration
+}
+
+// GCTrimmer evicts the least recently used entries from a SyncLRU after a
+// garbage collection, so that the cache gives up memory when the rest of the
+// process needs it. It finds out about garbage collections with a finalizer
+// on an object that it drops each time, so it only hears about some of them,
+// a little while after they happen.
+type GCTrimmer struct {
+	cache  *SyncLRU
+	config GCConfig
+
+	collected chan time.Time
+	stop      chan struct{}
+	done      chan struct{}
+}
+
+// gcSentinel is garbage that tells a GCTrimmer when it has been collected.
+type gcSentinel struct {
+	trimmer *GCTrimmer
+	// The finalizer may not run for zero sized objects.
+	_ byte
+}
+
+// NewGCTrimmer starts trimming cache after garbage collections. Close must be
+// called to stop it.
+func NewGCTrimmer(cache *SyncLRU, config GCConfig) *GCTrimmer {
+	if config.Fraction <= 0 || config.Fraction > 1 {
+		panic("Fraction must be > 0 and <= 1")
+	}
+	t := &GCTrimmer{
+		cache:     cache,
+		config:    config,
+		collected: make(chan time.Time, 1),
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	t.arm()
+	go t.loop()
+	return t
+}
+
+// arm drops a new sentinel for the next garbage collection to find.
+func (t *GCTrimmer) arm() {
+	runtime.SetFinalizer(&gcSentinel{trimmer: t}, (*gcSentinel).collected)
+}
+
+// collected runs on the finalizer goroutine, so it must not block.
+func (s *gcSentinel) collected() {
+	t := s.trimmer
+	select {
+	case <-t.stop:
+		return
+	default:
+	}
+	select {
+	case t.collected <- time.Now():
+	default:
+	}
+	t.arm()
+}
+
+func (t *GCTrimmer) loop() {
+	defer close(t.done)
+	var last time.Time
+	for {
+		select {
+		case now := <-t.collected:
+			if t.config.MinInterval == 0 || (!last.IsZero() && now.Sub(last) < t.config.MinInterval) {
+				t.Trim()
+			}
+			last = now
+		case <-t.stop:
+			return
+		}
+	}
+}
+
+// Trim evicts the share of entries given by Fraction straight away, and
+// returns how many were evicted.
+func (t *GCTrimmer) Trim() int {
+	t.cache.lock()
+	defer t.cache.mu.Unlock()
+	size := t.cache.lru.Len()
+	return t.cache.lru.TrimTo(size - int(float64(size)*t.config.Fraction))
+}
+
+// Close stops trimming the cache, and waits for its goroutine to finish.
+func (t *GCTrimmer) Close() {
+	select {
+	case <-t.stop:
+	default:
+		close(t.stop)
+	}
+	<-t.done
+}
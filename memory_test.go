@@ -0,0 +1,156 @@
+// Copyright 2026 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package lru_test
+
+import (
+	"os"
+	"runtime"
+	"time"
+
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/lru"
+)
+
+type MemorySuite struct{}
+
+var _ = gc.Suite(&MemorySuite{})
+
+func (*MemorySuite) TestShrinksAndGrows(c *gc.C) {
+	cache := lru.NewSync(100)
+	for i := 0; i < 100; i++ {
+		cache.Add(i, i)
+	}
+	used := int64(0)
+	controller := lru.NewMemoryController(cache, lru.MemoryConfig{
+		Limit:    1000,
+		MinSize:  20,
+		Interval: time.Hour,
+		Usage:    func() int64 { return used },
+	})
+	defer controller.Close()
+
+	// In between the marks, nothing changes.
+	used = 800
+	c.Check(controller.Check(), gc.Equals, 100)
+
+	used = 950
+	c.Check(controller.Check(), gc.Equals, 50)
+	c.Check(cache.Len(), gc.Equals, 50)
+	_, ok := cache.Peek(49)
+	c.Check(ok, gc.Equals, false)
+	_, ok = cache.Peek(50)
+	c.Check(ok, gc.Equals, true)
+	c.Check(controller.Check(), gc.Equals, 25)
+	c.Check(controller.Check(), gc.Equals, 20)
+	c.Check(controller.Check(), gc.Equals, 20)
+	c.Check(cache.Len(), gc.Equals, 20)
+
+	for i := 100; i < 200; i++ {
+		cache.Add(i, i)
+	}
+	c.Check(cache.Len(), gc.Equals, 20)
+
+	// It grows back a bit at a time, but doesn't refill itself.
+	used = 500
+	c.Check(controller.Check(), gc.Equals, 26)
+	c.Check(cache.Len(), gc.Equals, 20)
+	for i := 0; i < 10; i++ {
+		controller.Check()
+	}
+	c.Check(controller.Size(), gc.Equals, 100)
+	for i := 200; i < 400; i++ {
+		cache.Add(i, i)
+	}
+	c.Check(cache.Len(), gc.Equals, 100)
+}
+
+func (*MemorySuite) TestBoundsUnboundedCache(c *gc.C) {
+	cache := lru.NewSync(0)
+	for i := 0; i < 100; i++ {
+		cache.Add(i, i)
+	}
+	c.Check(cache.Stats().Cap, gc.Equals, 0)
+	controller := lru.NewMemoryController(cache, lru.MemoryConfig{
+		Limit:    1000,
+		Interval: time.Hour,
+		Usage:    func() int64 { return 950 },
+	})
+	defer controller.Close()
+	size := controller.Check()
+	c.Check(cache.Stats().Cap, gc.Equals, size)
+}
+
+func (*MemorySuite) TestChecksEveryInterval(c *gc.C) {
+	cache := lru.NewSync(100)
+	controller := lru.NewMemoryController(cache, lru.MemoryConfig{
+		Limit:    1000,
+		Interval: time.Millisecond,
+		Usage:    func() int64 { return 1000 },
+	})
+	defer controller.Close()
+	for i := 0; i < 1000 && controller.Size() > 1; i++ {
+		time.Sleep(time.Millisecond)
+	}
+	c.Check(controller.Size(), gc.Equals, 1)
+}
+
+func (*MemorySuite) TestNeedsLimit(c *gc.C) {
+	c.Check(func() {
+		lru.NewMemoryController(lru.NewSync(10), lru.MemoryConfig{
+			Limit: -1,
+		})
+	}, gc.PanicMatches, "MemoryController needs a Limit, or GOMEMLIMIT to be set")
+}
+
+func (*MemorySuite) TestLimitFromEnv(c *gc.C) {
+	old, set := os.LookupEnv("GOMEMLIMIT")
+	os.Setenv("GOMEMLIMIT", "1KiB")
+	defer func() {
+		if set {
+			os.Setenv("GOMEMLIMIT", old)
+		} else {
+			os.Unsetenv("GOMEMLIMIT")
+		}
+	}()
+	cache := lru.NewSync(10)
+	controller := lru.NewMemoryController(cache, lru.MemoryConfig{
+		Interval: time.Hour,
+		Usage:    func() int64 { return 1000 },
+	})
+	defer controller.Close()
+	c.Check(controller.Check(), gc.Equals, 5)
+}
+
+func (*MemorySuite) TestGCTrimmer(c *gc.C) {
+	cache := lru.NewSync(100)
+	for i := 0; i < 100; i++ {
+		cache.Add(i, i)
+	}
+	trimmer := lru.NewGCTrimmer(cache, lru.GCConfig{Fraction: 0.5})
+	defer trimmer.Close()
+	for i := 0; i < 100 && cache.Len() == 100; i++ {
+		runtime.GC()
+		time.Sleep(10 * time.Millisecond)
+	}
+	c.Assert(cache.Len() < 100, gc.Equals, true)
+	_, ok := cache.Peek(99)
+	c.Check(ok, gc.Equals, true)
+	_, ok = cache.Peek(0)
+	c.Check(ok, gc.Equals, false)
+}
+
+func (*MemorySuite) TestGCTrimmerTrim(c *gc.C) {
+	cache := lru.NewSync(100)
+	for i := 0; i < 10; i++ {
+		cache.Add(i, i)
+	}
+	trimmer := lru.NewGCTrimmer(cache, lru.GCConfig{
+		Fraction:    0.3,
+		MinInterval: time.Nanosecond,
+	})
+	defer trimmer.Close()
+	c.Check(trimmer.Trim(), gc.Equals, 3)
+	c.Check(cache.Len(), gc.Equals, 7)
+}
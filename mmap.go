@@ -0,0 +1,271 @@
+// Copyright 2026 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package lru
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// mmapMagic starts every file used by an MmapCache, followed by the version
+// of the layout.
+const mmapMagic = "JUJULRU\x01"
+
+const (
+	// mmapHeaderSize is the size of the header at the start of the file:
+	// the magic, then the number of slots, the largest key and value, and
+	// how many slots are in use, as little endian uint32s.
+	mmapHeaderSize = 24
+	// mmapSlotHeader is the size of the start of each slot: the prev and
+	// next links, and the lengths of the key and value, as uint32s.
+	mmapSlotHeader = 16
+)
+
+// ErrMmapTooLarge is returned by MmapCache.Add when the key or value is larger
+// than the cache was opened to hold.
+var ErrMmapTooLarge = errors.New("lru: key or value too large for MmapCache")
+
+// MmapCache is an LRU cache of byte slices, keyed by strings, whose entries
+// are kept in a memory mapped file rather than on the heap, so that they
+// outlive the process: a cache opened again from the same file, such as after
+// a restart, starts with the entries and recency order it had. Each entry
+// takes a fixed size slot, big enough for the largest key and value it was
+// opened with. Like LRU, the slots are kept in a flat buffer linked by offset,
+// with slot 0 as the root, and are always kept full from the start. The index
+// of keys is held in memory, and rebuilt when the file is opened.
+// A file can only be open in one MmapCache at a time, which is enforced with
+// an advisory lock, so processes can hand the cache on to each other, but not
+// use it at the same time. Writes go to the file as the operating system sees
+// fit; call Sync to flush them. If the file is found to be inconsistent when
+// it is opened, such as after a crash, the cache starts empty.
+// Note that MmapCache is *not* thread safe, some form of mutex is necessary if
+// you want to access it from multiple threads.
+type MmapCache struct {
+	file      *os.File
+	data      []byte
+	capacity  int
+	keySize   int
+	valueSize int
+	slotSize  int
+	index     map[string]uint32
+}
+
+// OpenMmapCache opens the cache in the file at path, creating it if needed,
+// to hold up to size entries, with keys up to keySize bytes long and values up
+// to valueSize bytes long. If the file already holds a cache with a different
+// size, keySize or valueSize, an error is returned. It is only supported on
+// Unix systems.
+func OpenMmapCache(path string, size, keySize, valueSize int) (*MmapCache, error) {
+	if size <= 0 || size >= maxLRUSize {
+		return nil, errors.New("lru: size must not be <= 0 or >= 2^32")
+	}
+	if keySize <= 0 || valueSize < 0 {
+		return nil, errors.New("lru: keySize must be > 0 and valueSize >= 0")
+	}
+	c := &MmapCache{
+		capacity:  size,
+		keySize:   keySize,
+		valueSize: valueSize,
+		slotSize:  mmapSlotHeader + keySize + valueSize,
+		index:     make(map[string]uint32),
+	}
+	length := mmapHeaderSize + (size+1)*c.slotSize
+	file, data, created, err := mapFile(path, length)
+	if err != nil {
+		return nil, err
+	}
+	c.file, c.data = file, data
+	if created {
+		c.reset()
+		return c, nil
+	}
+	if string(data[:len(mmapMagic)]) != mmapMagic {
+		c.Close()
+		return nil, fmt.Errorf("lru: %s is not an MmapCache file", path)
+	}
+	if c.header(8) != uint32(size) || c.header(12) != uint32(keySize) || c.header(16) != uint32(valueSize) {
+		c.Close()
+		return nil, fmt.Errorf("lru: %s holds a cache of a different size", path)
+	}
+	if !c.load() {
+		c.reset()
+	}
+	return c, nil
+}
+
+// Len returns the number of entries in the cache.
+func (c *MmapCache) Len() int {
+	return len(c.index)
+}
+
+// Add adds an entry to the cache, or replaces the value of an existing one,
+// evicting the least recently used entry if there is no room. It returns
+// ErrMmapTooLarge if the key or value don't fit in a slot.
+func (c *MmapCache) Add(key string, value []byte) error {
+	if len(key) > c.keySize || len(value) > c.valueSize {
+		return ErrMmapTooLarge
+	}
+	elem, ok := c.index[key]
+	if !ok {
+		size := len(c.index)
+		if size < c.capacity {
+			elem = uint32(size + 1)
+			c.setHeader(20, uint32(size+1))
+		} else {
+			elem = c.link(0, 0)
+			delete(c.index, string(c.key(elem)))
+			c.unlink(elem)
+		}
+		c.setLink(elem, 8, uint32(len(key)))
+		copy(c.slot(elem)[mmapSlotHeader:], key)
+		c.index[key] = elem
+	} else {
+		c.unlink(elem)
+	}
+	c.setLink(elem, 12, uint32(len(value)))
+	copy(c.slot(elem)[mmapSlotHeader+c.keySize:], value)
+	c.pushFront(elem)
+	return nil
+}
+
+// Get returns a copy of the value for key, and whether it is in the cache. If
+// it is, it is treated as recently used.
+func (c *MmapCache) Get(key string) ([]byte, bool) {
+	elem, ok := c.index[key]
+	if !ok {
+		return nil, false
+	}
+	c.unlink(elem)
+	c.pushFront(elem)
+	return append([]byte(nil), c.value(elem)...), true
+}
+
+// Peek is just like Get, except it doesn't count as a use.
+func (c *MmapCache) Peek(key string) ([]byte, bool) {
+	elem, ok := c.index[key]
+	if !ok {
+		return nil, false
+	}
+	return append([]byte(nil), c.value(elem)...), true
+}
+
+// Remove removes the entry for key, and returns whether there was one.
+func (c *MmapCache) Remove(key string) bool {
+	elem, ok := c.index[key]
+	if !ok {
+		return false
+	}
+	delete(c.index, key)
+	c.unlink(elem)
+	last := uint32(len(c.index) + 1)
+	if elem != last {
+		// Move the last slot into the hole, so the slots in use stay
+		// together at the start. See LRU.removeElem.
+		copy(c.slot(elem), c.slot(last))
+		c.setLink(c.link(elem, 0), 4, elem)
+		c.setLink(c.link(elem, 4), 0, elem)
+		c.index[string(c.key(elem))] = elem
+	}
+	c.setHeader(20, uint32(len(c.index)))
+	return true
+}
+
+// Sync flushes changes to the cache to the file.
+func (c *MmapCache) Sync() error {
+	return syncFile(c.data)
+}
+
+// Close unmaps and closes the file, after which the cache must not be used.
+func (c *MmapCache) Close() error {
+	err := unmapFile(c.file, c.data)
+	c.data = nil
+	return err
+}
+
+// reset makes the cache empty.
+func (c *MmapCache) reset() {
+	copy(c.data, mmapMagic)
+	c.setHeader(8, uint32(c.capacity))
+	c.setHeader(12, uint32(c.keySize))
+	c.setHeader(16, uint32(c.valueSize))
+	c.setHeader(20, 0)
+	c.setLink(0, 0, 0)
+	c.setLink(0, 4, 0)
+	c.index = make(map[string]uint32)
+}
+
+// load builds the index from the entries in the file, and returns whether
+// they were consistent.
+func (c *MmapCache) load() bool {
+	size := c.header(20)
+	if size > uint32(c.capacity) {
+		return false
+	}
+	prev := uint32(0)
+	for elem := c.link(0, 4); elem != 0; elem = c.link(elem, 4) {
+		if elem > size || c.link(elem, 0) != prev || len(c.index) >= int(size) {
+			return false
+		}
+		if c.link(elem, 8) > uint32(c.keySize) || c.link(elem, 12) > uint32(c.valueSize) {
+			return false
+		}
+		key := string(c.key(elem))
+		if _, ok := c.index[key]; ok {
+			return false
+		}
+		c.index[key] = elem
+		prev = elem
+	}
+	return len(c.index) == int(size) && c.link(0, 0) == prev
+}
+
+func (c *MmapCache) header(offset int) uint32 {
+	return binary.LittleEndian.Uint32(c.data[offset:])
+}
+
+func (c *MmapCache) setHeader(offset int, v uint32) {
+	binary.LittleEndian.PutUint32(c.data[offset:], v)
+}
+
+func (c *MmapCache) slot(elem uint32) []byte {
+	start := mmapHeaderSize + int(elem)*c.slotSize
+	return c.data[start : start+c.slotSize]
+}
+
+// link returns the uint32 at offset in the header of slot elem: 0 for prev, 4
+// for next, 8 for the key length and 12 for the value length.
+func (c *MmapCache) link(elem uint32, offset int) uint32 {
+	return binary.LittleEndian.Uint32(c.slot(elem)[offset:])
+}
+
+func (c *MmapCache) setLink(elem uint32, offset int, v uint32) {
+	binary.LittleEndian.PutUint32(c.slot(elem)[offset:], v)
+}
+
+func (c *MmapCache) key(elem uint32) []byte {
+	return c.slot(elem)[mmapSlotHeader : mmapSlotHeader+int(c.link(elem, 8))]
+}
+
+func (c *MmapCache) value(elem uint32) []byte {
+	start := mmapSlotHeader + c.keySize
+	return c.slot(elem)[start : start+int(c.link(elem, 12))]
+}
+
+// unlink takes elem out of the list.
+func (c *MmapCache) unlink(elem uint32) {
+	prev, next := c.link(elem, 0), c.link(elem, 4)
+	c.setLink(prev, 4, next)
+	c.setLink(next, 0, prev)
+}
+
+// pushFront links elem in as the most recently used.
+func (c *MmapCache) pushFront(elem uint32) {
+	next := c.link(0, 4)
+	c.setLink(elem, 0, 0)
+	c.setLink(elem, 4, next)
+	c.setLink(next, 0, elem)
+	c.setLink(0, 4, elem)
+}
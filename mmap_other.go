@@ -0,0 +1,25 @@
+// Copyright 2026 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+//go:build !(linux || darwin || dragonfly || freebsd || netbsd || openbsd)
+
+package lru
+
+import (
+	"errors"
+	"os"
+)
+
+var errMmapUnsupported = errors.New("lru: MmapCache is not supported on this platform")
+
+func mapFile(path string, length int) (*os.File, []byte, bool, error) {
+	return nil, nil, false, errMmapUnsupported
+}
+
+func syncFile(data []byte) error {
+	return errMmapUnsupported
+}
+
+func unmapFile(file *os.File, data []byte) error {
+	return errMmapUnsupported
+}
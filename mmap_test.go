@@ -0,0 +1,141 @@
+// Copyright 2026 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+//go:build linux || darwin || dragonfly || freebsd || netbsd || openbsd
+
+package lru_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/lru"
+)
+
+type MmapSuite struct {
+	path string
+}
+
+var _ = gc.Suite(&MmapSuite{})
+
+func (s *MmapSuite) SetUpTest(c *gc.C) {
+	s.path = filepath.Join(c.MkDir(), "cache")
+}
+
+func (s *MmapSuite) open(c *gc.C, size int) *lru.MmapCache {
+	cache, err := lru.OpenMmapCache(s.path, size, 8, 16)
+	c.Assert(err, gc.IsNil)
+	return cache
+}
+
+func checkMmapGet(c *gc.C, cache *lru.MmapCache, key, value string) {
+	got, ok := cache.Get(key)
+	c.Check(ok, gc.Equals, true, gc.Commentf("key %q", key))
+	c.Check(string(got), gc.Equals, value)
+}
+
+func checkMmapMissing(c *gc.C, cache *lru.MmapCache, key string) {
+	_, ok := cache.Peek(key)
+	c.Check(ok, gc.Equals, false, gc.Commentf("key %q", key))
+}
+
+func (s *MmapSuite) TestAddGet(c *gc.C) {
+	cache := s.open(c, 3)
+	defer cache.Close()
+	c.Assert(cache.Add("a", []byte("one")), gc.IsNil)
+	c.Assert(cache.Add("b", []byte("two")), gc.IsNil)
+	c.Assert(cache.Add("c", []byte("three")), gc.IsNil)
+	checkMmapGet(c, cache, "a", "one")
+	// b is the least recently used.
+	c.Assert(cache.Add("d", []byte("four")), gc.IsNil)
+	c.Check(cache.Len(), gc.Equals, 3)
+	checkMmapMissing(c, cache, "b")
+	checkMmapGet(c, cache, "c", "three")
+	c.Assert(cache.Add("a", []byte("ONE")), gc.IsNil)
+	checkMmapGet(c, cache, "a", "ONE")
+
+	c.Check(cache.Add("too long key", nil), gc.Equals, lru.ErrMmapTooLarge)
+	c.Check(cache.Add("a", make([]byte, 17)), gc.Equals, lru.ErrMmapTooLarge)
+}
+
+func (s *MmapSuite) TestRemove(c *gc.C) {
+	cache := s.open(c, 5)
+	defer cache.Close()
+	for i := 0; i < 5; i++ {
+		c.Assert(cache.Add(fmt.Sprint(i), []byte(fmt.Sprint("v", i))), gc.IsNil)
+	}
+	c.Check(cache.Remove("1"), gc.Equals, true)
+	c.Check(cache.Remove("1"), gc.Equals, false)
+	c.Check(cache.Len(), gc.Equals, 4)
+	checkMmapMissing(c, cache, "1")
+	for _, i := range []int{0, 2, 3, 4} {
+		checkMmapGet(c, cache, fmt.Sprint(i), fmt.Sprint("v", i))
+	}
+	c.Assert(cache.Add("5", []byte("v5")), gc.IsNil)
+	c.Assert(cache.Add("6", []byte("v6")), gc.IsNil)
+	// 0 was the least recently used.
+	checkMmapMissing(c, cache, "0")
+}
+
+func (s *MmapSuite) TestReopen(c *gc.C) {
+	cache := s.open(c, 3)
+	c.Assert(cache.Add("a", []byte("one")), gc.IsNil)
+	c.Assert(cache.Add("b", []byte("two")), gc.IsNil)
+	c.Assert(cache.Add("c", []byte("three")), gc.IsNil)
+	cache.Get("a")
+	c.Assert(cache.Remove("c"), gc.Equals, true)
+	c.Assert(cache.Sync(), gc.IsNil)
+	c.Assert(cache.Close(), gc.IsNil)
+
+	cache = s.open(c, 3)
+	defer cache.Close()
+	c.Check(cache.Len(), gc.Equals, 2)
+	checkMmapGet(c, cache, "a", "one")
+	c.Assert(cache.Add("d", []byte("four")), gc.IsNil)
+	c.Assert(cache.Add("e", []byte("five")), gc.IsNil)
+	// The recency order was kept, so b goes first.
+	checkMmapMissing(c, cache, "b")
+	checkMmapGet(c, cache, "a", "one")
+}
+
+func (s *MmapSuite) TestLocked(c *gc.C) {
+	cache := s.open(c, 3)
+	defer cache.Close()
+	_, err := lru.OpenMmapCache(s.path, 3, 8, 16)
+	c.Check(err, gc.ErrorMatches, ".* is in use: .*")
+}
+
+func (s *MmapSuite) TestDifferentSize(c *gc.C) {
+	c.Assert(s.open(c, 3).Close(), gc.IsNil)
+	_, err := lru.OpenMmapCache(s.path, 3, 8, 32)
+	c.Check(err, gc.ErrorMatches, ".* holds a cache of a different size")
+}
+
+func (s *MmapSuite) TestNotACache(c *gc.C) {
+	c.Assert(s.open(c, 3).Close(), gc.IsNil)
+	data, err := os.ReadFile(s.path)
+	c.Assert(err, gc.IsNil)
+	copy(data, "notmagic")
+	c.Assert(os.WriteFile(s.path, data, 0o600), gc.IsNil)
+	_, err = lru.OpenMmapCache(s.path, 3, 8, 16)
+	c.Check(err, gc.ErrorMatches, ".* is not an MmapCache file")
+}
+
+func (s *MmapSuite) TestCorruptStartsEmpty(c *gc.C) {
+	cache := s.open(c, 3)
+	c.Assert(cache.Add("a", []byte("one")), gc.IsNil)
+	c.Assert(cache.Close(), gc.IsNil)
+	data, err := os.ReadFile(s.path)
+	c.Assert(err, gc.IsNil)
+	// Claim more entries than are linked.
+	data[20] = 2
+	c.Assert(os.WriteFile(s.path, data, 0o600), gc.IsNil)
+	cache = s.open(c, 3)
+	defer cache.Close()
+	c.Check(cache.Len(), gc.Equals, 0)
+	c.Assert(cache.Add("b", []byte("two")), gc.IsNil)
+	checkMmapGet(c, cache, "b", "two")
+}
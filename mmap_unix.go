@@ -0,0 +1,71 @@
+// Copyright 2026 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+//go:build linux || darwin || dragonfly || freebsd || netbsd || openbsd
+
+package lru
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// mapFile opens the file at path, creating it if needed, takes an exclusive
+// lock on it, makes sure it is length bytes long, and maps it into memory. It
+// returns whether the file was created (or was empty).
+func mapFile(path string, length int) (*os.File, []byte, bool, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	if err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		file.Close()
+		return nil, nil, false, fmt.Errorf("lru: %s is in use: %w", path, err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, nil, false, err
+	}
+	created := info.Size() == 0
+	if !created && info.Size() != int64(length) {
+		file.Close()
+		return nil, nil, false, fmt.Errorf("lru: %s holds a cache of a different size", path)
+	}
+	if err := file.Truncate(int64(length)); err != nil {
+		file.Close()
+		return nil, nil, false, err
+	}
+	data, err := syscall.Mmap(int(file.Fd()), 0, length, syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_SHARED)
+	if err != nil {
+		file.Close()
+		return nil, nil, false, err
+	}
+	return file, data, created, nil
+}
+
+// syncFile flushes changes to the mapped data to its file.
+func syncFile(data []byte) error {
+	if len(data) == 0 {
+		return nil
+	}
+	_, _, errno := syscall.Syscall(syscall.SYS_MSYNC, uintptr(unsafe.Pointer(&data[0])), uintptr(len(data)), syscall.MS_SYNC)
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// unmapFile unmaps data, and closes file, which releases its lock.
+func unmapFile(file *os.File, data []byte) error {
+	var err error
+	if data != nil {
+		err = syscall.Munmap(data)
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
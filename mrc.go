@@ -0,0 +1,247 @@
+// Copyright 2026 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package lru
+
+import (
+	"hash/maphash"
+	"math"
+	"sync"
+)
+
+// MRCProfiler estimates the miss ratio curve of a stream of keys: the share
+// of lookups that would miss in an LRU cache of each size. It uses spatial
+// sampling (as in SHARDS): only keys whose hash falls in a fixed share of the
+// hash space are tracked, and the reuse distance of each, which is how many
+// other sampled keys were used since it was last used, is scaled up by the
+// same share. Sampling keeps the cost low enough to run on live traffic. It
+// is safe to use from multiple goroutines.
+type MRCProfiler struct {
+	mu        sync.Mutex
+	seed      maphash.Seed
+	rate      float64
+	threshold uint64
+	maxSize   int
+	// stackSize is how many sampled keys are kept. Keys whose scaled
+	// distance is more than maxSize would miss in every cache we report on,
+	// so they needn't be kept.
+	stackSize int
+	// Each sampled lookup is given a time, counting up from 0. last holds
+	// the time each kept key was last used, and keys holds the key used at
+	// each time since the oldest kept key was used. used is a Fenwick tree
+	// over the times, counting those that are still some key's last use, so
+	// that the reuse distance of a key can be found without walking
+	// through every key used since.
+	last   map[interface{}]int
+	keys   []interface{}
+	used   []int
+	oldest int
+	now    int
+	// distances counts the sampled lookups by their reuse distance, before
+	// it is scaled, and lookups counts all sampled lookups, including those
+	// of keys not in the stack.
+	distances []int64
+	lookups   int64
+}
+
+// MRCPoint is a point on a miss ratio curve.
+type MRCPoint struct {
+	// Size is the number of entries in the cache.
+	Size int `json:"size"`
+	// MissRatio is the share of lookups that would miss in a cache of
+	// Size entries, between 0 and 1.
+	MissRatio float64 `json:"miss_ratio"`
+}
+
+// NewMRCProfiler creates an MRCProfiler for caches of up to maxSize entries,
+// which tracks the given share of keys (between 0 and 1). The smaller the
+// share, the less memory and time it takes, and the less accurate it is; a
+// share of 0.01 is usually enough for large caches, as long as at least a few
+// hundred keys are sampled.
+func NewMRCProfiler(maxSize int, rate float64) *MRCProfiler {
+	if maxSize <= 0 || maxSize > maxLRUSize {
+		panic("maxSize must not be <= 0 or >= 2^32")
+	}
+	if rate <= 0 || rate > 1 {
+		panic("rate must be > 0 and <= 1")
+	}
+	stackSize := int(math.Ceil(float64(maxSize) * rate))
+	p := &MRCProfiler{
+		seed:      maphash.MakeSeed(),
+		rate:      rate,
+		threshold: math.MaxUint64,
+		maxSize:   maxSize,
+		stackSize: stackSize,
+		last:      make(map[interface{}]int),
+		distances: make([]int64, stackSize),
+	}
+	if rate < 1 {
+		p.threshold = uint64(rate * math.MaxUint64)
+	}
+	return p
+}
+
+// WithMRCProfiler passes the key of every call to Get to p, so that it can
+// estimate the miss ratio curve of the cache. Giving the same profiler to
+// every shard of a ShardedLRU profiles the cache as a whole.
+func WithMRCProfiler(p *MRCProfiler) Option {
+	return func(lru *LRU) {
+		lru.profiler = p
+	}
+}
+
+// Access records a lookup of key.
+func (p *MRCProfiler) Access(key interface{}) {
+	key = mapKey(key)
+	if hashKey(p.seed, key) > p.threshold {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.lookups++
+	if t, ok := p.last[key]; ok {
+		// The distance is how many kept keys were last used after t.
+		p.distances[len(p.last)-p.countUsed(t)]++
+		p.forget(t)
+	} else if len(p.last) == p.stackSize {
+		for !p.isLastUse(p.oldest) {
+			p.oldest++
+		}
+		p.forget(p.oldest)
+	}
+	if p.now == len(p.keys) {
+		p.compact()
+	}
+	p.last[key] = p.now
+	p.keys[p.now] = key
+	p.addUsed(p.now, 1)
+	p.now++
+}
+
+// isLastUse returns whether time t is the last use of the key used then.
+func (p *MRCProfiler) isLastUse(t int) bool {
+	last, ok := p.last[p.keys[t]]
+	return ok && last == t
+}
+
+// forget stops tracking the key last used at time t.
+func (p *MRCProfiler) forget(t int) {
+	delete(p.last, p.keys[t])
+	p.keys[t] = nil
+	p.addUsed(t, -1)
+}
+
+// addUsed adds delta to the count of last uses at time t.
+func (p *MRCProfiler) addUsed(t int, delta int) {
+	for i := t + 1; i < len(p.used); i += i & -i {
+		p.used[i] += delta
+	}
+}
+
+// countUsed returns how many of the times up to and including t are the last
+// use of a key.
+func (p *MRCProfiler) countUsed(t int) int {
+	n := 0
+	for i := t + 1; i > 0; i -= i & -i {
+		n += p.used[i]
+	}
+	return n
+}
+
+// compact renumbers the times of the kept keys from 0, keeping their order,
+// once every time has been given out. The times are grown first if more than
+// half of them are in use, so that compact runs no more often than once per
+// that many lookups.
+func (p *MRCProfiler) compact() {
+	keys := p.keys
+	if 2*len(p.last) >= len(keys) {
+		size := 2 * len(keys)
+		if size < 16 {
+			size = 16
+		}
+		keys = make([]interface{}, size)
+		p.used = make([]int, size+1)
+	} else {
+		for i := range p.used {
+			p.used[i] = 0
+		}
+	}
+	n := 0
+	for t := p.oldest; t < p.now; t++ {
+		if !p.isLastUse(t) {
+			continue
+		}
+		key := p.keys[t]
+		p.keys[t] = nil
+		keys[n] = key
+		p.last[key] = n
+		n++
+	}
+	p.keys = keys
+	// Every time before n is now a last use.
+	for i := 1; i < len(p.used); i++ {
+		if i <= n {
+			p.used[i]++
+		}
+		if j := i + i&-i; j < len(p.used) {
+			p.used[j] += p.used[i]
+		}
+	}
+	p.oldest, p.now = 0, n
+}
+
+// Curve returns the estimated miss ratio for caches of each of the given
+// sizes. If no sizes are given, it uses 10 sizes evenly spread up to the
+// maximum size the profiler was created with. Sizes over the maximum are
+// treated as the maximum.
+func (p *MRCProfiler) Curve(sizes ...int) []MRCPoint {
+	if len(sizes) == 0 {
+		for i := 1; i <= 10; i++ {
+			sizes = append(sizes, p.maxSize*i/10)
+		}
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	// hits[d] is how many lookups had a reuse distance of less than d.
+	hits := make([]int64, len(p.distances)+1)
+	for d, n := range p.distances {
+		hits[d+1] = hits[d] + n
+	}
+	curve := make([]MRCPoint, len(sizes))
+	for i, size := range sizes {
+		curve[i] = MRCPoint{Size: size, MissRatio: 1}
+		if p.lookups == 0 {
+			continue
+		}
+		d := int(math.Ceil(float64(size) * p.rate))
+		if d > len(p.distances) {
+			d = len(p.distances)
+		}
+		if d < 0 {
+			d = 0
+		}
+		curve[i].MissRatio = 1 - float64(hits[d])/float64(p.lookups)
+	}
+	return curve
+}
+
+// Reset forgets everything the profiler has seen.
+func (p *MRCProfiler) Reset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.last = make(map[interface{}]int)
+	p.keys, p.used = nil, nil
+	p.oldest, p.now = 0, 0
+	for i := range p.distances {
+		p.distances[i] = 0
+	}
+	p.lookups = 0
+}
+
+// profile passes key to the MRCProfiler, if there is one. It is safe under a
+// read lock, as the profiler has its own.
+func (lru *LRU) profile(key interface{}) {
+	if lru.profiler != nil {
+		lru.profiler.Access(lru.identity(key))
+	}
+}
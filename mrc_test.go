@@ -0,0 +1,87 @@
+// Copyright 2026 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package lru_test
+
+import (
+	"math/rand"
+
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/lru"
+)
+
+type MRCSuite struct{}
+
+var _ = gc.Suite(&MRCSuite{})
+
+func (*MRCSuite) TestCurve(c *gc.C) {
+	profiler := lru.NewMRCProfiler(8, 1)
+	cache := lru.New(2, lru.WithMRCProfiler(profiler))
+	for i := 0; i < 2; i++ {
+		for _, key := range []string{"a", "b", "c", "d"} {
+			cache.Get(key)
+		}
+	}
+	// Every key is used again after 3 others, so only a cache of 4 or more
+	// would find it.
+	c.Check(profiler.Curve(1, 3, 4, 100), gc.DeepEquals, []lru.MRCPoint{
+		{Size: 1, MissRatio: 1},
+		{Size: 3, MissRatio: 1},
+		{Size: 4, MissRatio: 0.5},
+		{Size: 100, MissRatio: 0.5},
+	})
+	c.Check(profiler.Curve(), gc.HasLen, 10)
+	profiler.Reset()
+	c.Check(profiler.Curve(4), gc.DeepEquals, []lru.MRCPoint{{Size: 4, MissRatio: 1}})
+}
+
+func (*MRCSuite) TestSampled(c *gc.C) {
+	profiler := lru.NewMRCProfiler(2000, 0.1)
+	for i := 0; i < 10; i++ {
+		for key := 0; key < 1000; key++ {
+			profiler.Access(key)
+		}
+	}
+	curve := profiler.Curve(500, 2000)
+	c.Check(curve[0].MissRatio, gc.Equals, 1.0)
+	// Only the first time round misses.
+	c.Check(curve[1].MissRatio > 0.05 && curve[1].MissRatio < 0.15, gc.Equals, true, gc.Commentf("%v", curve))
+}
+
+func (*MRCSuite) TestSyncLRU(c *gc.C) {
+	profiler := lru.NewMRCProfiler(10, 1)
+	cache := lru.NewSync(10, lru.WithMRCProfiler(profiler))
+	cache.Get("a")
+	cache.Get("a")
+	c.Check(profiler.Curve(1), gc.DeepEquals, []lru.MRCPoint{{Size: 1, MissRatio: 0.5}})
+}
+
+func (*MRCSuite) TestMatchesLRU(c *gc.C) {
+	// Enough lookups, of more keys than are kept, to keep dropping keys and
+	// renumbering the rest.
+	const maxSize = 50
+	profiler := lru.NewMRCProfiler(maxSize, 1)
+	caches := make([]*lru.LRU, maxSize)
+	for i := range caches {
+		caches[i] = lru.New(i + 1)
+	}
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 5000; i++ {
+		key := r.Intn(80)
+		profiler.Access(key)
+		for _, cache := range caches {
+			if _, ok := cache.Get(key); !ok {
+				cache.Add(key, nil)
+			}
+		}
+	}
+	sizes := make([]int, maxSize)
+	for i := range sizes {
+		sizes[i] = i + 1
+	}
+	for i, point := range profiler.Curve(sizes...) {
+		stats := caches[i].Stats()
+		c.Check(point.MissRatio, gc.Equals, 1-float64(stats.Hits)/float64(stats.Hits+stats.Misses), gc.Commentf("size %d", point.Size))
+	}
+}
@@ -0,0 +1,332 @@
+// Copyright 2026 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package lru
+
+import (
+	"time"
+)
+
+// Option configures optional behaviour of an LRU, see New.
+type Option func(*LRU)
+
+// WithNormalizer causes every key passed to the cache to first be passed
+// through normalize, so that for example keys can be lowercased, trimmed or
+// have aliases resolved in one place rather than at every call site. The
+// normalized key is the one stored in the cache.
+func WithNormalizer(normalize func(key interface{}) interface{}) Option {
+	return func(lru *LRU) {
+		lru.normalize = normalize
+	}
+}
+
+// WithExpiry sets a maximum age for every entry in the cache. Once an entry
+// was last written more than expiry ago, it is no longer returned by Get or
+// Peek, even if it has been used recently. Expired entries still take up
+// room (and count towards Len) until they are overwritten, evicted or looked
+// up with Get.
+func WithExpiry(expiry time.Duration) Option {
+	return func(lru *LRU) {
+		lru.expiry = expiry
+		lru.ensureMeta()
+	}
+}
+
+// WithIdleTimeout causes entries to expire once they have not been written or
+// returned by Get for the given duration, regardless of when they were first
+// added. Peek does not count as an access. As with WithExpiry, expired entries
+// still take up room until they are overwritten, evicted or looked up with
+// Get.
+func WithIdleTimeout(timeout time.Duration) Option {
+	return func(lru *LRU) {
+		lru.idleTimeout = timeout
+		lru.ensureMeta()
+	}
+}
+
+// WithStaleWhileRevalidate makes Get keep returning an entry for up to
+// maxStale after it has expired, so that a slow refresh doesn't hold up the
+// caller. The first time Get returns a stale value, revalidate is called with
+// the key and stale value, and should arrange for a fresh value to be written
+// to the cache, typically from a new goroutine. It is not called again for the
+// entry until it has been written. revalidate is called while any lock on the
+// cache is held, so it must not use the cache directly. Apart from Get,
+// expired entries are treated as missing as usual, and RemoveExpired keeps
+// them until maxStale has passed.
+func WithStaleWhileRevalidate(maxStale time.Duration, revalidate func(key, stale interface{})) Option {
+	return func(lru *LRU) {
+		lru.maxStale = maxStale
+		lru.revalidate = revalidate
+		lru.ensureMeta()
+	}
+}
+
+// WithEarlyExpiry makes Get treat entries as expired slightly before they
+// really do, at random, so that when many callers are using an entry, they
+// don't all miss (and recompute it) at the same moment. The chance of an
+// early miss grows as the entry gets closer to expiring, and with how long
+// its value takes to compute, as given to SetRecomputeTime. Entries without
+// a recompute time never miss early. beta scales how early misses can be, 1
+// is a good default. An early miss does not remove the entry, and only
+// affects the caller of Get that saw it.
+func WithEarlyExpiry(beta float64) Option {
+	return func(lru *LRU) {
+		lru.earlyBeta = beta
+		lru.ensureMeta()
+	}
+}
+
+// WithRandomEviction makes the cache evict an entry chosen at random when it is
+// full, rather than the least recently used one. Get no longer has to reorder
+// the entries, which makes it cheaper, for workloads where tracking recency
+// doesn't pay for itself. Methods that give entries in order of use (such as
+// Range and PeekMostRecentN) give them in the order they were added instead.
+func WithRandomEviction() Option {
+	return func(lru *LRU) {
+		lru.policy = policyRandom
+	}
+}
+
+// WithFIFO makes the cache evict entries in the order they were added, no
+// matter how they have been used since, which suits a bounded window of
+// recently seen keys, such as for deduplication. Neither Get nor updating an
+// existing key reorders the entries. As with WithRandomEviction, methods that
+// give entries in order of use give them in the order they were added
+// instead.
+func WithFIFO() Option {
+	return func(lru *LRU) {
+		lru.policy = policyFIFO
+	}
+}
+
+// WithMidpointInsertion makes the cache add new entries part way along the
+// list of entries in order of use, rather than at the front, so that the
+// oldRatio share of the cache that is least recently used (the old part) is
+// made up of new entries and entries that haven't been used for a while. An
+// entry only moves to the front when it is used again. A scan of keys that
+// are each only used once then only displaces the old part of the cache,
+// leaving the entries that have proven themselves alone. InnoDB uses an
+// oldRatio of 3/8. Methods that give entries in order of use give them in
+// the order they are in the list.
+func WithMidpointInsertion(oldRatio float64) Option {
+	if oldRatio < 0 || oldRatio > 1 {
+		panic("oldRatio must be between 0 and 1")
+	}
+	return func(lru *LRU) {
+		lru.policy = policyMidpoint
+		lru.oldRatio = oldRatio
+		lru.marks = make([]uint64, len(lru.buf)/64+1)
+	}
+}
+
+// WithSampledEviction makes the cache evict the least recently used of a
+// random sample of entries when it is full, as Redis does, rather than
+// keeping every entry in order of use. Get only records when the entry was
+// used, which is much cheaper than moving it in the list, at the cost of
+// sometimes evicting an entry that was used more recently than others. The
+// more entries are sampled, the closer it comes to LRU, Redis uses 5 by
+// default. As with WithRandomEviction, methods that give entries in order of
+// use give them in the order they were added instead.
+func WithSampledEviction(samples int) Option {
+	if samples <= 0 {
+		panic("samples must be > 0")
+	}
+	return func(lru *LRU) {
+		lru.policy = policySampled
+		lru.samples = samples
+		lru.stamps = make([]uint64, len(lru.buf))
+	}
+}
+
+// WithSIEVE makes the cache use the SIEVE eviction algorithm. Entries are kept
+// in the order they were added, and Get only marks an entry as visited rather
+// than moving it. When the cache is full, a hand moves from the oldest entry
+// towards the newest, clearing the marks of visited entries, and evicts the
+// first unvisited entry it comes to, carrying on from there the next time.
+// This is cheaper than LRU on a hit, and resists scans, as new entries that
+// are never used again are evicted quickly. As with WithRandomEviction,
+// methods that give entries in order of use give them in the order they were
+// added instead.
+func WithSIEVE() Option {
+	return func(lru *LRU) {
+		lru.policy = policySIEVE
+		lru.marks = make([]uint64, len(lru.buf)/64+1)
+	}
+}
+
+// WithTinyLFU adds a TinyLFU admission policy to the cache. It keeps a compact
+// sketch estimating how often each key has been added or returned by Get,
+// including keys that are no longer in the cache. When the cache is full, a
+// new key is only added if it has been used more often than the least
+// recently used entry that it would replace; otherwise the Add (or Update) is
+// dropped. This stops keys that are only seen once from pushing out more
+// popular ones, which greatly improves hit rates for skewed workloads. Note
+// that this means a value that was just added may not be in the cache.
+func WithTinyLFU() Option {
+	return func(lru *LRU) {
+		if lru.unbounded {
+			// It is never full, so nothing would be turned away.
+			return
+		}
+		lru.sketch = newFrequencySketch(lru.maxSize)
+	}
+}
+
+// WithDoorkeeper adds a doorkeeper to the cache: a small Bloom filter of keys
+// that have been seen recently. When the cache is full, a new key is only
+// added the second time it is added (or Updated) within a while, the first
+// time it is just remembered by the doorkeeper and the Add is dropped. Keys
+// that are only ever seen once therefore never push out other entries. The
+// doorkeeper is cleared after it has seen as many keys as the cache can hold.
+// It can be combined with WithTinyLFU, in which case a key has to get past
+// the doorkeeper first. Note that this means a value that was just added may
+// not be in the cache.
+func WithDoorkeeper() Option {
+	return func(lru *LRU) {
+		if lru.unbounded {
+			return
+		}
+		lru.doorkeeper = newDoorkeeper(lru.maxSize)
+	}
+}
+
+// WithNoEviction stops the cache from evicting entries to make room for new
+// ones, for when it is used as a bounded registry and dropping entries would
+// be wrong. Once the cache is full, new keys are not added until there is
+// room, which TryAdd reports; Add, Update and AddWithCost drop them silently.
+// Existing entries can still be updated. Note that growing the cost of an
+// entry in a cache created with NewWithMaxCost (or NewWithMaxBytes) can still
+// evict others.
+func WithNoEviction() Option {
+	return func(lru *LRU) {
+		lru.noEviction = true
+	}
+}
+
+// WithOnEvict sets a function to be called with each value that leaves the
+// cache, so that resources held by it can be released, along with the reason
+// it left: whether it was evicted to make room for another, expired, was
+// removed with RemoveIf or a similar method, was replaced by a new value for
+// the same key, or was removed by Purge. When a value is replaced, it is
+// called with the old value even if it is the same as the new one. It is
+// called after the value has been removed, but while the cache is in the
+// middle of an operation, so it must not use the cache; for a SyncLRU, it is
+// called with the lock held. If there is also an OnEvictBatch callback, it
+// is only called for values that leave the cache one at a time.
+func WithOnEvict(onEvict func(key, value interface{}, reason EvictionReason)) Option {
+	return func(lru *LRU) {
+		lru.onEvict = onEvict
+	}
+}
+
+// WithOnEvictBatch sets a function to be called with the entries that leave
+// the cache together, such as those evicted down to the low watermark (see
+// WithLowWatermark), or removed by Purge, TrimTo, RemoveIf or RemoveExpired,
+// so that they can be cleaned up in one go. It is called once the operation
+// has removed them all, with the reason they were removed, and the slice is
+// not used by the cache again. Entries that leave one at a time are passed to
+// the OnEvict callback, if there is one, and otherwise to this one, in a
+// batch of one. Like the OnEvict callback, it must not use the cache.
+func WithOnEvictBatch(onEvictBatch func(entries []Entry, reason EvictionReason)) Option {
+	return func(lru *LRU) {
+		lru.onEvictBatch = onEvictBatch
+	}
+}
+
+// WithOnAdd sets a function to be called when a new entry is added to the
+// cache, after any entry it displaced has been evicted. Like the OnEvict
+// callback, it must not use the cache.
+func WithOnAdd(onAdd func(key, value interface{})) Option {
+	return func(lru *LRU) {
+		lru.onAdd = onAdd
+	}
+}
+
+// WithOnUpdate sets a function to be called when the value of an existing
+// entry is replaced, with the old and new values, even if they are the same.
+// Like the OnEvict callback, it must not use the cache.
+func WithOnUpdate(onUpdate func(key, old, new interface{})) Option {
+	return func(lru *LRU) {
+		lru.onUpdate = onUpdate
+	}
+}
+
+// WithDrainEvicted makes the cache keep the entries that leave it, for
+// whatever reason, until they are collected with DrainEvicted. Values that
+// are replaced by a new value for the same key are not kept. This lets
+// cleanup be done in batches, such as by a background goroutine for a
+// SyncLRU, rather than in an OnEvict callback with the lock held. The entries
+// are kept however many there are, so DrainEvicted must be called regularly.
+func WithDrainEvicted() Option {
+	return func(lru *LRU) {
+		lru.drained = true
+	}
+}
+
+// WithLowWatermark makes the cache evict entries in batches. Normally, once
+// the cache is full, each new entry evicts one other. Instead, when a new
+// entry is added to a full cache, which acts as the high watermark, entries
+// are evicted until there are no more than low left, and no more are evicted
+// until the cache is full again. This spreads the cost of eviction across
+// more inserts. low must be more than 0, and less than the size of the cache.
+// For a cache created with NewWithMaxCost, it applies to the number of
+// entries, not their cost.
+func WithLowWatermark(low int) Option {
+	if low <= 0 {
+		panic("low must be > 0")
+	}
+	return func(lru *LRU) {
+		if low >= lru.maxSize {
+			panic("low must be less than the size of the cache")
+		}
+		lru.lowWatermark = low
+	}
+}
+
+// WithSizeFunc sets the function used to work out the size in bytes of
+// values, for a cache created with NewWithMaxBytes. It is used for every
+// value, whether or not it implements Sizer.
+func WithSizeFunc(sizeOf func(value interface{}) int) Option {
+	return func(lru *LRU) {
+		lru.sizeOf = sizeOf
+	}
+}
+
+// WithAccessCounts records how many times Get has found each entry since it
+// was added, which AccessCount and AccessCounts report. For a SyncLRU, Gets
+// are counted when they are applied to the recency list, so some may be
+// missed under heavy contention.
+func WithAccessCounts() Option {
+	return func(lru *LRU) {
+		lru.accessCounts = make([]uint64, len(lru.buf))
+	}
+}
+
+// WithEvictionAges records how long entries evicted to make room for others
+// had been in the cache, and how long it had been since they were last used,
+// which EvictionAges reports. It also records access times, as
+// WithAccessTimes does.
+func WithEvictionAges() Option {
+	return func(lru *LRU) {
+		lru.ensureMeta()
+		lru.evictionAges = &EvictionAges{}
+	}
+}
+
+// WithAccessTimes records when each entry was last written or returned by
+// Get, which EvictOlderThan, AgeOf and LastAccessed need. WithExpiry and
+// WithIdleTimeout also record them.
+func WithAccessTimes() Option {
+	return func(lru *LRU) {
+		lru.ensureMeta()
+	}
+}
+
+// WithClock sets the clock used by time based features such as WithExpiry,
+// which defaults to WallClock. This is mostly useful for tests, which can
+// then control the passage of time rather than having to sleep.
+func WithClock(clock Clock) Option {
+	return func(lru *LRU) {
+		lru.clock = clock
+	}
+}
@@ -0,0 +1,595 @@
+// Copyright 2026 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package lru_test
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/lru"
+)
+
+type OptionsSuite struct{}
+
+var _ = gc.Suite(&OptionsSuite{})
+
+func lowerKey(key interface{}) interface{} {
+	if s, ok := key.(string); ok {
+		return strings.ToLower(strings.TrimSpace(s))
+	}
+	return key
+}
+
+func (*OptionsSuite) TestWithNormalizer(c *gc.C) {
+	cache := lru.New(10, lru.WithNormalizer(lowerKey))
+	cache.Add(" Foo", "bar")
+	checkPeekExists(c, cache, "foo", "bar")
+	checkPeekExists(c, cache, "FOO ", "bar")
+	checkGet(c, cache, "fOo", "bar", true)
+	cache.Add("FOO", "baz")
+	c.Check(cache.Len(), gc.Equals, 1)
+	checkPeekExists(c, cache, "foo", "baz")
+	// The normalized key is what is stored
+	c.Check(cache.PeekMostRecentN(1), gc.DeepEquals, []lru.Entry{{Key: "foo", Value: "baz"}})
+}
+
+func (*OptionsSuite) TestWithNormalizerUpdateAndRetain(c *gc.C) {
+	cache := lru.New(10, lru.WithNormalizer(lowerKey))
+	cache.Add("a", 1)
+	cache.Add("b", 2)
+	cache.Update("A", func(old interface{}, exists bool) (interface{}, bool) {
+		c.Check(exists, gc.Equals, true)
+		return old.(int) + 1, true
+	})
+	checkPeekExists(c, cache, "a", 2)
+	removed := cache.RetainOnly(map[interface{}]struct{}{"B": {}})
+	c.Check(removed, gc.Equals, 1)
+	checkPeekExists(c, cache, "b", 2)
+}
+
+func (*OptionsSuite) TestTinyLFUAdmission(c *gc.C) {
+	cache := lru.New(3, lru.WithTinyLFU())
+	for _, key := range []string{"a", "b", "c"} {
+		cache.Add(key, key)
+		cache.Get(key)
+	}
+	// "d" has been seen less than "a", the least recently used entry, so
+	// it isn't let in.
+	cache.Add("d", "d")
+	checkPeekMissing(c, cache, "d")
+	checkPeekExists(c, cache, "a", "a")
+	// Once it is seen more often, it is.
+	cache.Add("d", "d")
+	cache.Add("d", "d")
+	checkPeekExists(c, cache, "d", "d")
+	checkPeekMissing(c, cache, "a")
+	c.Check(cache.Len(), gc.Equals, 3)
+}
+
+func (*OptionsSuite) TestTinyLFUHitRate(c *gc.C) {
+	hitRate := func(cache *lru.LRU) float64 {
+		zipf := rand.NewZipf(rand.New(rand.NewSource(1)), 1.1, 1, 1000000)
+		hits := 0
+		for i := 0; i < 200000; i++ {
+			key := zipf.Uint64()
+			if _, ok := cache.Get(key); ok {
+				hits++
+			} else {
+				cache.Add(key, key)
+			}
+		}
+		return float64(hits) / 200000
+	}
+	plain := hitRate(lru.New(1000))
+	tinyLFU := hitRate(lru.New(1000, lru.WithTinyLFU()))
+	c.Logf("hit rate: LRU %.3f, TinyLFU %.3f", plain, tinyLFU)
+	c.Check(tinyLFU > plain, gc.Equals, true)
+}
+
+func (*OptionsSuite) TestDoorkeeper(c *gc.C) {
+	cache := lru.New(3, lru.WithDoorkeeper())
+	// While there is room, everything is let in.
+	for _, key := range []string{"a", "b", "c"} {
+		cache.Add(key, key)
+	}
+	c.Assert(cache.Len(), gc.Equals, 3)
+	// The first time "d" is seen, it is only remembered.
+	cache.Add("d", "d")
+	checkPeekMissing(c, cache, "d")
+	checkPeekExists(c, cache, "a", "a")
+	// The second time, it is let in.
+	cache.Add("d", "d")
+	checkPeekExists(c, cache, "d", "d")
+	checkPeekMissing(c, cache, "a")
+	// Updating an entry that is already in the cache is not affected.
+	cache.Add("b", "B")
+	checkPeekExists(c, cache, "b", "B")
+}
+
+func (*OptionsSuite) TestDoorkeeperForgets(c *gc.C) {
+	cache := lru.New(10, lru.WithDoorkeeper())
+	for i := 0; i < 10; i++ {
+		cache.Add(i, i)
+	}
+	cache.Add("x", "x")
+	// Once the doorkeeper has seen as many keys as the cache holds, it
+	// starts again.
+	for i := 100; i < 110; i++ {
+		cache.Add(i, i)
+	}
+	cache.Add("x", "x")
+	checkPeekMissing(c, cache, "x")
+	for i := 0; i < 10; i++ {
+		checkPeekExists(c, cache, i, i)
+	}
+}
+
+func (*OptionsSuite) TestDoorkeeperHitRate(c *gc.C) {
+	// A working set used over and over, mixed with keys only seen once.
+	hitRate := func(cache *lru.LRU) float64 {
+		r := rand.New(rand.NewSource(1))
+		hits := 0
+		for i := 0; i < 100000; i++ {
+			key := 1000000 + i
+			if r.Intn(2) == 0 {
+				key = r.Intn(800)
+			}
+			if _, ok := cache.Get(key); ok {
+				hits++
+			} else {
+				cache.Add(key, key)
+			}
+		}
+		return float64(hits) / 100000
+	}
+	plain := hitRate(lru.New(1000))
+	doorkeeper := hitRate(lru.New(1000, lru.WithDoorkeeper()))
+	c.Logf("hit rate: LRU %.3f, doorkeeper %.3f", plain, doorkeeper)
+	c.Check(doorkeeper > plain, gc.Equals, true)
+}
+
+func (*OptionsSuite) TestRandomEviction(c *gc.C) {
+	victims := make(map[interface{}]bool)
+	for i := 0; i < 200; i++ {
+		cache := lru.New(10, lru.WithRandomEviction())
+		for j := 0; j < 10; j++ {
+			cache.Add(j, j)
+		}
+		// Get does not change the order.
+		cache.Get(0)
+		c.Assert(collectKeys(cache.Range), gc.DeepEquals, []interface{}{9, 8, 7, 6, 5, 4, 3, 2, 1, 0})
+		cache.Add(10, 10)
+		c.Assert(cache.Len(), gc.Equals, 10)
+		checkPeekExists(c, cache, 10, 10)
+		for j := 0; j < 10; j++ {
+			if _, ok := cache.Peek(j); !ok {
+				victims[j] = true
+			}
+		}
+	}
+	// Any of them could have been evicted.
+	c.Check(len(victims) > 5, gc.Equals, true, gc.Commentf("%v", victims))
+}
+
+func (*OptionsSuite) TestRandomEvictionRemove(c *gc.C) {
+	cache := lru.New(100, lru.WithRandomEviction())
+	for i := 0; i < 1000; i++ {
+		cache.Add(i, i)
+		if i%7 == 0 {
+			cache.RemoveIf(func(key, _ interface{}) bool { return key.(int)%2 == 0 })
+		}
+		c.Assert(cache.Len() <= 100, gc.Equals, true)
+	}
+	c.Check(len(collectKeys(cache.Range)), gc.Equals, cache.Len())
+}
+
+func (*OptionsSuite) TestSIEVE(c *gc.C) {
+	cache := lru.New(4, lru.WithSIEVE())
+	for i := 0; i < 4; i++ {
+		cache.Add(i, i)
+	}
+	// Get marks the entries as visited, without moving them.
+	cache.Get(0)
+	cache.Get(2)
+	c.Assert(collectKeys(cache.Range), gc.DeepEquals, []interface{}{3, 2, 1, 0})
+	// The hand passes over 0, and evicts 1.
+	cache.Add(4, 4)
+	c.Assert(collectKeys(cache.Range), gc.DeepEquals, []interface{}{4, 3, 2, 0})
+	// It carries on from 2, which is passed over, and evicts 3.
+	cache.Add(5, 5)
+	c.Assert(collectKeys(cache.Range), gc.DeepEquals, []interface{}{5, 4, 2, 0})
+	// It carries on to 4, which hasn't been visited.
+	cache.Add(6, 6)
+	c.Assert(collectKeys(cache.Range), gc.DeepEquals, []interface{}{6, 5, 2, 0})
+	// New entries that aren't used follow them out, ahead of 2 and 0.
+	cache.Add(7, 7)
+	cache.Add(8, 8)
+	cache.Add(9, 9)
+	c.Assert(collectKeys(cache.Range), gc.DeepEquals, []interface{}{9, 8, 2, 0})
+	// Once the hand reaches the newest entry it wraps around to 0, whose mark
+	// was cleared the first time.
+	cache.Get(8)
+	cache.Get(9)
+	cache.Add(10, 10)
+	c.Assert(collectKeys(cache.Range), gc.DeepEquals, []interface{}{10, 9, 8, 2})
+}
+
+func (*OptionsSuite) TestSIEVEScanResistant(c *gc.C) {
+	cache := lru.New(100, lru.WithSIEVE())
+	for i := 0; i < 50; i++ {
+		cache.Add(i, i)
+		cache.Get(i)
+	}
+	for i := 1000; i < 2000; i++ {
+		cache.Add(i, i)
+		for j := 0; j < 50; j += 10 {
+			cache.Get(j)
+		}
+	}
+	for j := 0; j < 50; j += 10 {
+		checkPeekExists(c, cache, j, j)
+	}
+}
+
+func (*OptionsSuite) TestSIEVERemove(c *gc.C) {
+	cache := lru.New(100, lru.WithSIEVE())
+	for i := 0; i < 1000; i++ {
+		cache.Add(i, i)
+		checkPeekExists(c, cache, i, i)
+		if i%3 == 0 {
+			cache.Get(i - 50)
+		}
+		if i%7 == 0 {
+			cache.RemoveIf(func(key, _ interface{}) bool { return key.(int)%2 == 0 })
+		}
+		c.Assert(cache.Len() <= 100, gc.Equals, true)
+	}
+	c.Check(len(collectKeys(cache.Range)), gc.Equals, cache.Len())
+}
+
+func (*OptionsSuite) TestFIFO(c *gc.C) {
+	cache := lru.New(3, lru.WithFIFO())
+	cache.Add(0, 0)
+	cache.Add(1, 1)
+	cache.Add(2, 2)
+	cache.Get(0)
+	cache.Add(1, 10)
+	c.Assert(collectKeys(cache.Range), gc.DeepEquals, []interface{}{2, 1, 0})
+	cache.Add(3, 3)
+	c.Assert(collectKeys(cache.Range), gc.DeepEquals, []interface{}{3, 2, 1})
+	checkPeekExists(c, cache, 1, 10)
+	cache.Add(4, 4)
+	c.Assert(collectKeys(cache.Range), gc.DeepEquals, []interface{}{4, 3, 2})
+}
+
+func (*OptionsSuite) TestMidpointInsertion(c *gc.C) {
+	cache := lru.New(8, lru.WithMidpointInsertion(0.5))
+	for i := 0; i < 8; i++ {
+		cache.Add(i, i)
+	}
+	// Each new entry goes in at the start of the old half, pushing the
+	// one before it into the new half.
+	c.Assert(collectKeys(cache.Range), gc.DeepEquals, []interface{}{0, 2, 4, 6, 7, 5, 3, 1})
+	// Using an entry moves it to the front, and the next entry along moves
+	// into the old half to make up the numbers.
+	cache.Get(5)
+	c.Assert(collectKeys(cache.Range), gc.DeepEquals, []interface{}{5, 0, 2, 4, 6, 7, 3, 1})
+	// Now new entries go in after 6.
+	cache.Add(8, 8)
+	c.Assert(collectKeys(cache.Range), gc.DeepEquals, []interface{}{5, 0, 2, 4, 8, 6, 7, 3})
+	cache.Add(9, 9)
+	c.Assert(collectKeys(cache.Range), gc.DeepEquals, []interface{}{5, 0, 2, 4, 9, 8, 6, 7})
+}
+
+func (*OptionsSuite) TestMidpointInsertionScan(c *gc.C) {
+	cache := lru.New(100, lru.WithMidpointInsertion(0.375))
+	for i := 0; i < 50; i++ {
+		cache.Add(i, i)
+		cache.Get(i)
+	}
+	// The scan is interleaved with uses of the working set, but not so
+	// often that it would stay put in a plain LRU.
+	plain := lru.New(100)
+	for i := 0; i < 50; i++ {
+		plain.Add(i, i)
+	}
+	for i := 1000; i < 2000; i++ {
+		cache.Add(i, i)
+		plain.Add(i, i)
+		if i%2 == 0 {
+			cache.Get(i / 2 % 50)
+			plain.Get(i / 2 % 50)
+		}
+	}
+	inPlain := 0
+	for i := 0; i < 50; i++ {
+		checkPeekExists(c, cache, i, i)
+		if _, ok := plain.Peek(i); ok {
+			inPlain++
+		}
+	}
+	c.Check(inPlain < 50, gc.Equals, true)
+}
+
+func (*OptionsSuite) TestMidpointInsertionRemove(c *gc.C) {
+	cache := lru.New(100, lru.WithMidpointInsertion(0.25))
+	for i := 0; i < 1000; i++ {
+		cache.Add(i, i)
+		checkPeekExists(c, cache, i, i)
+		if i%3 == 0 {
+			cache.Get(i - 50)
+		}
+		if i%7 == 0 {
+			cache.RemoveIf(func(key, _ interface{}) bool { return key.(int)%2 == 0 })
+		}
+		c.Assert(cache.Len() <= 100, gc.Equals, true)
+	}
+	c.Check(len(collectKeys(cache.Range)), gc.Equals, cache.Len())
+}
+
+func (*OptionsSuite) TestSampledEviction(c *gc.C) {
+	// With many more samples than entries, the least recently used entry is
+	// all but certain to be picked.
+	cache := lru.New(10, lru.WithSampledEviction(500))
+	for i := 0; i < 10; i++ {
+		cache.Add(i, i)
+	}
+	cache.Get(0)
+	cache.Get(1)
+	// Get does not change the order.
+	c.Assert(collectKeys(cache.Range), gc.DeepEquals, []interface{}{9, 8, 7, 6, 5, 4, 3, 2, 1, 0})
+	cache.Add(10, 10)
+	checkPeekMissing(c, cache, 2)
+	cache.Add(11, 11)
+	checkPeekMissing(c, cache, 3)
+	checkPeekExists(c, cache, 0, 0)
+	checkPeekExists(c, cache, 1, 1)
+}
+
+func (*OptionsSuite) TestSampledEvictionRemove(c *gc.C) {
+	cache := lru.New(100, lru.WithSampledEviction(5))
+	for i := 0; i < 1000; i++ {
+		cache.Add(i, i)
+		checkPeekExists(c, cache, i, i)
+		if i%3 == 0 {
+			cache.Get(i - 50)
+		}
+		if i%7 == 0 {
+			cache.RemoveIf(func(key, _ interface{}) bool { return key.(int)%2 == 0 })
+		}
+		c.Assert(cache.Len() <= 100, gc.Equals, true)
+	}
+	c.Check(len(collectKeys(cache.Range)), gc.Equals, cache.Len())
+}
+
+func (*OptionsSuite) TestLowWatermark(c *gc.C) {
+	cache := lru.New(10, lru.WithLowWatermark(6))
+	for i := 0; i < 10; i++ {
+		cache.Add(i, i)
+	}
+	c.Assert(cache.Len(), gc.Equals, 10)
+	// Adding to the full cache evicts down to 6, the least recently used
+	// first, then adds the new entry.
+	cache.Get(0)
+	cache.Add(10, 10)
+	c.Check(collectKeys(cache.Range), gc.DeepEquals, []interface{}{10, 0, 9, 8, 7, 6, 5})
+	// Nothing more is evicted until it is full again.
+	for i := 11; i < 14; i++ {
+		cache.Add(i, i)
+	}
+	c.Check(cache.Len(), gc.Equals, 10)
+	cache.Add(14, 14)
+	c.Check(collectKeys(cache.Range), gc.DeepEquals, []interface{}{14, 13, 12, 11, 10, 0, 9})
+}
+
+func (*OptionsSuite) TestLowWatermarkInvalid(c *gc.C) {
+	c.Check(func() { lru.WithLowWatermark(0) }, gc.PanicMatches, "low must be > 0")
+	c.Check(func() { lru.New(10, lru.WithLowWatermark(10)) }, gc.PanicMatches, "low must be less than the size of the cache")
+}
+
+func (*OptionsSuite) TestNoEviction(c *gc.C) {
+	cache := lru.New(3, lru.WithNoEviction())
+	for i := 0; i < 3; i++ {
+		c.Check(cache.TryAdd(i, i), gc.Equals, true)
+	}
+	c.Check(cache.TryAdd(3, 3), gc.Equals, false)
+	cache.Add(4, 4)
+	c.Check(collectKeys(cache.Range), gc.DeepEquals, []interface{}{2, 1, 0})
+	// Existing entries can still be updated.
+	c.Check(cache.TryAdd(0, 10), gc.Equals, true)
+	checkPeekExists(c, cache, 0, 10)
+	// And once there is room, new ones can be added.
+	cache.RemoveIf(func(key, _ interface{}) bool { return key == 1 })
+	c.Check(cache.TryAdd(3, 3), gc.Equals, true)
+	checkPeekExists(c, cache, 3, 3)
+}
+
+func (*OptionsSuite) TestNoEvictionCost(c *gc.C) {
+	cache := lru.NewWithMaxCost(100, lru.WithNoEviction())
+	cache.AddWithCost("a", 1, 60)
+	cache.AddWithCost("b", 2, 50)
+	checkPeekMissing(c, cache, "b")
+	cache.AddWithCost("c", 3, 40)
+	checkPeekExists(c, cache, "a", 1)
+	checkPeekExists(c, cache, "c", 3)
+}
+
+func (*OptionsSuite) TestTryAddAdmission(c *gc.C) {
+	cache := lru.New(1, lru.WithDoorkeeper())
+	c.Check(cache.TryAdd("a", 1), gc.Equals, true)
+	c.Check(cache.TryAdd("b", 2), gc.Equals, false)
+	c.Check(cache.TryAdd("b", 2), gc.Equals, true)
+}
+
+// evictedEntry is an entry passed to an OnEvict callback.
+type evictedEntry struct {
+	key, value interface{}
+	reason     lru.EvictionReason
+}
+
+func (*OptionsSuite) TestOnEvict(c *gc.C) {
+	var evicted []evictedEntry
+	clock := newTestClock()
+	cache := lru.New(3, lru.WithClock(clock), lru.WithExpiry(time.Minute), lru.WithOnEvict(func(key, value interface{}, reason lru.EvictionReason) {
+		evicted = append(evicted, evictedEntry{key, value, reason})
+	}))
+	for i := 0; i < 4; i++ {
+		cache.Add(i, i*10)
+	}
+	c.Check(evicted, gc.DeepEquals, []evictedEntry{{0, 0, lru.CapacityEvicted}})
+	evicted = nil
+	cache.Add(1, 11)
+	c.Check(evicted, gc.DeepEquals, []evictedEntry{{1, 10, lru.Replaced}})
+	evicted = nil
+	cache.RemoveIf(func(key, _ interface{}) bool { return key == 2 })
+	c.Check(evicted, gc.DeepEquals, []evictedEntry{{2, 20, lru.Removed}})
+	evicted = nil
+	clock.Advance(2 * time.Minute)
+	checkGet(c, cache, 3, nil, false)
+	c.Check(evicted, gc.DeepEquals, []evictedEntry{{3, 30, lru.Expired}})
+	evicted = nil
+	c.Check(cache.RemoveExpired(), gc.Equals, 1)
+	c.Check(evicted, gc.DeepEquals, []evictedEntry{{1, 11, lru.Expired}})
+}
+
+func (*OptionsSuite) TestOnEvictCost(c *gc.C) {
+	var evicted []evictedEntry
+	cache := lru.NewWithMaxCost(10, lru.WithOnEvict(func(key, value interface{}, reason lru.EvictionReason) {
+		evicted = append(evicted, evictedEntry{key, value, reason})
+	}))
+	cache.AddWithCost("a", 1, 4)
+	cache.AddWithCost("b", 2, 4)
+	cache.AddWithCost("c", 3, 4)
+	c.Check(evicted, gc.DeepEquals, []evictedEntry{{"a", 1, lru.CapacityEvicted}})
+	evicted = nil
+	cache.AddWithCost("b", 4, 11)
+	c.Check(evicted, gc.DeepEquals, []evictedEntry{{"b", 2, lru.CapacityEvicted}})
+	evicted = nil
+	cache.Add("d", 5)
+	cache.TrimTo(1)
+	c.Check(evicted, gc.DeepEquals, []evictedEntry{{"c", 3, lru.CapacityEvicted}})
+	evicted = nil
+	cache.Purge()
+	c.Check(evicted, gc.DeepEquals, []evictedEntry{{"d", 5, lru.Purged}})
+	c.Check(cache.Len(), gc.Equals, 0)
+}
+
+func (*OptionsSuite) TestEvictionReasonString(c *gc.C) {
+	c.Check(lru.CapacityEvicted.String(), gc.Equals, "CapacityEvicted")
+	c.Check(lru.Purged.String(), gc.Equals, "Purged")
+	c.Check(lru.EvictionReason(10).String(), gc.Equals, "EvictionReason(10)")
+}
+
+func (*OptionsSuite) TestOnAddOnUpdate(c *gc.C) {
+	var events []string
+	cache := lru.New(2,
+		lru.WithOnAdd(func(key, value interface{}) {
+			events = append(events, fmt.Sprintf("add %v=%v", key, value))
+		}),
+		lru.WithOnUpdate(func(key, old, new interface{}) {
+			events = append(events, fmt.Sprintf("update %v=%v->%v", key, old, new))
+		}),
+		lru.WithOnEvict(func(key, value interface{}, reason lru.EvictionReason) {
+			if reason != lru.Replaced {
+				events = append(events, fmt.Sprintf("evict %v=%v", key, value))
+			}
+		}),
+	)
+	cache.Add("a", 1)
+	cache.Add("b", 2)
+	cache.Add("a", 3)
+	cache.Update("b", func(old interface{}, _ bool) (interface{}, bool) {
+		return old.(int) + 1, true
+	})
+	cache.Update("c", func(interface{}, bool) (interface{}, bool) {
+		return 4, true
+	})
+	cache.Update("b", func(interface{}, bool) (interface{}, bool) {
+		return nil, false
+	})
+	c.Check(events, gc.DeepEquals, []string{
+		"add a=1",
+		"add b=2",
+		"update a=1->3",
+		"update b=2->3",
+		"evict a=3",
+		"add c=4",
+	})
+}
+
+func (*OptionsSuite) TestOnEvictBatch(c *gc.C) {
+	type batch struct {
+		keys   []interface{}
+		reason lru.EvictionReason
+	}
+	var batches []batch
+	var single []interface{}
+	cache := lru.New(10,
+		lru.WithLowWatermark(6),
+		lru.WithOnEvictBatch(func(entries []lru.Entry, reason lru.EvictionReason) {
+			var keys []interface{}
+			for _, entry := range entries {
+				keys = append(keys, entry.Key)
+			}
+			batches = append(batches, batch{keys, reason})
+		}),
+		lru.WithOnEvict(func(key, _ interface{}, _ lru.EvictionReason) {
+			single = append(single, key)
+		}),
+	)
+	for i := 0; i < 11; i++ {
+		cache.Add(i, i)
+	}
+	cache.Add(0, 0)
+	// Replacing a value is never part of a batch.
+	cache.Add(10, 10)
+	cache.RemoveIf(func(key, _ interface{}) bool { return key.(int) >= 9 })
+	// Nothing to remove, so no batch.
+	cache.RemoveIf(func(key, _ interface{}) bool { return false })
+	cache.Purge()
+	c.Check(batches, gc.DeepEquals, []batch{
+		{[]interface{}{0, 1, 2, 3}, lru.CapacityEvicted},
+		{[]interface{}{10, 9}, lru.Removed},
+		{[]interface{}{4, 5, 6, 7, 8, 0}, lru.Purged},
+	})
+	c.Check(single, gc.DeepEquals, []interface{}{10})
+}
+
+func (*OptionsSuite) TestOnEvictBatchSingle(c *gc.C) {
+	var evicted [][]lru.Entry
+	cache := lru.New(1, lru.WithOnEvictBatch(func(entries []lru.Entry, _ lru.EvictionReason) {
+		evicted = append(evicted, entries)
+	}))
+	cache.Add("a", 1)
+	cache.Add("b", 2)
+	c.Check(evicted, gc.DeepEquals, [][]lru.Entry{{{Key: "a", Value: 1}}})
+}
+
+type testLogger struct {
+	messages []string
+}
+
+func (l *testLogger) Debugf(format string, args ...interface{}) {
+	l.messages = append(l.messages, fmt.Sprintf(format, args...))
+}
+
+func (*OptionsSuite) TestLogger(c *gc.C) {
+	logger := &testLogger{}
+	cache := lru.New(200, lru.WithLogger(logger))
+	for i := 0; i < 201; i++ {
+		cache.Add(i, i)
+	}
+	cache.RemoveIf(func(key, _ interface{}) bool { return key.(int) >= 10 })
+	cache.Compact()
+	c.Check(logger.messages[:3], gc.DeepEquals, []string{
+		"lru: grew buffer to 200 entries",
+		"lru: evicted 0 (CapacityEvicted)",
+		"lru: evicted 200 (Removed)",
+	})
+	c.Check(logger.messages[len(logger.messages)-1], gc.Equals, "lru: compacted buffer to 100 entries")
+}
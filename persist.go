@@ -0,0 +1,80 @@
+// Copyright 2026 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package lru
+
+import (
+	"encoding/gob"
+	"io"
+)
+
+// Save writes the entries in the cache to w with encoding/gob, from the least
+// recently used to the most, so that Load can recreate the same recency
+// order, such as to start with a warm cache after a restart. Expired entries
+// are not saved. As the keys and values are interface values, their types
+// must be registered with gob.Register, unless they are basic types such as
+// strings and ints. gob itself uses the MarshalBinary method of types that
+// implement encoding.BinaryMarshaler.
+func (lru *LRU) Save(w io.Writer) error {
+	enc := gob.NewEncoder(w)
+	var err error
+	lru.RangeReverse(func(key, value interface{}) bool {
+		err = enc.Encode(&Entry{Key: key, Value: value})
+		return err == nil
+	})
+	return err
+}
+
+// Load reads entries written by Save from r, and adds them to the cache in
+// the order they were saved, so that the most recently used entry is also the
+// most recently used in the cache. If there are more entries than fit, the
+// least recently used are evicted as usual. Loaded entries count as newly
+// written, for expiry. If an error is returned, the entries read before it
+// have still been added.
+func (lru *LRU) Load(r io.Reader) error {
+	dec := gob.NewDecoder(r)
+	for {
+		var entry Entry
+		if err := dec.Decode(&entry); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		lru.Add(entry.Key, entry.Value)
+	}
+}
+
+// Dump returns the entries in the cache, from the least recently used to the
+// most, so that adding them in order to an empty cache, such as with Warm,
+// recreates the same state. Expired entries are left out. It does not affect
+// how recently any entry was accessed.
+func (lru *LRU) Dump() []Entry {
+	return lru.PeekLeastRecentN(lru.size)
+}
+
+// NewFromEntries creates a new LRU cache as New does, and adds entries to it
+// with Warm.
+func NewFromEntries(size int, entries []Entry, options ...Option) *LRU {
+	lru := New(size, options...)
+	lru.Warm(entries)
+	return lru
+}
+
+// Warm adds entries to the cache, taking them to be in order from the least
+// recently used to the most, as RangeReverse visits them, so that the last
+// entry is the most recently used. It is like calling Add for each entry, but
+// grows the cache's buffers once, rather than as it goes. If there are more
+// entries than fit, the first ones are evicted as usual.
+func (lru *LRU) Warm(entries []Entry) {
+	if n := lru.size + len(entries); n >= len(lru.buf) {
+		if n > lru.maxSize {
+			n = lru.maxSize
+		}
+		if n >= len(lru.buf) {
+			lru.resizeBuffers(n)
+		}
+	}
+	for _, entry := range entries {
+		lru.Add(entry.Key, entry.Value)
+	}
+}
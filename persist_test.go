@@ -0,0 +1,168 @@
+// Copyright 2026 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package lru_test
+
+import (
+	"bytes"
+	"encoding/json"
+
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/lru"
+)
+
+type PersistSuite struct{}
+
+var _ = gc.Suite(&PersistSuite{})
+
+func (*PersistSuite) TestSaveLoad(c *gc.C) {
+	cache := simpleFullCache()
+	cache.Get(5)
+	var buf bytes.Buffer
+	c.Assert(cache.Save(&buf), gc.IsNil)
+
+	loaded := lru.New(10)
+	c.Assert(loaded.Load(&buf), gc.IsNil)
+	c.Check(collectKeys(loaded.Range), gc.DeepEquals, collectKeys(cache.Range))
+	checkPeekExists(c, loaded, 5, "e")
+	// The eviction order is the same too.
+	cache.Add(11, 11)
+	loaded.Add(11, 11)
+	c.Check(collectKeys(loaded.Range), gc.DeepEquals, collectKeys(cache.Range))
+}
+
+func (*PersistSuite) TestLoadMoreThanFits(c *gc.C) {
+	var buf bytes.Buffer
+	c.Assert(simpleFullCache().Save(&buf), gc.IsNil)
+	loaded := lru.New(3)
+	c.Assert(loaded.Load(&buf), gc.IsNil)
+	// The most recently used entries are kept.
+	c.Check(collectKeys(loaded.Range), gc.DeepEquals, []interface{}{0, 9, 8})
+}
+
+type persistValue struct {
+	Name string
+}
+
+func (*PersistSuite) TestSaveUnregisteredType(c *gc.C) {
+	cache := lru.New(10)
+	cache.Add("a", persistValue{Name: "a"})
+	var buf bytes.Buffer
+	c.Check(cache.Save(&buf), gc.ErrorMatches, ".*type not registered for interface.*")
+}
+
+func (*PersistSuite) TestLoadBadData(c *gc.C) {
+	cache := lru.New(10)
+	c.Check(cache.Load(bytes.NewBufferString("not gob")), gc.NotNil)
+}
+
+func (*PersistSuite) TestSyncSaveLoad(c *gc.C) {
+	cache := lru.NewSync(10)
+	cache.Add("a", 1)
+	cache.Add("b", 2)
+	cache.Get("a")
+	var buf bytes.Buffer
+	c.Assert(cache.Save(&buf), gc.IsNil)
+	loaded := lru.NewSync(10)
+	c.Assert(loaded.Load(&buf), gc.IsNil)
+	c.Check(collectKeys(loaded.Range), gc.DeepEquals, []interface{}{"a", "b"})
+}
+
+func (*PersistSuite) TestJSON(c *gc.C) {
+	cache := lru.New(10)
+	cache.Add("a", 1)
+	cache.Add("b", "two")
+	cache.Add("c", []int{3})
+	cache.Get("a")
+	data, err := json.Marshal(cache)
+	c.Assert(err, gc.IsNil)
+	c.Check(string(data), gc.Equals, `[{"key":"b","value":"two"},{"key":"c","value":[3]},{"key":"a","value":1}]`)
+
+	loaded := lru.New(10)
+	c.Assert(json.Unmarshal(data, loaded), gc.IsNil)
+	c.Check(collectKeys(loaded.Range), gc.DeepEquals, []interface{}{"a", "c", "b"})
+	checkPeekExists(c, loaded, "a", 1.0)
+}
+
+func (*PersistSuite) TestUnmarshalJSONNeedsNew(c *gc.C) {
+	var cache lru.LRU
+	c.Check(json.Unmarshal([]byte(`[]`), &cache), gc.ErrorMatches, "lru: UnmarshalJSON needs a cache created with New")
+}
+
+func (*PersistSuite) TestSyncJSON(c *gc.C) {
+	cache := lru.NewSync(10)
+	cache.Add("a", 1)
+	data, err := json.Marshal(cache)
+	c.Assert(err, gc.IsNil)
+	c.Check(string(data), gc.Equals, `[{"key":"a","value":1}]`)
+	loaded := lru.NewSync(10)
+	c.Assert(json.Unmarshal(data, loaded), gc.IsNil)
+	c.Check(loaded.Len(), gc.Equals, 1)
+}
+
+func (*PersistSuite) TestStringCacheJSON(c *gc.C) {
+	cache := lru.NewStringCache(10)
+	cache.Intern("a")
+	cache.Intern("b")
+	cache.Intern("a")
+	data, err := json.Marshal(cache)
+	c.Assert(err, gc.IsNil)
+	c.Check(string(data), gc.Equals, `["b","a"]`)
+
+	loaded := lru.NewStringCache(1)
+	c.Assert(json.Unmarshal(data, loaded), gc.IsNil)
+	c.Check(loaded.Contains("a"), gc.Equals, true)
+	c.Check(loaded.Contains("b"), gc.Equals, false)
+}
+
+func (*PersistSuite) TestNewFromEntries(c *gc.C) {
+	entries := []lru.Entry{{Key: "a", Value: 1}, {Key: "b", Value: 2}, {Key: "c", Value: 3}}
+	cache := lru.NewFromEntries(200, entries)
+	c.Check(collectKeys(cache.Range), gc.DeepEquals, []interface{}{"c", "b", "a"})
+
+	// Only the most recent entries are kept if they don't all fit.
+	cache = lru.NewFromEntries(2, entries)
+	c.Check(collectKeys(cache.Range), gc.DeepEquals, []interface{}{"c", "b"})
+}
+
+func (*PersistSuite) TestWarmLarge(c *gc.C) {
+	entries := make([]lru.Entry, 1000)
+	for i := range entries {
+		entries[i] = lru.Entry{Key: i, Value: i}
+	}
+	cache := lru.New(500)
+	cache.Add("x", "x")
+	cache.Warm(entries)
+	c.Check(cache.Len(), gc.Equals, 500)
+	checkPeekExists(c, cache, 999, 999)
+	checkPeekMissing(c, cache, 499)
+}
+
+func (*PersistSuite) TestSyncWarm(c *gc.C) {
+	cache := lru.NewSync(10)
+	cache.Warm([]lru.Entry{{Key: "a", Value: 1}, {Key: "b", Value: 2}})
+	c.Check(collectKeys(cache.Range), gc.DeepEquals, []interface{}{"b", "a"})
+}
+
+func (*PersistSuite) TestDump(c *gc.C) {
+	cache := lru.New(10)
+	c.Check(cache.Dump(), gc.HasLen, 0)
+	cache.Add("a", 1)
+	cache.Add("b", 2)
+	cache.Add("c", 3)
+	cache.Get("a")
+	entries := cache.Dump()
+	c.Check(entries, gc.DeepEquals, []lru.Entry{{Key: "b", Value: 2}, {Key: "c", Value: 3}, {Key: "a", Value: 1}})
+	// Dumping doesn't count as a use.
+	c.Check(cache.Dump(), gc.DeepEquals, entries)
+	c.Check(lru.NewFromEntries(10, entries).Dump(), gc.DeepEquals, entries)
+}
+
+func (*PersistSuite) TestSyncDump(c *gc.C) {
+	cache := lru.NewSync(10)
+	cache.Add("a", 1)
+	cache.Add("b", 2)
+	cache.Get("a")
+	c.Check(cache.Dump(), gc.DeepEquals, []lru.Entry{{Key: "b", Value: 2}, {Key: "a", Value: 1}})
+}
@@ -0,0 +1,285 @@
+// Copyright 2026 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package lru
+
+import (
+	"math/rand"
+)
+
+// evictionPolicy selects how an LRU picks the entry to evict.
+type evictionPolicy int
+
+const (
+	// policyLRU evicts the least recently used entry.
+	policyLRU evictionPolicy = iota
+	// policyRandom evicts an entry at random, see WithRandomEviction.
+	policyRandom
+	// policySIEVE uses the SIEVE algorithm, see WithSIEVE.
+	policySIEVE
+	// policyFIFO evicts the entry that was added first, see WithFIFO.
+	policyFIFO
+	// policyMidpoint evicts the least recently used entry, but adds new
+	// entries part way along the list, see WithMidpointInsertion.
+	policyMidpoint
+	// policySampled evicts the least recently used of a random sample of
+	// entries, see WithSampledEviction.
+	policySampled
+)
+
+// touch marks elem as having been used, which for most policies means moving
+// it to the front of the list.
+func (lru *LRU) touch(elem uint32, entry *cacheEntry) {
+	switch lru.policy {
+	case policyLRU:
+		lru.moveToFront(elem, entry)
+	case policySIEVE:
+		lru.setMarked(elem, true)
+	case policyMidpoint:
+		lru.leaveOld(elem, entry)
+		lru.moveToFront(elem, entry)
+		lru.balanceOld()
+	case policySampled:
+		lru.stamp(elem)
+	}
+}
+
+// place links a new entry into the list, which for most policies means at
+// the front.
+func (lru *LRU) place(elem uint32, entry *cacheEntry) {
+	if lru.policy == policySampled {
+		lru.stamp(elem)
+	}
+	if lru.policy != policyMidpoint {
+		lru.moveToFront(elem, entry)
+		return
+	}
+	if entry.prev != 0 || lru.root.next == elem {
+		// It is being reused, take it out of its current spot.
+		lru.buf[entry.prev].next = entry.next
+		lru.buf[entry.next].prev = entry.prev
+	}
+	// Put it at the start of the old part of the list.
+	at := lru.mid
+	entry.prev = lru.buf[at].prev
+	entry.next = at
+	lru.buf[entry.prev].next = elem
+	lru.buf[at].prev = elem
+	lru.mid = elem
+	lru.setMarked(elem, true)
+	lru.oldLen++
+	lru.balanceOld()
+}
+
+// leaveOld takes elem out of the old part of the list, if it is in it.
+func (lru *LRU) leaveOld(elem uint32, entry *cacheEntry) {
+	if !lru.isMarked(elem) {
+		return
+	}
+	lru.setMarked(elem, false)
+	lru.oldLen--
+	if lru.mid == elem {
+		lru.mid = entry.next
+	}
+}
+
+// balanceOld moves the start of the old part of the list so that it holds
+// its share of the entries.
+func (lru *LRU) balanceOld() {
+	target := int(lru.oldRatio * float64(lru.size))
+	for lru.oldLen > target {
+		lru.setMarked(lru.mid, false)
+		lru.mid = lru.buf[lru.mid].next
+		lru.oldLen--
+	}
+	for lru.oldLen < target {
+		prev := lru.buf[lru.mid].prev
+		if prev == 0 {
+			break
+		}
+		lru.mid = prev
+		lru.setMarked(prev, true)
+		lru.oldLen++
+	}
+}
+
+// victim returns the entry to evict to make room for a new one.
+func (lru *LRU) victim() uint32 {
+	if lru.evictsLRU() {
+		return lru.root.prev
+	}
+	switch lru.policy {
+	case policyRandom:
+		return uint32(1 + lru.random()%uint64(lru.size))
+	case policySIEVE:
+		return lru.sieve()
+	case policySampled:
+		return lru.sample()
+	default:
+		if lru.priorities != nil {
+			return lru.lowestPriority()
+		}
+		return lru.root.prev
+	}
+}
+
+// evictsLRU returns whether victim always picks the least recently used
+// entry, which is also the one added longest ago if none have been used since.
+func (lru *LRU) evictsLRU() bool {
+	return lru.policy == policyLRU && lru.priorities == nil
+}
+
+// lowestPriority returns the entry with the lowest priority among the
+// priorityWindow least recently used entries, and of those, the least recently
+// used.
+func (lru *LRU) lowestPriority() uint32 {
+	victim := lru.root.prev
+	elem := victim
+	for i := 0; i < priorityWindow && elem != 0; i++ {
+		if lru.priorities[elem] < lru.priorities[victim] {
+			victim = elem
+		}
+		elem = lru.buf[elem].prev
+	}
+	return victim
+}
+
+// random returns a pseudo-random number. We don't need anything better than
+// xorshift, and it avoids the lock around the global source in math/rand.
+func (lru *LRU) random() uint64 {
+	if lru.rng == 0 {
+		lru.rng = rand.Uint64() | 1
+	}
+	lru.rng ^= lru.rng >> 12
+	lru.rng ^= lru.rng << 25
+	lru.rng ^= lru.rng >> 27
+	return lru.rng * 2685821657736338717
+}
+
+// sieve moves the hand from the oldest entry towards the newest, clearing the
+// visited bits as it goes, until it finds an entry that has not been visited,
+// which it returns. The hand is left at the next newer entry, to carry on
+// from there next time.
+func (lru *LRU) sieve() uint32 {
+	elem := lru.hand
+	for {
+		if elem == 0 {
+			elem = lru.root.prev
+		}
+		if !lru.isMarked(elem) {
+			lru.hand = lru.buf[elem].prev
+			return elem
+		}
+		lru.setMarked(elem, false)
+		elem = lru.buf[elem].prev
+	}
+}
+
+// stamp records that elem has just been used.
+func (lru *LRU) stamp(elem uint32) {
+	lru.uses++
+	lru.stamps[elem] = lru.uses
+}
+
+// sample returns the least recently used of a random sample of entries.
+// Entries may be picked more than once, which doesn't matter.
+func (lru *LRU) sample() uint32 {
+	victim := uint32(1 + lru.random()%uint64(lru.size))
+	for i := 1; i < lru.samples; i++ {
+		elem := uint32(1 + lru.random()%uint64(lru.size))
+		if lru.stamps[elem] < lru.stamps[victim] {
+			victim = elem
+		}
+	}
+	return victim
+}
+
+// policyRemoving is called when elem, which holds entry, is about to be
+// removed from the list, or reused for a new entry.
+func (lru *LRU) policyRemoving(elem uint32, entry *cacheEntry) {
+	if lru.hand == elem {
+		lru.hand = entry.prev
+	}
+	if lru.policy == policyMidpoint {
+		lru.leaveOld(elem, entry)
+	}
+	if lru.costs != nil {
+		lru.totalCost -= lru.costs[elem]
+	}
+}
+
+// policyMoved is called when removeElem has moved an entry from one element
+// to another.
+func (lru *LRU) policyMoved(from, to uint32) {
+	if lru.hand == from {
+		lru.hand = to
+	}
+	if lru.mid == from {
+		lru.mid = to
+	}
+	lru.setMarked(to, lru.isMarked(from))
+	if lru.priorities != nil {
+		lru.priorities[to] = lru.priorities[from]
+	}
+	if lru.stamps != nil {
+		lru.stamps[to] = lru.stamps[from]
+	}
+	if lru.accessCounts != nil {
+		lru.accessCounts[to] = lru.accessCounts[from]
+	}
+	if lru.costs != nil {
+		lru.costs[to] = lru.costs[from]
+	}
+}
+
+func (lru *LRU) isMarked(elem uint32) bool {
+	return lru.marks != nil && lru.marks[elem/64]&(1<<(elem%64)) != 0
+}
+
+func (lru *LRU) setMarked(elem uint32, marked bool) {
+	if lru.marks == nil {
+		return
+	}
+	if marked {
+		lru.marks[elem/64] |= 1 << (elem % 64)
+	} else {
+		lru.marks[elem/64] &^= 1 << (elem % 64)
+	}
+}
+
+// Priority ranks entries added with AddWithPriority. When the cache is full,
+// an entry with a lower priority is evicted before one with a higher priority
+// that was used at about the same time.
+type Priority int8
+
+const (
+	// PriorityLow is for entries that are cheap to recreate.
+	PriorityLow Priority = -1
+	// PriorityNormal is the priority of entries added with Add.
+	PriorityNormal Priority = 0
+	// PriorityHigh is for entries that are expensive to recreate.
+	PriorityHigh Priority = 1
+)
+
+// priorityWindow is how many of the least recently used entries are looked
+// at to find one with the lowest priority. Only entries within the window are
+// considered, so looking for a victim stays cheap however large the cache is.
+const priorityWindow = 16
+
+// AddWithPriority adds a new entry into the cache with the given priority, or
+// updates the value and priority of an existing entry. When the cache is
+// full, the entry evicted is the least recently used of those with the lowest
+// priority among the 16 least recently used entries, so low priority entries
+// go before high priority ones of comparable recency. Note that this means an
+// entry is never evicted while one with a lower priority is among the 16
+// least recently used. Entries added with Add have PriorityNormal, and Add
+// does not change the priority of an existing entry.
+func (lru *LRU) AddWithPriority(key, value interface{}, priority Priority) {
+	if lru.priorities == nil {
+		lru.priorities = make([]Priority, len(lru.buf))
+	}
+	lru.Add(key, value)
+	if elem, ok := lru.elements[lru.identity(key)]; ok {
+		lru.priorities[elem] = priority
+	}
+}
@@ -0,0 +1,132 @@
+// Copyright 2026 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+// Package prometheus exports the statistics of caches from
+// github.com/juju/lru as Prometheus metrics, so that every cache is reported
+// with the same metric names. It is a separate module, so that the lru
+// package itself doesn't depend on the Prometheus client.
+package prometheus
+
+import (
+	"time"
+
+	prom "github.com/prometheus/client_golang/prometheus"
+
+	"github.com/juju/lru"
+)
+
+// StatsSource is a cache that can report its statistics. It is satisfied by
+// *lru.SyncLRU and *lru.ShardedLRU.
+type StatsSource interface {
+	Stats() lru.Stats
+}
+
+// metric describes one of the metrics reported for each cache.
+type metric struct {
+	name, help string
+	valueType  prom.ValueType
+	value      func(lru.Stats) float64
+}
+
+var metrics = []metric{{
+	"lru_cache_entries",
+	"Number of entries in the cache.",
+	prom.GaugeValue,
+	func(s lru.Stats) float64 { return float64(s.Len) },
+}, {
+	"lru_cache_capacity",
+	"Most entries the cache can hold, or 0 if it is unbounded.",
+	prom.GaugeValue,
+	func(s lru.Stats) float64 { return float64(s.Cap) },
+}, {
+	"lru_cache_hits_total",
+	"Lookups that found the key in the cache.",
+	prom.CounterValue,
+	func(s lru.Stats) float64 { return float64(s.Hits) },
+}, {
+	"lru_cache_misses_total",
+	"Lookups that did not find the key in the cache.",
+	prom.CounterValue,
+	func(s lru.Stats) float64 { return float64(s.Misses) },
+}, {
+	"lru_cache_hit_ratio",
+	"Share of lookups that found the key in the cache, since it was created.",
+	prom.GaugeValue,
+	func(s lru.Stats) float64 {
+		if lookups := s.Hits + s.Misses; lookups > 0 {
+			return float64(s.Hits) / float64(lookups)
+		}
+		return 0
+	},
+}, {
+	"lru_cache_evictions_total",
+	"Entries evicted to make room for new ones.",
+	prom.CounterValue,
+	func(s lru.Stats) float64 { return float64(s.Evictions) },
+}, {
+	"lru_cache_expirations_total",
+	"Entries removed because they expired.",
+	prom.CounterValue,
+	func(s lru.Stats) float64 { return float64(s.Expirations) },
+}, {
+	"lru_cache_replacements_total",
+	"Values replaced by a new value for the same key.",
+	prom.CounterValue,
+	func(s lru.Stats) float64 { return float64(s.Replacements) },
+}}
+
+// Collector is a prometheus.Collector that reports the statistics of a
+// cache, labelled with its name, along with how long values took to load, as
+// given to ObserveLoad.
+type Collector struct {
+	cache StatsSource
+	// descs is parallel to metrics.
+	descs []*prom.Desc
+	loads prom.Histogram
+}
+
+// NewCollector returns a Collector for cache, whose metrics have a "cache"
+// label with the given name, to tell different caches apart. It needs to be
+// registered, such as with prometheus.MustRegister.
+func NewCollector(name string, cache StatsSource) *Collector {
+	c := &Collector{
+		cache: cache,
+		descs: make([]*prom.Desc, len(metrics)),
+	}
+	labels := prom.Labels{"cache": name}
+	for i, m := range metrics {
+		c.descs[i] = prom.NewDesc(m.name, m.help, nil, labels)
+	}
+	c.loads = prom.NewHistogram(prom.HistogramOpts{
+		Name:        "lru_cache_load_duration_seconds",
+		Help:        "Time taken to load values on a miss, as given to ObserveLoad.",
+		ConstLabels: labels,
+		Buckets:     prom.DefBuckets,
+	})
+	return c
+}
+
+// ObserveLoad records that loading a value for the cache, such as after a
+// miss, took d. The caches don't load values themselves, so whatever does
+// should call this, and probably SetRecomputeTime too, for each value it
+// loads. It is safe to call from multiple goroutines.
+func (c *Collector) ObserveLoad(d time.Duration) {
+	c.loads.Observe(d.Seconds())
+}
+
+// Describe is part of the prometheus.Collector interface.
+func (c *Collector) Describe(ch chan<- *prom.Desc) {
+	for _, desc := range c.descs {
+		ch <- desc
+	}
+	c.loads.Describe(ch)
+}
+
+// Collect is part of the prometheus.Collector interface.
+func (c *Collector) Collect(ch chan<- prom.Metric) {
+	stats := c.cache.Stats()
+	for i, m := range metrics {
+		ch <- prom.MustNewConstMetric(c.descs[i], m.valueType, m.value(stats))
+	}
+	c.loads.Collect(ch)
+}
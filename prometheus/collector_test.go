@@ -0,0 +1,118 @@
+// Copyright 2026 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package prometheus_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	prom "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/lru"
+	"github.com/juju/lru/prometheus"
+)
+
+func TestAll(t *testing.T) {
+	gc.TestingT(t)
+}
+
+type CollectorSuite struct{}
+
+var _ = gc.Suite(&CollectorSuite{})
+
+func (*CollectorSuite) TestCollect(c *gc.C) {
+	cache := lru.NewSync(2)
+	cache.Add("a", 1)
+	cache.Add("a", 2)
+	cache.Add("b", 3)
+	cache.Add("c", 4)
+	cache.Get("c")
+	cache.Get("c")
+	cache.Get("c")
+	cache.Get("a")
+	registry := prom.NewPedanticRegistry()
+	registry.MustRegister(prometheus.NewCollector("things", cache))
+	err := testutil.GatherAndCompare(registry, strings.NewReader(`
+# HELP lru_cache_capacity Most entries the cache can hold, or 0 if it is unbounded.
+# TYPE lru_cache_capacity gauge
+lru_cache_capacity{cache="things"} 2
+# HELP lru_cache_entries Number of entries in the cache.
+# TYPE lru_cache_entries gauge
+lru_cache_entries{cache="things"} 2
+# HELP lru_cache_evictions_total Entries evicted to make room for new ones.
+# TYPE lru_cache_evictions_total counter
+lru_cache_evictions_total{cache="things"} 1
+# HELP lru_cache_expirations_total Entries removed because they expired.
+# TYPE lru_cache_expirations_total counter
+lru_cache_expirations_total{cache="things"} 0
+# HELP lru_cache_load_duration_seconds Time taken to load values on a miss, as given to ObserveLoad.
+# TYPE lru_cache_load_duration_seconds histogram
+lru_cache_load_duration_seconds_bucket{cache="things",le="0.005"} 0
+lru_cache_load_duration_seconds_bucket{cache="things",le="0.01"} 0
+lru_cache_load_duration_seconds_bucket{cache="things",le="0.025"} 0
+lru_cache_load_duration_seconds_bucket{cache="things",le="0.05"} 0
+lru_cache_load_duration_seconds_bucket{cache="things",le="0.1"} 0
+lru_cache_load_duration_seconds_bucket{cache="things",le="0.25"} 0
+lru_cache_load_duration_seconds_bucket{cache="things",le="0.5"} 0
+lru_cache_load_duration_seconds_bucket{cache="things",le="1"} 0
+lru_cache_load_duration_seconds_bucket{cache="things",le="2.5"} 0
+lru_cache_load_duration_seconds_bucket{cache="things",le="5"} 0
+lru_cache_load_duration_seconds_bucket{cache="things",le="10"} 0
+lru_cache_load_duration_seconds_bucket{cache="things",le="+Inf"} 0
+lru_cache_load_duration_seconds_sum{cache="things"} 0
+lru_cache_load_duration_seconds_count{cache="things"} 0
+# HELP lru_cache_hit_ratio Share of lookups that found the key in the cache, since it was created.
+# TYPE lru_cache_hit_ratio gauge
+lru_cache_hit_ratio{cache="things"} 0.75
+# HELP lru_cache_hits_total Lookups that found the key in the cache.
+# TYPE lru_cache_hits_total counter
+lru_cache_hits_total{cache="things"} 3
+# HELP lru_cache_misses_total Lookups that did not find the key in the cache.
+# TYPE lru_cache_misses_total counter
+lru_cache_misses_total{cache="things"} 1
+# HELP lru_cache_replacements_total Values replaced by a new value for the same key.
+# TYPE lru_cache_replacements_total counter
+lru_cache_replacements_total{cache="things"} 1
+`))
+	c.Assert(err, gc.IsNil)
+}
+
+func (*CollectorSuite) TestSeveralCaches(c *gc.C) {
+	registry := prom.NewPedanticRegistry()
+	registry.MustRegister(prometheus.NewCollector("one", lru.NewSync(10)))
+	registry.MustRegister(prometheus.NewCollector("two", lru.NewSharded(2, 10)))
+	count, err := testutil.GatherAndCount(registry, "lru_cache_entries")
+	c.Assert(err, gc.IsNil)
+	c.Check(count, gc.Equals, 2)
+}
+
+func (*CollectorSuite) TestObserveLoad(c *gc.C) {
+	collector := prometheus.NewCollector("things", lru.NewSync(10))
+	collector.ObserveLoad(20 * time.Millisecond)
+	collector.ObserveLoad(3 * time.Second)
+	registry := prom.NewPedanticRegistry()
+	registry.MustRegister(collector)
+	err := testutil.GatherAndCompare(registry, strings.NewReader(`
+# HELP lru_cache_load_duration_seconds Time taken to load values on a miss, as given to ObserveLoad.
+# TYPE lru_cache_load_duration_seconds histogram
+lru_cache_load_duration_seconds_bucket{cache="things",le="0.005"} 0
+lru_cache_load_duration_seconds_bucket{cache="things",le="0.01"} 0
+lru_cache_load_duration_seconds_bucket{cache="things",le="0.025"} 1
+lru_cache_load_duration_seconds_bucket{cache="things",le="0.05"} 1
+lru_cache_load_duration_seconds_bucket{cache="things",le="0.1"} 1
+lru_cache_load_duration_seconds_bucket{cache="things",le="0.25"} 1
+lru_cache_load_duration_seconds_bucket{cache="things",le="0.5"} 1
+lru_cache_load_duration_seconds_bucket{cache="things",le="1"} 1
+lru_cache_load_duration_seconds_bucket{cache="things",le="2.5"} 1
+lru_cache_load_duration_seconds_bucket{cache="things",le="5"} 2
+lru_cache_load_duration_seconds_bucket{cache="things",le="10"} 2
+lru_cache_load_duration_seconds_bucket{cache="things",le="+Inf"} 2
+lru_cache_load_duration_seconds_sum{cache="things"} 3.02
+lru_cache_load_duration_seconds_count{cache="things"} 2
+`), "lru_cache_load_duration_seconds")
+	c.Assert(err, gc.IsNil)
+}
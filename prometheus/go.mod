@@ -0,0 +1,27 @@
+module github.com/juju/lru/prometheus
+
+go 1.18
+
+require (
+	github.com/juju/lru v0.0.0-00010101000000-000000000000
+	github.com/prometheus/client_golang v1.14.0
+	gopkg.in/check.v1 v1.0.0-20190902080502-41f04d3bba15
+)
+
+require (
+	github.com/beorn7/perks v1.0.1 // indirect
+	github.com/cespare/xxhash/v2 v2.1.2 // indirect
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/golang/protobuf v1.5.2 // indirect
+	github.com/kr/pretty v0.1.0 // indirect
+	github.com/kr/text v0.1.0 // indirect
+	github.com/matttproud/golang_protobuf_extensions v1.0.1 // indirect
+	github.com/prometheus/client_model v0.3.0 // indirect
+	github.com/prometheus/common v0.37.0 // indirect
+	github.com/prometheus/procfs v0.8.0 // indirect
+	golang.org/x/sys v0.0.0-20220520151302-bc2c85ada10a // indirect
+	google.golang.org/protobuf v1.28.1 // indirect
+)
+
+// The collector is developed alongside the cache it reports on.
+replace github.com/juju/lru => ../
@@ -0,0 +1,60 @@
+// Copyright 2026 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package lru
+
+import "sync"
+
+// StatsSource is a cache that can report its Stats, such as a *SyncLRU or a
+// *ShardedLRU.
+type StatsSource interface {
+	Stats() Stats
+}
+
+// registry holds the caches passed to Register, by name.
+var registry = struct {
+	mu     sync.Mutex
+	caches map[string]StatsSource
+}{caches: make(map[string]StatsSource)}
+
+// Register adds cache to the caches returned by Caches, under the given name,
+// so that an application can report on all of its caches in one place. It
+// panics if a cache has already been registered with the same name. Only
+// register caches that are safe to use from multiple goroutines.
+func Register(name string, cache StatsSource) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	if _, ok := registry.caches[name]; ok {
+		panic("lru: cache " + name + " is already registered")
+	}
+	registry.caches[name] = cache
+}
+
+// Unregister removes the cache registered with the given name, if there is
+// one, such as when it is no longer used.
+func Unregister(name string) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	delete(registry.caches, name)
+}
+
+// Caches returns the registered caches, by name.
+func Caches() map[string]StatsSource {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	caches := make(map[string]StatsSource, len(registry.caches))
+	for name, cache := range registry.caches {
+		caches[name] = cache
+	}
+	return caches
+}
+
+// AllStats returns the Stats of every registered cache, by name.
+func AllStats() map[string]Stats {
+	caches := Caches()
+	stats := make(map[string]Stats, len(caches))
+	for name, cache := range caches {
+		stats[name] = cache.Stats()
+	}
+	return stats
+}
@@ -0,0 +1,45 @@
+// Copyright 2026 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package lru_test
+
+import (
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/lru"
+)
+
+type RegistrySuite struct{}
+
+var _ = gc.Suite(&RegistrySuite{})
+
+func (*RegistrySuite) TestRegister(c *gc.C) {
+	cache := lru.NewSync(10)
+	sharded := lru.NewSharded(2, 10)
+	lru.Register("registry-sync", cache)
+	defer lru.Unregister("registry-sync")
+	lru.Register("registry-sharded", sharded)
+	defer lru.Unregister("registry-sharded")
+
+	caches := lru.Caches()
+	c.Check(caches["registry-sync"], gc.Equals, cache)
+	c.Check(caches["registry-sharded"], gc.Equals, sharded)
+
+	cache.Add("a", 1)
+	cache.Get("a")
+	stats := lru.AllStats()
+	c.Check(stats["registry-sync"], gc.Equals, lru.Stats{Len: 1, Cap: 10, Hits: 1})
+	c.Check(stats["registry-sharded"], gc.Equals, lru.Stats{Cap: 10})
+
+	lru.Unregister("registry-sharded")
+	_, ok := lru.Caches()["registry-sharded"]
+	c.Check(ok, gc.Equals, false)
+}
+
+func (*RegistrySuite) TestRegisterTwice(c *gc.C) {
+	lru.Register("registry-twice", lru.NewSync(10))
+	defer lru.Unregister("registry-twice")
+	c.Check(func() {
+		lru.Register("registry-twice", lru.NewSync(10))
+	}, gc.PanicMatches, "lru: cache registry-twice is already registered")
+}
@@ -0,0 +1,222 @@
+// Copyright 2026 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package lru
+
+// DefaultS3FIFOSmallRatio is the share of an S3FIFOCache given to its small
+// queue.
+const DefaultS3FIFOSmallRatio = 0.1
+
+const (
+	// s3Small and s3Main are the roots of the small and main queues in the
+	// buffer of an S3FIFOCache.
+	s3Small = 0
+	s3Main  = 1
+
+	// s3MaxFreq is the most uses an S3FIFOCache counts for an entry.
+	s3MaxFreq = 3
+)
+
+// S3FIFOCache is a cache using the S3-FIFO algorithm, which uses only FIFO
+// queues, but gets a better hit ratio than LRU on many workloads, as most
+// keys that are only used once are evicted soon after they are added.
+// New entries go into a small queue. When an entry reaches the end of the
+// small queue, it is moved into the main queue if it has been used since it
+// was added, and is evicted otherwise, with its key remembered in a ghost
+// queue. Keys that are added again while still in the ghost queue go
+// straight into the main queue. When an entry reaches the end of the main
+// queue, it is put back at the start if it has been used since it was last
+// there (up to 3 times), and is evicted otherwise.
+// Hits only increment a counter, and never reorder the queues. The queues are
+// stored in a single flat buffer, linked by offset, as for LRU.
+// Note that S3FIFOCache is *not* thread safe, some form of mutex is necessary
+// if you want to access it from multiple threads.
+type S3FIFOCache struct {
+	maxSize   int
+	smallSize int
+	smallLen  int
+	mainLen   int
+	// buf[s3Small] and buf[s3Main] are the roots of the two queues, new
+	// entries are added at next, and removed from prev.
+	buf      []s3Entry
+	elements map[interface{}]uint32
+	// ghost holds the keys recently evicted from the small queue, with nil
+	// values. Nothing is ever looked up in it with Get, so it is in FIFO
+	// order.
+	ghost *LRU
+}
+
+type s3Entry struct {
+	prev, next uint32
+	main       bool
+	freq       uint8
+	key        interface{}
+	value      interface{}
+}
+
+// NewS3FIFO creates an S3FIFOCache that will hold no more than the given
+// number of items, with the default share for the small queue.
+func NewS3FIFO(size int) *S3FIFOCache {
+	return NewS3FIFOWithRatio(size, DefaultS3FIFOSmallRatio)
+}
+
+// NewS3FIFOWithRatio creates an S3FIFOCache that will hold no more than the
+// given number of items, where smallRatio is the share of the cache that the
+// small queue can take up. The ghost queue remembers as many keys as the main
+// queue can hold.
+func NewS3FIFOWithRatio(size int, smallRatio float64) *S3FIFOCache {
+	if size > maxLRUSize || size <= 0 {
+		panic("size must not be <= 0 or >= 2^32")
+	}
+	if smallRatio < 0 || smallRatio > 1 {
+		panic("smallRatio must be between 0 and 1")
+	}
+	smallSize := int(float64(size) * smallRatio)
+	ghostSize := size - smallSize
+	if ghostSize < 1 {
+		ghostSize = 1
+	}
+	initialBufSize := size + 2
+	if initialBufSize > 102 {
+		initialBufSize = 102
+	}
+	c := &S3FIFOCache{
+		maxSize:   size,
+		smallSize: smallSize,
+		buf:       make([]s3Entry, 2, initialBufSize),
+		elements:  make(map[interface{}]uint32, initialBufSize),
+		ghost:     New(ghostSize),
+	}
+	c.buf[s3Small] = s3Entry{prev: s3Small, next: s3Small}
+	c.buf[s3Main] = s3Entry{prev: s3Main, next: s3Main}
+	return c
+}
+
+// Len gives the number of items in the cache
+func (c *S3FIFOCache) Len() int {
+	return c.smallLen + c.mainLen
+}
+
+// Add a new entry into the cache. Adding a key that is already in the cache
+// counts as a use of it.
+func (c *S3FIFOCache) Add(key, value interface{}) {
+	id := mapKey(key)
+	if elem, exists := c.elements[id]; exists {
+		entry := &c.buf[elem]
+		entry.value = value
+		entry.used()
+		return
+	}
+	var elem uint32
+	if c.Len() < c.maxSize {
+		if len(c.buf) == cap(c.buf) {
+			c.realloc()
+		}
+		elem = uint32(len(c.buf))
+		c.buf = append(c.buf, s3Entry{})
+	} else {
+		elem = c.evict()
+	}
+	c.buf[elem] = s3Entry{key: key, value: value}
+	c.elements[id] = elem
+	_, ghostHit := c.ghost.remove(key)
+	c.push(elem, ghostHit)
+}
+
+// Get returns the Value associated with key, and a boolean as to whether it
+// actually exists in the cache. If it does exist in the cache, then it counts
+// as a use of it.
+func (c *S3FIFOCache) Get(key interface{}) (interface{}, bool) {
+	if elem, exists := c.elements[mapKey(key)]; exists {
+		entry := &c.buf[elem]
+		entry.used()
+		return entry.value, true
+	}
+	return nil, false
+}
+
+// Peek is just like Get() except it doesn't count as a use.
+func (c *S3FIFOCache) Peek(key interface{}) (interface{}, bool) {
+	if elem, exists := c.elements[mapKey(key)]; exists {
+		return c.buf[elem].value, true
+	}
+	return nil, false
+}
+
+func (entry *s3Entry) used() {
+	if entry.freq < s3MaxFreq {
+		entry.freq++
+	}
+}
+
+// evict removes an entry from the cache to make room for a new one, and
+// returns the element it was in. Entries that have been used are moved along
+// rather than evicted, until one is found that hasn't.
+func (c *S3FIFOCache) evict() uint32 {
+	for {
+		if c.smallLen > 0 && (c.smallLen >= c.smallSize || c.mainLen == 0) {
+			elem := c.buf[s3Small].prev
+			entry := &c.buf[elem]
+			c.unlink(elem)
+			if entry.freq > 0 {
+				entry.freq = 0
+				c.push(elem, true)
+				continue
+			}
+			c.ghost.Add(entry.key, nil)
+			delete(c.elements, mapKey(entry.key))
+			return elem
+		}
+		elem := c.buf[s3Main].prev
+		entry := &c.buf[elem]
+		c.unlink(elem)
+		if entry.freq > 0 {
+			entry.freq--
+			c.push(elem, true)
+			continue
+		}
+		delete(c.elements, mapKey(entry.key))
+		return elem
+	}
+}
+
+// push adds elem to the start of the main queue if main is true, and of the
+// small queue otherwise.
+func (c *S3FIFOCache) push(elem uint32, main bool) {
+	root := uint32(s3Small)
+	if main {
+		root = s3Main
+		c.mainLen++
+	} else {
+		c.smallLen++
+	}
+	entry := &c.buf[elem]
+	entry.main = main
+	entry.prev = root
+	entry.next = c.buf[root].next
+	c.buf[entry.next].prev = elem
+	c.buf[root].next = elem
+}
+
+// unlink removes elem from the queue it is in.
+func (c *S3FIFOCache) unlink(elem uint32) {
+	entry := &c.buf[elem]
+	c.buf[entry.prev].next = entry.next
+	c.buf[entry.next].prev = entry.prev
+	if entry.main {
+		c.mainLen--
+	} else {
+		c.smallLen--
+	}
+}
+
+func (c *S3FIFOCache) realloc() {
+	// The first two slots hold the roots of the queues.
+	nextSize := (cap(c.buf) - 2) * 2
+	if nextSize > c.maxSize {
+		nextSize = c.maxSize
+	}
+	newBuf := make([]s3Entry, len(c.buf), nextSize+2)
+	copy(newBuf, c.buf)
+	c.buf = newBuf
+}
@@ -0,0 +1,116 @@
+// Copyright 2026 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package lru_test
+
+import (
+	"math/rand"
+
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/lru"
+)
+
+type S3FIFOSuite struct{}
+
+var _ = gc.Suite(&S3FIFOSuite{})
+
+func (*S3FIFOSuite) TestAddGet(c *gc.C) {
+	cache := lru.NewS3FIFO(10)
+	cache.Add("a", 1)
+	cache.Add("b", 2)
+	value, ok := cache.Get("a")
+	c.Check(ok, gc.Equals, true)
+	c.Check(value, gc.Equals, 1)
+	cache.Add("b", 3)
+	value, ok = cache.Peek("b")
+	c.Check(ok, gc.Equals, true)
+	c.Check(value, gc.Equals, 3)
+	_, ok = cache.Get("c")
+	c.Check(ok, gc.Equals, false)
+	c.Check(cache.Len(), gc.Equals, 2)
+}
+
+func (*S3FIFOSuite) TestMaxSize(c *gc.C) {
+	cache := lru.NewS3FIFO(150)
+	for i := 0; i < 1000; i++ {
+		cache.Add(i, i)
+		if i%3 == 0 {
+			cache.Get(i - 20)
+		}
+		c.Assert(cache.Len() <= 150, gc.Equals, true)
+		checkS3FIFOExists(c, cache, i)
+	}
+	c.Check(cache.Len(), gc.Equals, 150)
+}
+
+func (*S3FIFOSuite) TestOneHitWondersEvictedFirst(c *gc.C) {
+	cache := lru.NewS3FIFOWithRatio(10, 0.2)
+	for i := 0; i < 10; i++ {
+		cache.Add(i, i)
+	}
+	cache.Get(0)
+	cache.Get(1)
+	// 0 and 1 have been used, so they are moved into the main queue, and 2,
+	// the oldest that hasn't, is evicted.
+	cache.Add(10, 10)
+	_, ok := cache.Peek(2)
+	c.Check(ok, gc.Equals, false)
+	// A stream of new keys only ever evicts from the small queue once it
+	// is over its share.
+	for i := 100; i < 200; i++ {
+		cache.Add(i, i)
+	}
+	checkS3FIFOExists(c, cache, 0)
+	checkS3FIFOExists(c, cache, 1)
+}
+
+func (*S3FIFOSuite) TestGhostHit(c *gc.C) {
+	cache := lru.NewS3FIFOWithRatio(4, 0.5)
+	for i := 0; i < 4; i++ {
+		cache.Add(i, i)
+	}
+	// 0 is evicted from the small queue, and remembered.
+	cache.Add(4, 4)
+	_, ok := cache.Peek(0)
+	c.Assert(ok, gc.Equals, false)
+	// Added again, it goes into the main queue, so it outlasts a run of new
+	// keys.
+	cache.Add(0, 0)
+	for i := 5; i < 8; i++ {
+		cache.Add(i, i)
+	}
+	checkS3FIFOExists(c, cache, 0)
+}
+
+func (*S3FIFOSuite) TestHitRate(c *gc.C) {
+	// A small set of hot keys mixed in with a lot of keys that are only
+	// used once, which S3-FIFO handles better than LRU.
+	r := rand.New(rand.NewSource(1))
+	s3 := lru.NewS3FIFO(100)
+	plain := lru.New(100)
+	var s3Hits, lruHits int
+	for i := 0; i < 20000; i++ {
+		key := 1000000 + i
+		if r.Intn(2) == 0 {
+			key = r.Intn(80)
+		}
+		if _, ok := s3.Get(key); ok {
+			s3Hits++
+		} else {
+			s3.Add(key, key)
+		}
+		if _, ok := plain.Get(key); ok {
+			lruHits++
+		} else {
+			plain.Add(key, key)
+		}
+	}
+	c.Check(s3Hits > lruHits, gc.Equals, true, gc.Commentf("S3-FIFO %d, LRU %d", s3Hits, lruHits))
+}
+
+func checkS3FIFOExists(c *gc.C, cache *lru.S3FIFOCache, key int) {
+	value, ok := cache.Peek(key)
+	c.Check(ok, gc.Equals, true, gc.Commentf("key %d did not exist in cache", key))
+	c.Check(value, gc.Equals, key)
+}
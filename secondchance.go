@@ -0,0 +1,124 @@
+// Copyright 2026 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package lru
+
+// SecondChanceCache is a cache using the CLOCK (or second chance) algorithm,
+// an approximation of LRU. Rather than keeping entries in order of use, each
+// entry has a reference bit that is set when it is used. When the cache is
+// full, a "hand" sweeps around the entries, clearing reference bits as it
+// goes, and evicts the first entry whose bit was already clear. An entry that
+// has been used since the hand last passed it therefore survives for another
+// sweep.
+// Marking an entry as used is a single write, rather than moving it to the
+// front of a list, which makes hits cheaper and leaves room for a future
+// concurrent version where hits don't need an exclusive lock.
+// Note that SecondChanceCache is *not* thread safe, some form of mutex is
+// necessary if you want to access it from multiple threads.
+type SecondChanceCache struct {
+	maxSize  int
+	buf      []clockEntry
+	elements map[interface{}]uint32
+	// hand is the next entry to consider for eviction.
+	hand uint32
+}
+
+type clockEntry struct {
+	referenced bool
+	key        interface{}
+	value      interface{}
+}
+
+// NewSecondChance creates a SecondChanceCache that will hold no more than the
+// given number of items.
+func NewSecondChance(size int) *SecondChanceCache {
+	if size > maxLRUSize || size <= 0 {
+		panic("size must not be <= 0 or >= 2^32")
+	}
+	initialBufSize := size
+	if initialBufSize > 100 {
+		initialBufSize = 100
+	}
+	return &SecondChanceCache{
+		maxSize:  size,
+		buf:      make([]clockEntry, 0, initialBufSize),
+		elements: make(map[interface{}]uint32, initialBufSize),
+	}
+}
+
+// Len gives the number of items in the cache
+func (c *SecondChanceCache) Len() int {
+	return len(c.buf)
+}
+
+// Add a new entry into the cache
+func (c *SecondChanceCache) Add(key, value interface{}) {
+	id := mapKey(key)
+	if elem, exists := c.elements[id]; exists {
+		entry := &c.buf[elem]
+		entry.value = value
+		entry.referenced = true
+		return
+	}
+	if len(c.buf) < c.maxSize {
+		if len(c.buf) == cap(c.buf) {
+			c.realloc()
+		}
+		c.elements[id] = uint32(len(c.buf))
+		c.buf = append(c.buf, clockEntry{key: key, value: value})
+		return
+	}
+	elem := c.victim()
+	entry := &c.buf[elem]
+	delete(c.elements, mapKey(entry.key))
+	*entry = clockEntry{key: key, value: value}
+	c.elements[id] = elem
+}
+
+// victim sweeps the hand around until it finds an entry that has not been
+// used since it was last passed, and returns it. The hand is left pointing
+// past it.
+func (c *SecondChanceCache) victim() uint32 {
+	for {
+		elem := c.hand
+		c.hand++
+		if int(c.hand) == len(c.buf) {
+			c.hand = 0
+		}
+		entry := &c.buf[elem]
+		if !entry.referenced {
+			return elem
+		}
+		entry.referenced = false
+	}
+}
+
+// Get returns the Value associated with key, and a boolean as to whether it
+// actually exists in the cache. If it does exist in the cache, then it is
+// marked as recently used.
+func (c *SecondChanceCache) Get(key interface{}) (interface{}, bool) {
+	if elem, exists := c.elements[mapKey(key)]; exists {
+		entry := &c.buf[elem]
+		entry.referenced = true
+		return entry.value, true
+	}
+	return nil, false
+}
+
+// Peek is just like Get() except it doesn't affect if it was 'recently accessed'
+func (c *SecondChanceCache) Peek(key interface{}) (interface{}, bool) {
+	if elem, exists := c.elements[mapKey(key)]; exists {
+		return c.buf[elem].value, true
+	}
+	return nil, false
+}
+
+func (c *SecondChanceCache) realloc() {
+	nextSize := cap(c.buf) * 2
+	if nextSize > c.maxSize {
+		nextSize = c.maxSize
+	}
+	newBuf := make([]clockEntry, len(c.buf), nextSize)
+	copy(newBuf, c.buf)
+	c.buf = newBuf
+}
@@ -0,0 +1,82 @@
+// Copyright 2026 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package lru_test
+
+import (
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/lru"
+)
+
+type SecondChanceSuite struct{}
+
+var _ = gc.Suite(&SecondChanceSuite{})
+
+func (*SecondChanceSuite) TestAddGet(c *gc.C) {
+	cache := lru.NewSecondChance(10)
+	cache.Add("a", 1)
+	cache.Add("b", 2)
+	value, ok := cache.Get("a")
+	c.Check(ok, gc.Equals, true)
+	c.Check(value, gc.Equals, 1)
+	cache.Add("b", 3)
+	value, ok = cache.Peek("b")
+	c.Check(ok, gc.Equals, true)
+	c.Check(value, gc.Equals, 3)
+	_, ok = cache.Get("c")
+	c.Check(ok, gc.Equals, false)
+	c.Check(cache.Len(), gc.Equals, 2)
+}
+
+func (*SecondChanceSuite) TestMaxSize(c *gc.C) {
+	cache := lru.NewSecondChance(150)
+	for i := 0; i < 1000; i++ {
+		cache.Add(i, i)
+		if i%3 == 0 {
+			cache.Get(i)
+		}
+		c.Assert(cache.Len() <= 150, gc.Equals, true)
+	}
+	c.Check(cache.Len(), gc.Equals, 150)
+}
+
+func (*SecondChanceSuite) TestSecondChance(c *gc.C) {
+	cache := lru.NewSecondChance(4)
+	for i := 0; i < 4; i++ {
+		cache.Add(i, i)
+	}
+	cache.Get(0)
+	cache.Get(2)
+	// The hand skips over 0, clearing its bit, and evicts 1.
+	cache.Add(4, 4)
+	_, ok := cache.Peek(1)
+	c.Check(ok, gc.Equals, false)
+	// Then 2 is skipped, and 3 evicted.
+	cache.Add(5, 5)
+	_, ok = cache.Peek(3)
+	c.Check(ok, gc.Equals, false)
+	// The hand comes back round to 0, which hasn't been used since it was
+	// passed.
+	cache.Add(6, 6)
+	_, ok = cache.Peek(0)
+	c.Check(ok, gc.Equals, false)
+	for _, key := range []int{2, 4, 5, 6} {
+		_, ok := cache.Peek(key)
+		c.Check(ok, gc.Equals, true, gc.Commentf("missing %d", key))
+	}
+}
+
+func (*SecondChanceSuite) TestAllReferenced(c *gc.C) {
+	cache := lru.NewSecondChance(3)
+	for i := 0; i < 3; i++ {
+		cache.Add(i, i)
+		cache.Get(i)
+	}
+	// Every bit is set, so the hand goes all the way round and evicts the
+	// first entry.
+	cache.Add(3, 3)
+	_, ok := cache.Peek(0)
+	c.Check(ok, gc.Equals, false)
+	c.Check(cache.Len(), gc.Equals, 3)
+}
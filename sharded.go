@@ -0,0 +1,347 @@
+// Copyright 2026 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package lru
+
+import (
+	"context"
+	"hash/maphash"
+	"time"
+)
+
+// ShardedLRU is a cache for concurrent use that spreads its keys across a
+// number of independent SyncLRUs, each with its own lock, so that goroutines
+// working on different keys rarely contend with each other. Recency is only
+// tracked within a shard, so the entry evicted is the least recently used in
+// its shard, which is not necessarily the least recently used overall.
+type ShardedLRU struct {
+	seed   maphash.Seed
+	shards []*lruShard
+}
+
+type lruShard struct {
+	SyncLRU
+}
+
+// NewSharded creates a ShardedLRU with the given number of shards, holding no
+// more than size items in total. Each shard is configured with the options
+// given, see New.
+func NewSharded(shards, size int, options ...Option) *ShardedLRU {
+	if shards <= 0 {
+		panic("shards must be > 0")
+	}
+	if size < shards {
+		panic("size must be >= shards")
+	}
+	s := &ShardedLRU{
+		seed:   maphash.MakeSeed(),
+		shards: make([]*lruShard, shards),
+	}
+	for i := range s.shards {
+		// Spread any remainder over the first shards.
+		shardSize := size / shards
+		if i < size%shards {
+			shardSize++
+		}
+		s.shards[i] = &lruShard{}
+		s.shards[i].init(New(shardSize, options...))
+	}
+	return s
+}
+
+// shardFor returns the shard that holds key.
+func (s *ShardedLRU) shardFor(key interface{}) *lruShard {
+	if len(s.shards) == 1 {
+		return s.shards[0]
+	}
+	// All shards share the same options, so any of them can tell us how the
+	// key will be identified.
+	k := s.shards[0].lru.identity(key)
+	return s.shards[hashKey(s.seed, k)%uint64(len(s.shards))]
+}
+
+// Len gives the total number of items in all shards.
+func (s *ShardedLRU) Len() int {
+	total := 0
+	for _, shard := range s.shards {
+		total += shard.Len()
+	}
+	return total
+}
+
+// Compact reallocates the buffers of every shard to fit the entries it holds.
+// See LRU.Compact.
+func (s *ShardedLRU) Compact() {
+	for _, shard := range s.shards {
+		shard.Compact()
+	}
+}
+
+// SizeBytes returns an estimate of the memory used by all the shards. See
+// LRU.SizeBytes.
+func (s *ShardedLRU) SizeBytes() int {
+	total := 0
+	for _, shard := range s.shards {
+		total += shard.SizeBytes()
+	}
+	return total
+}
+
+// HitCounts gives information about calls to Get, summed across all shards.
+func (s *ShardedLRU) HitCounts() HitCounts {
+	var counts HitCounts
+	for _, shard := range s.shards {
+		shardCounts := shard.HitCounts()
+		counts.Hit += shardCounts.Hit
+		counts.Miss += shardCounts.Miss
+	}
+	return counts
+}
+
+// Stats returns the totals of the statistics for all the shards. See
+// LRU.Stats.
+func (s *ShardedLRU) Stats() Stats {
+	return s.sumStats((*lruShard).Stats)
+}
+
+// sumStats adds up the statistics returned by get for each shard.
+func (s *ShardedLRU) sumStats(get func(*lruShard) Stats) Stats {
+	var total Stats
+	for _, shard := range s.shards {
+		stats := get(shard)
+		total.Len += stats.Len
+		total.Cap += stats.Cap
+		total.Hits += stats.Hits
+		total.Misses += stats.Misses
+		total.Evictions += stats.Evictions
+		total.Expirations += stats.Expirations
+		total.Replacements += stats.Replacements
+	}
+	return total
+}
+
+// ResetStats returns the totals of the statistics for all the shards, and
+// resets their counters. Each shard is reset in turn. See LRU.ResetStats.
+func (s *ShardedLRU) ResetStats() Stats {
+	return s.sumStats((*lruShard).ResetStats)
+}
+
+// EvictionAges returns how old the entries evicted from all the shards have
+// been. See LRU.EvictionAges.
+func (s *ShardedLRU) EvictionAges() EvictionAges {
+	var total EvictionAges
+	for _, shard := range s.shards {
+		ages := shard.EvictionAges()
+		total.Lifetime.merge(ages.Lifetime)
+		total.Idle.merge(ages.Idle)
+	}
+	return total
+}
+
+// EntryAges returns how old the entries in all the shards are. See
+// LRU.EntryAges.
+func (s *ShardedLRU) EntryAges() EntryAges {
+	var total EntryAges
+	for _, shard := range s.shards {
+		ages := shard.EntryAges()
+		total.Age.merge(ages.Age)
+		total.Idle.merge(ages.Idle)
+	}
+	return total
+}
+
+// EstimatedHitCounts estimates what HitCounts would be if each shard were
+// larger, summed across all shards. See LRU.EstimatedHitCounts.
+func (s *ShardedLRU) EstimatedHitCounts() HitCounts {
+	var counts HitCounts
+	for _, shard := range s.shards {
+		shardCounts := shard.EstimatedHitCounts()
+		counts.Hit += shardCounts.Hit
+		counts.Miss += shardCounts.Miss
+	}
+	return counts
+}
+
+// ShardStats describes a single shard of a ShardedLRU, so that skew in how
+// keys are distributed can be spotted.
+type ShardStats struct {
+	// Len is the number of items in the shard.
+	Len int
+	// Hits and Misses count calls to Get on keys in this shard.
+	Hits, Misses int64
+	// Evictions counts items dropped to make room for new ones.
+	Evictions int64
+}
+
+// ShardStats returns the statistics for each shard, in shard order.
+func (s *ShardedLRU) ShardStats() []ShardStats {
+	stats := make([]ShardStats, len(s.shards))
+	for i, shard := range s.shards {
+		shard.mu.RLock()
+		stats[i].Len = shard.lru.Len()
+		stats[i].Evictions = shard.lru.evictions
+		shard.mu.RUnlock()
+		counts := shard.HitCounts()
+		stats[i].Hits = counts.Hit
+		stats[i].Misses = counts.Miss
+	}
+	return stats
+}
+
+// Add a new entry into the cache
+func (s *ShardedLRU) Add(key, value interface{}) {
+	s.shardFor(key).Add(key, value)
+}
+
+// TryAdd is like Add, but returns whether the key is now in the cache. See
+// LRU.TryAdd.
+func (s *ShardedLRU) TryAdd(key, value interface{}) bool {
+	return s.shardFor(key).TryAdd(key, value)
+}
+
+// AddWithPriority adds a new entry into the cache with the given priority.
+// See LRU.AddWithPriority.
+func (s *ShardedLRU) AddWithPriority(key, value interface{}, priority Priority) {
+	s.shardFor(key).AddWithPriority(key, value, priority)
+}
+
+// Update is LRU.Update, with fn called while the key's shard is locked.
+func (s *ShardedLRU) Update(key interface{}, fn func(old interface{}, exists bool) (new interface{}, write bool)) {
+	s.shardFor(key).Update(key, fn)
+}
+
+// Get returns the Value associated with key, and a boolean as to whether it
+// actually exists in the cache. See LRU.Get.
+func (s *ShardedLRU) Get(key interface{}) (interface{}, bool) {
+	return s.shardFor(key).Get(key)
+}
+
+// GetWait is like Get, but if key is not in the cache, it waits until another
+// goroutine adds it, or until ctx is done. See SyncLRU.GetWait.
+func (s *ShardedLRU) GetWait(ctx context.Context, key interface{}) (interface{}, error) {
+	return s.shardFor(key).GetWait(ctx, key)
+}
+
+// Peek is just like Get() except it doesn't affect if it was 'recently accessed'
+func (s *ShardedLRU) Peek(key interface{}) (interface{}, bool) {
+	return s.shardFor(key).Peek(key)
+}
+
+// PeekStale is like Peek, but also returns expired entries. See
+// LRU.PeekStale.
+func (s *ShardedLRU) PeekStale(key interface{}) (value interface{}, expired bool, ok bool) {
+	return s.shardFor(key).PeekStale(key)
+}
+
+// AgeOf returns how long ago the value for key was written. See LRU.AgeOf.
+func (s *ShardedLRU) AgeOf(key interface{}) (time.Duration, bool) {
+	return s.shardFor(key).AgeOf(key)
+}
+
+// LastAccessed returns when key was last written or returned by Get. See
+// SyncLRU.LastAccessed.
+func (s *ShardedLRU) LastAccessed(key interface{}) (time.Time, bool) {
+	return s.shardFor(key).LastAccessed(key)
+}
+
+// SetTTL changes when key expires to ttl from now. See LRU.SetTTL.
+func (s *ShardedLRU) SetTTL(key interface{}, ttl time.Duration) bool {
+	return s.shardFor(key).SetTTL(key, ttl)
+}
+
+// Extend pushes back when key expires by d. See LRU.Extend.
+func (s *ShardedLRU) Extend(key interface{}, d time.Duration) bool {
+	return s.shardFor(key).Extend(key, d)
+}
+
+// SetRecomputeTime records how long it takes to compute the value for key.
+// See LRU.SetRecomputeTime.
+func (s *ShardedLRU) SetRecomputeTime(key interface{}, d time.Duration) bool {
+	return s.shardFor(key).SetRecomputeTime(key, d)
+}
+
+// Do calls fn with the LRU of the shard that holds key, while holding that
+// shard's lock. fn may only operate on keys that belong to the same shard as
+// key, which is only guaranteed for key itself. See SyncLRU.Do.
+func (s *ShardedLRU) Do(key interface{}, fn func(*LRU)) {
+	s.shardFor(key).Do(fn)
+}
+
+// StartReaper starts a reaper for every shard. See SyncLRU.StartReaper.
+func (s *ShardedLRU) StartReaper(interval time.Duration) {
+	for _, shard := range s.shards {
+		shard.StartReaper(interval)
+	}
+}
+
+// Close stops the reapers started by StartReaper.
+func (s *ShardedLRU) Close() {
+	for _, shard := range s.shards {
+		shard.Close()
+	}
+}
+
+// RemoveIf removes every entry for which fn returns true, and returns how many
+// entries were removed. Each shard is locked in turn, so the removal is not
+// atomic across the whole cache.
+func (s *ShardedLRU) RemoveIf(fn func(key, value interface{}) bool) int {
+	removed := 0
+	for _, shard := range s.shards {
+		removed += shard.RemoveIf(fn)
+	}
+	return removed
+}
+
+// TrimTo evicts entries until no more than n remain, and returns how many were
+// evicted. n is split between the shards in the same way as the size is by
+// NewSharded, and each shard is trimmed to its share, so fewer than n entries
+// may remain if the keys are not spread evenly. See LRU.TrimTo.
+func (s *ShardedLRU) TrimTo(n int) int {
+	if n < 0 {
+		panic("n must be >= 0")
+	}
+	removed := 0
+	for i, shard := range s.shards {
+		shardN := n / len(s.shards)
+		if i < n%len(s.shards) {
+			shardN++
+		}
+		removed += shard.TrimTo(shardN)
+	}
+	return removed
+}
+
+// DrainEvicted returns the entries that have left any of the shards since it
+// was last called, and forgets them. See LRU.DrainEvicted.
+func (s *ShardedLRU) DrainEvicted() []Entry {
+	var evicted []Entry
+	for _, shard := range s.shards {
+		evicted = append(evicted, shard.DrainEvicted()...)
+	}
+	return evicted
+}
+
+// Watch returns a channel that is sent an event whenever key is added to the
+// cache, its value is replaced, or it leaves the cache, and a function to stop
+// watching. See LRU.Watch.
+func (s *ShardedLRU) Watch(key interface{}) (<-chan WatchEvent, func()) {
+	return s.shardFor(key).Watch(key)
+}
+
+// Purge removes every entry from every shard. Each shard is locked in turn, so
+// entries may be added to one shard while another is being purged.
+func (s *ShardedLRU) Purge() {
+	for _, shard := range s.shards {
+		shard.Purge()
+	}
+}
+
+// EvictOlderThan removes every entry that was last written or returned by Get
+// before t, and returns how many were removed. See LRU.EvictOlderThan.
+func (s *ShardedLRU) EvictOlderThan(t time.Time) int {
+	removed := 0
+	for _, shard := range s.shards {
+		removed += shard.EvictOlderThan(t)
+	}
+	return removed
+}
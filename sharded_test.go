@@ -0,0 +1,218 @@
+// Copyright 2026 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package lru_test
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"testing"
+
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/lru"
+)
+
+type ShardedLRUSuite struct{}
+
+var _ = gc.Suite(&ShardedLRUSuite{})
+
+func (*ShardedLRUSuite) TestBasics(c *gc.C) {
+	cache := lru.NewSharded(4, 100)
+	for i := 0; i < 50; i++ {
+		cache.Add(i, fmt.Sprint(i))
+	}
+	c.Check(cache.Len(), gc.Equals, 50)
+	for i := 0; i < 50; i++ {
+		v, ok := cache.Get(i)
+		c.Check(ok, gc.Equals, true)
+		c.Check(v, gc.Equals, fmt.Sprint(i))
+	}
+	_, ok := cache.Get("missing")
+	c.Check(ok, gc.Equals, false)
+	c.Check(cache.HitCounts(), gc.Equals, lru.HitCounts{Hit: 50, Miss: 1})
+	removed := cache.RemoveIf(func(key, value interface{}) bool {
+		return key.(int) < 10
+	})
+	c.Check(removed, gc.Equals, 10)
+	c.Check(cache.Len(), gc.Equals, 40)
+}
+
+func (*ShardedLRUSuite) TestCapacity(c *gc.C) {
+	cache := lru.NewSharded(3, 10)
+	for i := 0; i < 1000; i++ {
+		cache.Add(fmt.Sprint(i), i)
+	}
+	// Each shard is full, and the shard sizes add up to the total
+	c.Check(cache.Len(), gc.Equals, 10)
+}
+
+func (*ShardedLRUSuite) TestNormalizedKeysShareAShard(c *gc.C) {
+	cache := lru.NewSharded(16, 100, lru.WithNormalizer(lowerKey))
+	for i := 0; i < 20; i++ {
+		cache.Add(fmt.Sprintf("KEY%d", i), i)
+	}
+	for i := 0; i < 20; i++ {
+		v, ok := cache.Peek(fmt.Sprintf("key%d", i))
+		c.Check(ok, gc.Equals, true)
+		c.Check(v, gc.Equals, i)
+	}
+}
+
+type shardNode struct {
+	id int
+}
+
+func (*ShardedLRUSuite) TestPointerKeysStayInTheirShard(c *gc.C) {
+	cache := lru.NewSharded(16, 100)
+	nodes := make([]*shardNode, 20)
+	for i := range nodes {
+		nodes[i] = &shardNode{id: i}
+		cache.Add(nodes[i], i)
+	}
+	// A pointer key is the same key whatever it points to.
+	for i, node := range nodes {
+		node.id += 100
+		v, ok := cache.Get(node)
+		c.Check(ok, gc.Equals, true)
+		c.Check(v, gc.Equals, i)
+	}
+	_, ok := cache.Get(&shardNode{id: 100})
+	c.Check(ok, gc.Equals, false)
+}
+
+type shardKey struct {
+	name  string
+	n     int
+	f     float64
+	inner interface{}
+}
+
+func (*ShardedLRUSuite) TestStructKeys(c *gc.C) {
+	cache := lru.NewSharded(16, 100)
+	for i := 0; i < 20; i++ {
+		cache.Add(shardKey{name: fmt.Sprint(i), n: i, inner: [2]int{i, i}}, i)
+	}
+	for i := 0; i < 20; i++ {
+		v, ok := cache.Get(shardKey{name: fmt.Sprint(i), n: i, inner: [2]int{i, i}})
+		c.Check(ok, gc.Equals, true)
+		c.Check(v, gc.Equals, i)
+	}
+	// -0 == 0, so they must be the same key.
+	negZero := math.Copysign(0, -1)
+	cache.Add(shardKey{f: negZero}, "zero")
+	v, ok := cache.Get(shardKey{})
+	c.Check(ok, gc.Equals, true)
+	c.Check(v, gc.Equals, "zero")
+}
+
+func (*ShardedLRUSuite) TestStructKeysDoNotAllocate(c *gc.C) {
+	cache := lru.NewSharded(16, 100)
+	var key interface{} = shardKey{name: "a", n: 1}
+	cache.Add(key, 1)
+	allocs := testing.AllocsPerRun(100, func() {
+		cache.Get(key)
+	})
+	c.Check(allocs, gc.Equals, float64(0))
+}
+
+func (*ShardedLRUSuite) TestConcurrentAccess(c *gc.C) {
+	cache := lru.NewSharded(8, 1000)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 2000; j++ {
+				cache.Add(j%1500, i)
+				cache.Get((j * 7) % 1500)
+				cache.Update("shared", func(old interface{}, exists bool) (interface{}, bool) {
+					return j, true
+				})
+			}
+		}(i)
+	}
+	wg.Wait()
+	counts := cache.HitCounts()
+	c.Check(counts.Hit+counts.Miss, gc.Equals, int64(8*2000))
+}
+
+func (*ShardedLRUSuite) TestDo(c *gc.C) {
+	cache := lru.NewSharded(4, 100)
+	cache.Add("key", 1)
+	cache.Do("key", func(l *lru.LRU) {
+		v, ok := l.Get("key")
+		c.Check(ok, gc.Equals, true)
+		l.Add("key", v.(int)+1)
+	})
+	v, ok := cache.Peek("key")
+	c.Check(ok, gc.Equals, true)
+	c.Check(v, gc.Equals, 2)
+}
+
+func (*ShardedLRUSuite) TestShardStats(c *gc.C) {
+	cache := lru.NewSharded(4, 40)
+	for i := 0; i < 400; i++ {
+		cache.Add(i, i)
+	}
+	for i := 0; i < 400; i++ {
+		cache.Get(i)
+	}
+	stats := cache.ShardStats()
+	c.Assert(stats, gc.HasLen, 4)
+	var total lru.ShardStats
+	for _, shard := range stats {
+		c.Check(shard.Len, gc.Equals, 10)
+		total.Len += shard.Len
+		total.Hits += shard.Hits
+		total.Misses += shard.Misses
+		total.Evictions += shard.Evictions
+	}
+	c.Check(total, gc.Equals, lru.ShardStats{
+		Len:       40,
+		Hits:      40,
+		Misses:    360,
+		Evictions: 360,
+	})
+}
+
+func (*ShardedLRUSuite) TestTrimTo(c *gc.C) {
+	cache := lru.NewSharded(4, 40)
+	for i := 0; i < 400; i++ {
+		cache.Add(i, i)
+	}
+	c.Check(cache.TrimTo(20), gc.Equals, 20)
+	c.Check(cache.Len(), gc.Equals, 20)
+	for _, shard := range cache.ShardStats() {
+		c.Check(shard.Len, gc.Equals, 5)
+	}
+}
+
+func (*ShardedLRUSuite) TestStats(c *gc.C) {
+	cache := lru.NewSharded(4, 40)
+	for i := 0; i < 50; i++ {
+		cache.Add(i, i)
+	}
+	cache.Add(49, 49)
+	cache.Get(49)
+	stats := cache.Stats()
+	c.Check(stats, gc.Equals, lru.Stats{
+		Len:          stats.Len,
+		Cap:          40,
+		Hits:         1,
+		Evictions:    int64(50 - stats.Len),
+		Replacements: 1,
+	})
+	c.Check(stats.Len <= 40, gc.Equals, true)
+}
+
+func (*ShardedLRUSuite) TestResetStats(c *gc.C) {
+	cache := lru.NewSharded(4, 40)
+	for i := 0; i < 10; i++ {
+		cache.Add(i, i)
+		cache.Get(i)
+	}
+	c.Check(cache.ResetStats().Hits, gc.Equals, int64(10))
+	c.Check(cache.Stats().Hits, gc.Equals, int64(0))
+}
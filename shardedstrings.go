@@ -0,0 +1,220 @@
+// Copyright 2026 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package lru
+
+import (
+	"hash/maphash"
+	"sync"
+)
+
+// ShardedStringCache is a string interner for concurrent use. Strings are
+// hashed to one of a number of independent StringCaches, each with its own
+// lock and its own share of the total size, so goroutines interning different
+// strings rarely contend with each other.
+type ShardedStringCache struct {
+	seed   maphash.Seed
+	shards []stringShard
+}
+
+type stringShard struct {
+	mu    sync.Mutex
+	cache *StringCache
+}
+
+// NewShardedStringCache creates a ShardedStringCache with the given number of
+// shards, that will hold no more than 'size' strings in total, with each
+// shard configured by any options given.
+func NewShardedStringCache(shards, size int, options ...StringOption) *ShardedStringCache {
+	if shards <= 0 {
+		panic("shards must be > 0")
+	}
+	if size < shards {
+		panic("size must be >= shards")
+	}
+	sc := &ShardedStringCache{
+		seed:   maphash.MakeSeed(),
+		shards: make([]stringShard, shards),
+	}
+	for i := range sc.shards {
+		// Spread any remainder over the first shards.
+		shardSize := size / shards
+		if i < size%shards {
+			shardSize++
+		}
+		sc.shards[i].cache = NewStringCache(shardSize, options...)
+	}
+	return sc
+}
+
+// SetMaxSize changes how many strings the cache can hold in total, sharing
+// them between the shards as NewShardedStringCache does. See
+// StringCache.SetMaxSize.
+func (sc *ShardedStringCache) SetMaxSize(size int) {
+	shards := len(sc.shards)
+	if size < shards {
+		panic("size must be >= shards")
+	}
+	for i := range sc.shards {
+		shardSize := size / shards
+		if i < size%shards {
+			shardSize++
+		}
+		shard := &sc.shards[i]
+		shard.mu.Lock()
+		shard.cache.SetMaxSize(shardSize)
+		shard.mu.Unlock()
+	}
+}
+
+func (sc *ShardedStringCache) shardFor(v string) *stringShard {
+	if len(sc.shards) == 1 {
+		return &sc.shards[0]
+	}
+	return &sc.shards[hashKey(sc.seed, v)%uint64(len(sc.shards))]
+}
+
+// Intern returns either the cached copy of the string, or caches the string
+// and returns it back. See StringCache.Intern.
+func (sc *ShardedStringCache) Intern(v string) string {
+	shard := sc.shardFor(v)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+	return shard.cache.Intern(v)
+}
+
+// InternBytes is just like Intern, except it takes the string as a byte
+// slice. See StringCache.InternBytes.
+func (sc *ShardedStringCache) InternBytes(b []byte) string {
+	shard := &sc.shards[0]
+	if len(sc.shards) > 1 {
+		// Hash the bytes just as hashKey hashes a string, so that they
+		// go to the same shard.
+		var h maphash.Hash
+		h.SetSeed(sc.seed)
+		h.Write(b)
+		shard = &sc.shards[h.Sum64()%uint64(len(sc.shards))]
+	}
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+	return shard.cache.InternBytes(b)
+}
+
+// Contains returns true if the string is in the cache. It does not change
+// information about recently-used.
+func (sc *ShardedStringCache) Contains(v string) bool {
+	shard := sc.shardFor(v)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+	return shard.cache.Contains(v)
+}
+
+// Remove removes v from the cache, and returns whether it was there.
+func (sc *ShardedStringCache) Remove(v string) bool {
+	shard := sc.shardFor(v)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+	return shard.cache.Remove(v)
+}
+
+// Clear removes every string from each shard. See StringCache.Clear.
+func (sc *ShardedStringCache) Clear() {
+	for i := range sc.shards {
+		shard := &sc.shards[i]
+		shard.mu.Lock()
+		shard.cache.Clear()
+		shard.mu.Unlock()
+	}
+}
+
+// Len returns how many strings are currently cached across all shards.
+func (sc *ShardedStringCache) Len() int {
+	total := 0
+	for i := range sc.shards {
+		shard := &sc.shards[i]
+		shard.mu.Lock()
+		total += shard.cache.Len()
+		shard.mu.Unlock()
+	}
+	return total
+}
+
+// SizeBytes returns an estimate of the memory used by all the shards. See
+// StringCache.SizeBytes.
+func (sc *ShardedStringCache) SizeBytes() int {
+	total := 0
+	for i := range sc.shards {
+		shard := &sc.shards[i]
+		shard.mu.Lock()
+		total += shard.cache.SizeBytes()
+		shard.mu.Unlock()
+	}
+	return total
+}
+
+// TotalBytes returns the total length of the strings in all the shards, plus
+// an estimate of the overhead of each. See StringCache.TotalBytes.
+func (sc *ShardedStringCache) TotalBytes() int64 {
+	var total int64
+	for i := range sc.shards {
+		shard := &sc.shards[i]
+		shard.mu.Lock()
+		total += shard.cache.TotalBytes()
+		shard.mu.Unlock()
+	}
+	return total
+}
+
+// HitCounts gives information about accesses to the cache, summed across all
+// shards.
+func (sc *ShardedStringCache) HitCounts() HitCounts {
+	var counts HitCounts
+	for i := range sc.shards {
+		shard := &sc.shards[i]
+		shardCounts := shard.cache.HitCounts()
+		counts.Hit += shardCounts.Hit
+		counts.Miss += shardCounts.Miss
+	}
+	return counts
+}
+
+// ResetHitCounts returns the counts summed across all shards, and sets them
+// back to zero. Each shard is reset in turn. See StringCache.ResetHitCounts.
+func (sc *ShardedStringCache) ResetHitCounts() HitCounts {
+	var counts HitCounts
+	for i := range sc.shards {
+		shard := &sc.shards[i]
+		shardCounts := shard.cache.ResetHitCounts()
+		counts.Hit += shardCounts.Hit
+		counts.Miss += shardCounts.Miss
+	}
+	return counts
+}
+
+// DedupStats returns how many bytes of strings interning has saved, and how
+// many are held, summed across all shards. See StringCache.DedupStats.
+func (sc *ShardedStringCache) DedupStats() DedupStats {
+	var total DedupStats
+	for i := range sc.shards {
+		shard := &sc.shards[i]
+		shard.mu.Lock()
+		stats := shard.cache.DedupStats()
+		shard.mu.Unlock()
+		total.SavedBytes += stats.SavedBytes
+		total.HeldBytes += stats.HeldBytes
+	}
+	return total
+}
+
+// RemoveExpired removes every string that is too old from each shard. See
+// StringCache.RemoveExpired.
+func (sc *ShardedStringCache) RemoveExpired() int {
+	removed := 0
+	for i := range sc.shards {
+		shard := &sc.shards[i]
+		shard.mu.Lock()
+		removed += shard.cache.RemoveExpired()
+		shard.mu.Unlock()
+	}
+	return removed
+}
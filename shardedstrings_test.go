@@ -0,0 +1,151 @@
+// Copyright 2026 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package lru_test
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/lru"
+)
+
+type ShardedStringCacheSuite struct{}
+
+var _ = gc.Suite(&ShardedStringCacheSuite{})
+
+func (*ShardedStringCacheSuite) TestIntern(c *gc.C) {
+	str1 := fmt.Sprintf("foo%s", "bar")
+	str2 := fmt.Sprintf("foo%s", "bar")
+	cache := lru.NewShardedStringCache(4, 100)
+	str3 := cache.Intern(str1)
+	c.Check(isSameStr(str1, str3), gc.Equals, true)
+	str4 := cache.Intern(str2)
+	c.Check(isSameStr(str1, str4), gc.Equals, true)
+	c.Check(cache.Len(), gc.Equals, 1)
+	c.Check(cache.Contains(str2), gc.Equals, true)
+	c.Check(cache.HitCounts(), gc.Equals, lru.HitCounts{Hit: 1, Miss: 1})
+}
+
+func (*ShardedStringCacheSuite) TestInternBytes(c *gc.C) {
+	cache := lru.NewShardedStringCache(4, 100)
+	for i := 0; i < 20; i++ {
+		str1 := cache.Intern(fmt.Sprint(i))
+		// The bytes go to the same shard as the string.
+		str2 := cache.InternBytes([]byte(fmt.Sprint(i)))
+		c.Check(isSameStr(str1, str2), gc.Equals, true)
+	}
+	c.Check(cache.Len(), gc.Equals, 20)
+	c.Check(cache.HitCounts(), gc.Equals, lru.HitCounts{Hit: 20, Miss: 20})
+}
+
+func (*ShardedStringCacheSuite) TestRemove(c *gc.C) {
+	cache := lru.NewShardedStringCache(4, 100)
+	cache.Intern("foo")
+	cache.Intern("bar")
+	c.Check(cache.Remove("foo"), gc.Equals, true)
+	c.Check(cache.Remove("foo"), gc.Equals, false)
+	c.Check(cache.Contains("foo"), gc.Equals, false)
+	c.Check(cache.Len(), gc.Equals, 1)
+}
+
+func (*ShardedStringCacheSuite) TestClear(c *gc.C) {
+	cache := lru.NewShardedStringCache(4, 100)
+	for i := 0; i < 20; i++ {
+		cache.Intern(fmt.Sprint(i))
+	}
+	cache.Clear()
+	c.Check(cache.Len(), gc.Equals, 0)
+	c.Check(cache.Contains("0"), gc.Equals, false)
+}
+
+func (*ShardedStringCacheSuite) TestSetMaxSize(c *gc.C) {
+	cache := lru.NewShardedStringCache(3, 30)
+	for i := 0; i < 1000; i++ {
+		cache.Intern(fmt.Sprint(i))
+	}
+	cache.SetMaxSize(10)
+	c.Check(cache.Len(), gc.Equals, 10)
+	cache.SetMaxSize(100)
+	for i := 0; i < 1000; i++ {
+		cache.Intern(fmt.Sprint(i))
+	}
+	c.Check(cache.Len(), gc.Equals, 100)
+	c.Check(func() { cache.SetMaxSize(2) }, gc.PanicMatches, "size must be >= shards")
+}
+
+func (*ShardedStringCacheSuite) TestResetHitCounts(c *gc.C) {
+	cache := lru.NewShardedStringCache(4, 100)
+	for i := 0; i < 20; i++ {
+		cache.Intern(fmt.Sprint(i % 10))
+	}
+	c.Check(cache.ResetHitCounts(), gc.Equals, lru.HitCounts{Hit: 10, Miss: 10})
+	c.Check(cache.HitCounts(), gc.Equals, lru.HitCounts{})
+	cache.Intern("0")
+	c.Check(cache.ResetHitCounts(), gc.Equals, lru.HitCounts{Hit: 1})
+}
+
+func (*ShardedStringCacheSuite) TestTotalBytes(c *gc.C) {
+	cache := lru.NewShardedStringCache(4, 100)
+	single := lru.NewStringCache(100)
+	for i := 0; i < 20; i++ {
+		cache.Intern(fmt.Sprint(i))
+		single.Intern(fmt.Sprint(i))
+	}
+	c.Check(cache.TotalBytes(), gc.Equals, single.TotalBytes())
+}
+
+func (*ShardedStringCacheSuite) TestMaxSize(c *gc.C) {
+	cache := lru.NewShardedStringCache(3, 10)
+	for i := 0; i < 1000; i++ {
+		cache.Intern(fmt.Sprint(i))
+	}
+	c.Check(cache.Len(), gc.Equals, 10)
+}
+
+func (*ShardedStringCacheSuite) TestConcurrentIntern(c *gc.C) {
+	const threads = 8
+	const totalKeys = 10000
+	cache := lru.NewShardedStringCache(8, 500)
+	var wg sync.WaitGroup
+	for i := 0; i < threads; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < totalKeys; j++ {
+				k := fmt.Sprint(j % 1000)
+				if v := cache.Intern(k); v != k {
+					c.Errorf("key %q mapped to %q", k, v)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	counts := cache.HitCounts()
+	c.Check(counts.Hit+counts.Miss, gc.Equals, int64(threads*totalKeys))
+	c.Check(cache.Len(), gc.Equals, 500)
+}
+
+func (*ShardedStringCacheSuite) TestMaxAge(c *gc.C) {
+	clock := newTestClock()
+	cache := lru.NewShardedStringCache(4, 100, lru.WithMaxAge(time.Minute), lru.WithStringClock(clock))
+	for i := 0; i < 20; i++ {
+		cache.Intern(fmt.Sprint(i))
+	}
+	clock.Advance(time.Minute)
+	c.Check(cache.Contains("0"), gc.Equals, false)
+	c.Check(cache.RemoveExpired(), gc.Equals, 20)
+	c.Check(cache.Len(), gc.Equals, 0)
+}
+
+func (*ShardedStringCacheSuite) TestDedupStats(c *gc.C) {
+	cache := lru.NewShardedStringCache(4, 100)
+	cache.Intern("abc")
+	cache.Intern("abc")
+	cache.Intern("de")
+	c.Check(cache.DedupStats(), gc.Equals, lru.DedupStats{SavedBytes: 3, HeldBytes: 5})
+}
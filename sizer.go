@@ -0,0 +1,85 @@
+// Copyright 2026 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package lru
+
+import (
+	"unsafe"
+)
+
+// Sizer can be implemented by keys and values that know how much memory they
+// use, for NewWithMaxBytes.
+type Sizer interface {
+	// SizeBytes returns the number of bytes used by the value, not
+	// counting the interface holding it.
+	SizeBytes() int
+}
+
+const (
+	// mapEntryOverhead estimates the bytes used by each entry in the map of
+	// keys: the key and offset, plus the map's own bookkeeping and spare
+	// capacity.
+	mapEntryOverhead = 40
+
+	// entryOverhead estimates the bytes used by each entry in an LRU created
+	// with NewWithMaxBytes, not counting its key and value.
+	entryOverhead = int64(unsafe.Sizeof(cacheEntry{})) + mapEntryOverhead + 8
+)
+
+// sizeBytes returns the estimated size in bytes of an entry for key and
+// value.
+func (lru *LRU) sizeBytes(key, value interface{}) int64 {
+	size := entryOverhead + int64(dataSize(key))
+	if lru.sizeOf != nil {
+		size += int64(lru.sizeOf(value))
+	} else {
+		size += int64(dataSize(value))
+	}
+	return size
+}
+
+// dataSize returns the number of bytes a key or value refers to, beyond the
+// interface that holds it, where that is known.
+func dataSize(v interface{}) int {
+	switch v := v.(type) {
+	case Sizer:
+		return v.SizeBytes()
+	case string:
+		return len(v)
+	case []byte:
+		return len(v)
+	}
+	return 0
+}
+
+// SizeBytes returns an estimate of the memory used by the cache: its buffers
+// and map, and the keys and values it holds, as far as they are known (see
+// NewWithMaxBytes). The values of interfaces are not counted, so the memory
+// used by values other than strings, byte slices and Sizers is not included.
+// It looks at every entry, so is O(n).
+func (lru *LRU) SizeBytes() int {
+	size := int(unsafe.Sizeof(*lru))
+	size += cap(lru.buf) * int(unsafe.Sizeof(cacheEntry{}))
+	size += cap(lru.meta) * int(unsafe.Sizeof(entryMeta{}))
+	size += cap(lru.marks)*8 + cap(lru.stamps)*8 + cap(lru.accessCounts)*8 + cap(lru.costs)*8 + cap(lru.priorities)
+	size += len(lru.elements) * mapEntryOverhead
+	if lru.wheel != nil {
+		size += int(unsafe.Sizeof(*lru.wheel))
+	}
+	if lru.sketch != nil {
+		size += len(lru.sketch.rows) * len(lru.sketch.rows[0]) * 8
+	}
+	if lru.doorkeeper != nil {
+		size += len(lru.doorkeeper.bits) * 8
+	}
+	for elem := lru.root.next; elem != 0; elem = lru.buf[elem].next {
+		entry := &lru.buf[elem]
+		size += dataSize(entry.key)
+		if lru.sizeOf != nil {
+			size += lru.sizeOf(entry.value)
+		} else {
+			size += dataSize(entry.value)
+		}
+	}
+	return size
+}
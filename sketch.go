@@ -0,0 +1,128 @@
+// Copyright 2026 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package lru
+
+import (
+	"hash/maphash"
+)
+
+// frequencySketch is a count-min sketch with 4-bit counters, used to estimate
+// how often keys have been used without having to remember the keys. Every
+// so often all the counters are halved, so that keys that were popular a long
+// time ago don't stay that way forever.
+type frequencySketch struct {
+	seed maphash.Seed
+	// rows holds sketchDepth rows of counters, each packed 16 to a word.
+	rows [sketchDepth][]uint64
+	// shift selects a counter within a row from the top bits of a hash.
+	shift uint
+	// additions counts increments since the counters were last halved,
+	// which happens when it reaches resetAt.
+	additions int
+	resetAt   int
+}
+
+const sketchDepth = 4
+
+// sketchMultipliers are the constants used to pick a counter in each row.
+var sketchMultipliers = [sketchDepth]uint64{
+	0x9e3779b97f4a7c15,
+	0xc2b2ae3d27d4eb4f,
+	0x165667b19e3779f9,
+	0xd6e8feb86659fd93,
+}
+
+// newFrequencySketch creates a sketch suitable for a cache holding size
+// entries.
+func newFrequencySketch(size int) *frequencySketch {
+	width, bits := 64, uint(6)
+	for width < size {
+		width *= 2
+		bits++
+	}
+	s := &frequencySketch{
+		seed:    maphash.MakeSeed(),
+		shift:   64 - bits,
+		resetAt: 10 * width,
+	}
+	for i := range s.rows {
+		s.rows[i] = make([]uint64, width/16)
+	}
+	return s
+}
+
+// counter returns the word and shift of the counter for hash h in row i.
+func (s *frequencySketch) counter(h uint64, i int) (*uint64, uint) {
+	// Each row multiplies the hash by a different odd constant and takes
+	// the top bits, so every bit of the hash affects which counter is used
+	// in every row, and keys that share a counter in one row are unlikely
+	// to in the others.
+	index := (h * sketchMultipliers[i]) >> s.shift
+	return &s.rows[i][index/16], uint(index%16) * 4
+}
+
+// increment adds one to the estimated count for key.
+func (s *frequencySketch) increment(key interface{}) {
+	h := hashKey(s.seed, key)
+	for i := range s.rows {
+		word, shift := s.counter(h, i)
+		if (*word>>shift)&0xf < 0xf {
+			*word += 1 << shift
+		}
+	}
+	s.additions++
+	if s.additions >= s.resetAt {
+		s.halve()
+	}
+}
+
+// estimate returns the estimated count for key, which may be too high, but is
+// never too low (unless the counters have been halved since).
+func (s *frequencySketch) estimate(key interface{}) uint64 {
+	h := hashKey(s.seed, key)
+	least := uint64(0xf)
+	for i := range s.rows {
+		word, shift := s.counter(h, i)
+		if count := (*word >> shift) & 0xf; count < least {
+			least = count
+		}
+	}
+	return least
+}
+
+// halve divides every counter by two.
+func (s *frequencySketch) halve() {
+	for _, row := range s.rows {
+		for i := range row {
+			row[i] = (row[i] >> 1) & 0x7777777777777777
+		}
+	}
+	s.additions /= 2
+}
+
+// recordUse notes a use of key for WithTinyLFU.
+func (lru *LRU) recordUse(key interface{}) {
+	if lru.sketch != nil {
+		lru.sketch.increment(mapKey(key))
+	}
+}
+
+// filtersAdmission returns whether admit may turn a new entry away.
+func (lru *LRU) filtersAdmission() bool {
+	return lru.sketch != nil || lru.doorkeeper != nil
+}
+
+// admit returns whether key should be added to the cache in place of victim.
+func (lru *LRU) admit(key interface{}, victim uint32) bool {
+	if !lru.filtersAdmission() || lru.expiredAt(victim, lru.nowNano()) {
+		return true
+	}
+	if lru.doorkeeper != nil && !lru.doorkeeper.add(mapKey(key)) {
+		return false
+	}
+	if lru.sketch == nil {
+		return true
+	}
+	return lru.sketch.estimate(mapKey(key)) > lru.sketch.estimate(mapKey(lru.buf[victim].key))
+}
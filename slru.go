@@ -0,0 +1,94 @@
+// Copyright 2026 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package lru
+
+// DefaultSLRUProtectedRatio is the share of a SegmentedLRU given to its
+// protected segment.
+const DefaultSLRUProtectedRatio = 0.8
+
+// SegmentedLRU is a segmented LRU (SLRU) cache. New entries go into a
+// probationary segment, and are only promoted into the protected segment when
+// they are used again. When the cache is full, entries are evicted from the
+// probationary segment, so a burst of new keys can't flush out entries that
+// have proven themselves. When the protected segment is over its share of the
+// cache, its least recently used entries are moved back to the probationary
+// segment, where they get another chance to be used before being evicted.
+// Each segment is an LRU, so it uses the same compact storage.
+// Note that SegmentedLRU is *not* thread safe, some form of mutex is
+// necessary if you want to access it from multiple threads.
+type SegmentedLRU struct {
+	size          int
+	protectedSize int
+	probation     *LRU
+	protected     *LRU
+}
+
+// NewSLRU creates a SegmentedLRU that will hold no more than the given number
+// of items, with the default segment ratio.
+func NewSLRU(size int) *SegmentedLRU {
+	return NewSLRUWithRatio(size, DefaultSLRUProtectedRatio)
+}
+
+// NewSLRUWithRatio creates a SegmentedLRU that will hold no more than the
+// given number of items, where protectedRatio is the share of the cache that
+// the protected segment can take up.
+func NewSLRUWithRatio(size int, protectedRatio float64) *SegmentedLRU {
+	if protectedRatio < 0 || protectedRatio > 1 {
+		panic("protectedRatio must be between 0 and 1")
+	}
+	return &SegmentedLRU{
+		size:          size,
+		protectedSize: int(float64(size) * protectedRatio),
+		probation:     New(size),
+		protected:     New(size),
+	}
+}
+
+// Len gives the number of items in the cache
+func (c *SegmentedLRU) Len() int {
+	return c.probation.Len() + c.protected.Len()
+}
+
+// Add a new entry into the cache. Updating an entry that is already in the
+// cache marks it as recently used within its segment, but does not promote
+// it.
+func (c *SegmentedLRU) Add(key, value interface{}) {
+	if _, ok := c.protected.elements[c.protected.identity(key)]; ok {
+		c.protected.Add(key, value)
+		return
+	}
+	if _, ok := c.probation.elements[c.probation.identity(key)]; !ok && c.Len() >= c.size {
+		if _, ok := c.probation.removeOldest(); !ok {
+			c.protected.removeOldest()
+		}
+	}
+	c.probation.Add(key, value)
+}
+
+// Get returns the Value associated with key, and a boolean as to whether it
+// actually exists in the cache. If it does exist in the cache, then it is
+// treated as recently accessed, and promoted into the protected segment.
+func (c *SegmentedLRU) Get(key interface{}) (interface{}, bool) {
+	if value, ok := c.protected.Get(key); ok {
+		return value, true
+	}
+	value, ok := c.probation.remove(key)
+	if !ok {
+		return nil, false
+	}
+	c.protected.Add(key, value)
+	if c.protected.Len() > c.protectedSize {
+		entry, _ := c.protected.removeOldest()
+		c.probation.Add(entry.Key, entry.Value)
+	}
+	return value, true
+}
+
+// Peek is just like Get() except it doesn't affect if it was 'recently accessed'
+func (c *SegmentedLRU) Peek(key interface{}) (interface{}, bool) {
+	if value, ok := c.protected.Peek(key); ok {
+		return value, true
+	}
+	return c.probation.Peek(key)
+}
@@ -0,0 +1,82 @@
+// Copyright 2026 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package lru_test
+
+import (
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/lru"
+)
+
+type SLRUSuite struct{}
+
+var _ = gc.Suite(&SLRUSuite{})
+
+func (*SLRUSuite) TestAddGet(c *gc.C) {
+	cache := lru.NewSLRU(10)
+	cache.Add("a", 1)
+	cache.Add("b", 2)
+	value, ok := cache.Get("a")
+	c.Check(ok, gc.Equals, true)
+	c.Check(value, gc.Equals, 1)
+	cache.Add("a", 3)
+	cache.Add("b", 4)
+	value, _ = cache.Peek("a")
+	c.Check(value, gc.Equals, 3)
+	value, _ = cache.Get("b")
+	c.Check(value, gc.Equals, 4)
+	_, ok = cache.Get("c")
+	c.Check(ok, gc.Equals, false)
+	c.Check(cache.Len(), gc.Equals, 2)
+}
+
+func (*SLRUSuite) TestMaxSize(c *gc.C) {
+	cache := lru.NewSLRU(10)
+	for i := 0; i < 100; i++ {
+		cache.Add(i, i)
+		if i%2 == 0 {
+			cache.Get(i)
+		}
+		c.Assert(cache.Len() <= 10, gc.Equals, true)
+	}
+	c.Check(cache.Len(), gc.Equals, 10)
+}
+
+func (*SLRUSuite) TestBurstOfNewKeys(c *gc.C) {
+	cache := lru.NewSLRU(100)
+	for i := 0; i < 50; i++ {
+		cache.Add(i, i)
+		cache.Get(i)
+	}
+	for i := 1000; i < 2000; i++ {
+		cache.Add(i, i)
+	}
+	for i := 0; i < 50; i++ {
+		_, ok := cache.Peek(i)
+		c.Check(ok, gc.Equals, true, gc.Commentf("lost %d", i))
+	}
+	c.Check(cache.Len(), gc.Equals, 100)
+}
+
+func (*SLRUSuite) TestDemotion(c *gc.C) {
+	cache := lru.NewSLRUWithRatio(4, 0.5)
+	for _, key := range []string{"a", "b", "c"} {
+		cache.Add(key, key)
+		cache.Get(key)
+	}
+	// Only two fit in the protected segment, so "a" was moved back to
+	// probation, and is the first to go.
+	cache.Add("d", "d")
+	cache.Add("e", "e")
+	_, ok := cache.Peek("a")
+	c.Check(ok, gc.Equals, false)
+	for _, key := range []string{"b", "c", "d", "e"} {
+		_, ok := cache.Peek(key)
+		c.Check(ok, gc.Equals, true, gc.Commentf("missing %q", key))
+	}
+}
+
+func (*SLRUSuite) TestBadRatio(c *gc.C) {
+	c.Check(func() { lru.NewSLRUWithRatio(10, 1.5) }, gc.PanicMatches, "protectedRatio must be .*")
+}
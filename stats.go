@@ -0,0 +1,207 @@
+// Copyright 2026 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package lru
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// HitCounts gives information about calls to Get: Hit counts those that found
+// the key in the cache, and Miss those that didn't, including those that
+// found it expired. The counters are updated atomically, so it is safe to call
+// while the cache is being read from other goroutines under a read lock.
+func (lru *LRU) HitCounts() HitCounts {
+	return HitCounts{
+		Hit:  atomic.LoadInt64(&lru.hitCount),
+		Miss: atomic.LoadInt64(&lru.missCount),
+	}
+}
+
+// PeekHitCounts gives information about calls to Peek, which are counted
+// separately from those to Get, see HitCounts.
+func (lru *LRU) PeekHitCounts() HitCounts {
+	return HitCounts{
+		Hit:  atomic.LoadInt64(&lru.peekHitCount),
+		Miss: atomic.LoadInt64(&lru.peekMissCount),
+	}
+}
+
+// Stats is a snapshot of the state of a cache, and what has happened to it.
+type Stats struct {
+	// Len is the number of entries in the cache, and Cap the most it can
+	// hold, which is 0 if it is unbounded.
+	Len int `json:"len"`
+	Cap int `json:"cap"`
+	// Hits and Misses count calls to Get, see HitCounts.
+	Hits   int64 `json:"hits"`
+	Misses int64 `json:"misses"`
+	// Evictions counts entries dropped to make room for new ones.
+	Evictions int64 `json:"evictions"`
+	// Expirations counts entries removed because they expired, whether
+	// they were looked up or removed by RemoveExpired.
+	Expirations int64 `json:"expirations"`
+	// Replacements counts values replaced by a new value for the same key.
+	Replacements int64 `json:"replacements"`
+}
+
+// Stats returns a snapshot of the state of the cache and its counters.
+func (lru *LRU) Stats() Stats {
+	counts := lru.HitCounts()
+	return lru.stats(counts.Hit, counts.Miss)
+}
+
+// ResetStats returns the same snapshot as Stats, and sets the counters it
+// reports back to zero, so that each call reports what happened since the
+// last. It also resets EstimatedHitCounts. The Get counters are swapped
+// atomically, so no Gets are lost even if they happen at the same time under a
+// read lock.
+func (lru *LRU) ResetStats() Stats {
+	stats := lru.stats(atomic.SwapInt64(&lru.hitCount, 0), atomic.SwapInt64(&lru.missCount, 0))
+	lru.evictions, lru.expirations, lru.replacements = 0, 0, 0
+	atomic.StoreInt64(&lru.ghostHits, 0)
+	return stats
+}
+
+// stats returns a snapshot of the cache with the given Get counters.
+func (lru *LRU) stats(hits, misses int64) Stats {
+	stats := Stats{
+		Len:          lru.size,
+		Cap:          lru.maxSize,
+		Hits:         hits,
+		Misses:       misses,
+		Evictions:    lru.evictions,
+		Expirations:  lru.expirations,
+		Replacements: lru.replacements,
+	}
+	if lru.unbounded {
+		stats.Cap = 0
+	}
+	return stats
+}
+
+// KeyCount is a key and how many times Get has found it, see AccessCounts.
+type KeyCount struct {
+	Key   interface{}
+	Count uint64
+}
+
+// AccessCount returns how many times Get has found key since it was added,
+// and whether it is in the cache. The cache must have been created
+// WithAccessCounts.
+func (lru *LRU) AccessCount(key interface{}) (uint64, bool) {
+	if lru.accessCounts == nil {
+		panic("AccessCount needs the cache to be created WithAccessCounts")
+	}
+	elem, ok := lru.lookup(key)
+	if !ok {
+		return 0, false
+	}
+	return lru.accessCounts[elem], true
+}
+
+// AccessCounts returns how many times Get has found each entry in the cache,
+// most recently used first, to study how often the cached keys are used. The
+// cache must have been created WithAccessCounts.
+func (lru *LRU) AccessCounts() []KeyCount {
+	if lru.accessCounts == nil {
+		panic("AccessCounts needs the cache to be created WithAccessCounts")
+	}
+	counts := make([]KeyCount, 0, lru.size)
+	now := lru.nowNano()
+	for elem := lru.root.next; elem != 0; elem = lru.buf[elem].next {
+		if !lru.expiredAt(elem, now) {
+			counts = append(counts, KeyCount{Key: lru.buf[elem].key, Count: lru.accessCounts[elem]})
+		}
+	}
+	return counts
+}
+
+// EvictionAges describes how old entries were when they were evicted to make
+// room for others. If entries are evicted soon after they were last used, the
+// cache is likely too small for the keys being used.
+type EvictionAges struct {
+	// Lifetime is how long entries had been in the cache.
+	Lifetime DurationHistogram
+	// Idle is how long it had been since entries were last written or
+	// returned by Get.
+	Idle DurationHistogram
+}
+
+// EvictionAges returns how old the entries evicted to make room for others
+// have been, since the cache was created. Entries that are removed or expire
+// are not counted. The cache must have been created WithEvictionAges.
+func (lru *LRU) EvictionAges() EvictionAges {
+	if lru.evictionAges == nil {
+		panic("EvictionAges needs the cache to be created WithEvictionAges")
+	}
+	return *lru.evictionAges
+}
+
+// EntryAges describes how old the entries in a cache are, which shows whether
+// it mostly holds entries that are soon replaced, or ones that stay in use
+// for a long time.
+type EntryAges struct {
+	// Age is how long entries have been in the cache.
+	Age DurationHistogram
+	// Idle is how long it has been since entries were last written or
+	// returned by Get.
+	Idle DurationHistogram
+}
+
+// EntryAges returns how old the entries in the cache are. Entries that have
+// expired are not counted. The cache must have been created WithAccessTimes
+// (or another option that records them, such as WithExpiry).
+func (lru *LRU) EntryAges() EntryAges {
+	if lru.meta == nil {
+		panic("EntryAges needs the cache to be created WithAccessTimes")
+	}
+	var ages EntryAges
+	now := lru.clock.Now().UnixNano()
+	for elem := uint32(1); elem <= uint32(lru.size); elem++ {
+		if lru.expiredAt(elem, now) {
+			continue
+		}
+		meta := &lru.meta[elem]
+		ages.Age.add(time.Duration(now - meta.added))
+		ages.Idle.add(time.Duration(now - meta.accessed))
+	}
+	return ages
+}
+
+// recordEvictionAge records the age of elem, which is being evicted.
+func (lru *LRU) recordEvictionAge(elem uint32) {
+	if lru.evictionAges == nil {
+		return
+	}
+	now := lru.clock.Now().UnixNano()
+	meta := &lru.meta[elem]
+	lru.evictionAges.Lifetime.add(time.Duration(now - meta.added))
+	lru.evictionAges.Idle.add(time.Duration(now - meta.accessed))
+}
+
+// countAccess records that Get found elem.
+func (lru *LRU) countAccess(elem uint32) {
+	if lru.accessCounts != nil {
+		lru.accessCounts[elem]++
+	}
+}
+
+// countGet records whether a call to Get found its key.
+func (lru *LRU) countGet(hit bool) {
+	if hit {
+		atomic.AddInt64(&lru.hitCount, 1)
+	} else {
+		atomic.AddInt64(&lru.missCount, 1)
+	}
+}
+
+// countPeek records whether a call to Peek found its key.
+func (lru *LRU) countPeek(hit bool) {
+	if hit {
+		atomic.AddInt64(&lru.peekHitCount, 1)
+	} else {
+		atomic.AddInt64(&lru.peekMissCount, 1)
+	}
+}
@@ -0,0 +1,482 @@
+// Copyright 2026 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package lru
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// The format written by WriteTo is stable: streams written by older versions
+// of this package can always be read by newer ones. A stream is:
+//
+//	magic    "LRU\x00"
+//	version  1 byte, currently 2
+//	count    uvarint, the number of entries (since version 2)
+//	entries  count entries, from the least recently used to the most
+//
+// Each entry is its length in bytes as a uvarint, followed by its key and its
+// value. Each of those is a one byte tag giving its type, followed by:
+//
+//	nil                       nothing
+//	string, []byte            length as a uvarint, and the bytes
+//	bool                      1 byte, 0 or 1
+//	int, int8 ... int64       varint
+//	uint, uint8 ... uint64    uvarint
+//	float32, float64          IEEE 754 bits, little endian, 4 or 8 bytes
+//	gob                       length as a uvarint, and a gob encoded gobValue
+//	binary                    type name length as a uvarint, the name, then
+//	                          length as a uvarint, and the MarshalBinary data
+//
+// Version 1 has no count, and instead ends with a zero length entry.
+const streamMagic = "LRU\x00"
+
+// streamVersion is the version of the format WriteTo writes.
+const streamVersion = 2
+
+// Tags for the types of keys and values written by WriteTo. Common types are
+// written directly, so that they are cheap and always read back as the same
+// type, types registered with RegisterBinary are written with their
+// MarshalBinary method, and anything else is written with encoding/gob.
+const (
+	tagNil byte = iota
+	tagString
+	tagBytes
+	tagBool
+	tagInt
+	tagInt8
+	tagInt16
+	tagInt32
+	tagInt64
+	tagUint
+	tagUint8
+	tagUint16
+	tagUint32
+	tagUint64
+	tagFloat32
+	tagFloat64
+	tagGob
+	tagBinary
+)
+
+// gobValue wraps a value written with encoding/gob, so that its type is
+// recorded too.
+type gobValue struct {
+	V interface{}
+}
+
+// WriteTo implements io.WriterTo. It streams the entries in the cache to w,
+// from the least recently used to the most, without copying them first, so
+// that ReadFrom can recreate them. The format, which is described with
+// streamMagic, is versioned. Strings, byte slices, bools and numbers are
+// written directly, types registered with RegisterBinary are written with
+// their MarshalBinary method, and other types are written with encoding/gob,
+// so they must be registered with gob.Register. Expired entries are not
+// written. It returns the number of bytes written.
+func (lru *LRU) WriteTo(w io.Writer) (int64, error) {
+	cw := &countingWriter{w: w}
+	bw := bufio.NewWriter(cw)
+	bw.WriteString(streamMagic)
+	bw.WriteByte(streamVersion)
+	// Both passes must agree on what has expired, or the count won't match
+	// the entries written.
+	now := lru.nowNano()
+	count := 0
+	for elem := lru.root.next; elem != 0; elem = lru.buf[elem].next {
+		if !lru.expiredAt(elem, now) {
+			count++
+		}
+	}
+	writeUvarint(bw, uint64(count))
+	var record []byte
+	var err error
+	for elem := lru.root.prev; elem != 0; elem = lru.buf[elem].prev {
+		if lru.expiredAt(elem, now) {
+			continue
+		}
+		entry := &lru.buf[elem]
+		record, err = appendEntry(record[:0], entry.key, entry.value)
+		if err != nil {
+			break
+		}
+		writeUvarint(bw, uint64(len(record)))
+		if _, err = bw.Write(record); err != nil {
+			break
+		}
+	}
+	if err != nil {
+		return cw.n, err
+	}
+	err = bw.Flush()
+	return cw.n, err
+}
+
+// WriteTo streams the entries in the cache to w. The lock is held while they
+// are written. See LRU.WriteTo.
+func (s *SyncLRU) WriteTo(w io.Writer) (int64, error) {
+	// Taking the write lock applies any Gets not yet recorded, so the
+	// recency order is up to date.
+	s.lock()
+	defer s.mu.Unlock()
+	return s.lru.WriteTo(w)
+}
+
+// appendEntry appends the encoded key and value to buf.
+func appendEntry(buf []byte, key, value interface{}) ([]byte, error) {
+	buf, err := appendValue(buf, key)
+	if err != nil {
+		return nil, fmt.Errorf("writing key %v: %w", key, err)
+	}
+	buf, err = appendValue(buf, value)
+	if err != nil {
+		return nil, fmt.Errorf("writing value for %v: %w", key, err)
+	}
+	return buf, nil
+}
+
+// appendValue appends v to buf, as a tag for its type, followed by its data.
+func appendValue(buf []byte, v interface{}) ([]byte, error) {
+	switch v := v.(type) {
+	case nil:
+		return append(buf, tagNil), nil
+	case string:
+		buf = appendUvarint(append(buf, tagString), uint64(len(v)))
+		return append(buf, v...), nil
+	case []byte:
+		buf = appendUvarint(append(buf, tagBytes), uint64(len(v)))
+		return append(buf, v...), nil
+	case bool:
+		if v {
+			return append(buf, tagBool, 1), nil
+		}
+		return append(buf, tagBool, 0), nil
+	case int:
+		return appendVarint(append(buf, tagInt), int64(v)), nil
+	case int8:
+		return appendVarint(append(buf, tagInt8), int64(v)), nil
+	case int16:
+		return appendVarint(append(buf, tagInt16), int64(v)), nil
+	case int32:
+		return appendVarint(append(buf, tagInt32), int64(v)), nil
+	case int64:
+		return appendVarint(append(buf, tagInt64), v), nil
+	case uint:
+		return appendUvarint(append(buf, tagUint), uint64(v)), nil
+	case uint8:
+		return appendUvarint(append(buf, tagUint8), uint64(v)), nil
+	case uint16:
+		return appendUvarint(append(buf, tagUint16), uint64(v)), nil
+	case uint32:
+		return appendUvarint(append(buf, tagUint32), uint64(v)), nil
+	case uint64:
+		return appendUvarint(append(buf, tagUint64), v), nil
+	case float32:
+		var b [4]byte
+		binary.LittleEndian.PutUint32(b[:], math.Float32bits(v))
+		return append(append(buf, tagFloat32), b[:]...), nil
+	case float64:
+		var b [8]byte
+		binary.LittleEndian.PutUint64(b[:], math.Float64bits(v))
+		return append(append(buf, tagFloat64), b[:]...), nil
+	}
+	buf, ok, err := appendBinary(buf, v)
+	if ok || err != nil {
+		return buf, err
+	}
+	var gobBuf bytes.Buffer
+	if err := gob.NewEncoder(&gobBuf).Encode(&gobValue{V: v}); err != nil {
+		return nil, err
+	}
+	buf = appendUvarint(append(buf, tagGob), uint64(gobBuf.Len()))
+	return append(buf, gobBuf.Bytes()...), nil
+}
+
+// appendUvarint appends x to buf as a uvarint.
+func appendUvarint(buf []byte, x uint64) []byte {
+	var b [binary.MaxVarintLen64]byte
+	return append(buf, b[:binary.PutUvarint(b[:], x)]...)
+}
+
+// appendVarint appends x to buf as a varint.
+func appendVarint(buf []byte, x int64) []byte {
+	var b [binary.MaxVarintLen64]byte
+	return append(buf, b[:binary.PutVarint(b[:], x)]...)
+}
+
+// writeUvarint writes x to w as a uvarint.
+func writeUvarint(w *bufio.Writer, x uint64) {
+	var buf [binary.MaxVarintLen64]byte
+	w.Write(buf[:binary.PutUvarint(buf[:], x)])
+}
+
+// countingWriter counts the bytes written to w.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
+
+// maxStreamRecord is the longest entry ReadFrom accepts, so that a corrupt
+// length can't make it allocate without limit.
+const maxStreamRecord = 1 << 30
+
+// ErrBadStream is returned by ReadFrom when what it reads is not a stream
+// written by WriteTo, or is cut short.
+var ErrBadStream = errors.New("lru: invalid stream")
+
+// ReadFrom implements io.ReaderFrom. It reads entries written by WriteTo from
+// r, adding each to the cache as it is read, in the order they were written,
+// so that the most recently used entry is also the most recently used in the
+// cache. The whole stream is never held in memory, and the cache never holds
+// more than it can: if there are more entries than fit, the least recently
+// used are evicted as usual, leaving the most recent ones (or, if the stream
+// says how many entries it holds, and nothing but recency decides what is
+// evicted, are skipped without being added at all). Streams in any earlier
+// version of the format are read too. The format is checked as it is read,
+// and an error wrapping ErrBadStream is returned if it is wrong, or ends
+// early. The entries read before an error have still been added. It returns
+// the number of bytes read. If r is not an io.ByteReader, it is buffered, so
+// more may be read from it than the stream takes up.
+func (lru *LRU) ReadFrom(r io.Reader) (int64, error) {
+	sr := newStreamReader(r)
+	magic := make([]byte, len(streamMagic)+1)
+	if _, err := io.ReadFull(sr, magic); err != nil {
+		return sr.n, badStream(err)
+	}
+	if string(magic[:len(streamMagic)]) != streamMagic {
+		return sr.n, fmt.Errorf("%w: bad magic", ErrBadStream)
+	}
+	version := magic[len(streamMagic)]
+	if version != 1 && version != streamVersion {
+		return sr.n, fmt.Errorf("%w: unknown version %d", ErrBadStream, version)
+	}
+	// Version 1 has no count, and ends with an empty entry instead.
+	count := uint64(math.MaxUint64)
+	if version >= 2 {
+		var err error
+		if count, err = binary.ReadUvarint(sr); err != nil {
+			return sr.n, badStream(err)
+		}
+	}
+	// Entries that would only be evicted by the ones after them needn't be
+	// added at all, if the cache evicts the least recently used entry.
+	skip := uint64(0)
+	if count != math.MaxUint64 && count > uint64(lru.maxSize) && lru.evictsOneLRU() {
+		skip = count - uint64(lru.maxSize)
+	}
+	var record []byte
+	for i := uint64(0); i < count; i++ {
+		length, err := binary.ReadUvarint(sr)
+		if err != nil {
+			return sr.n, badStream(err)
+		}
+		if length == 0 {
+			if version == 1 {
+				return sr.n, nil
+			}
+			return sr.n, fmt.Errorf("%w: empty entry", ErrBadStream)
+		}
+		if length > maxStreamRecord {
+			return sr.n, fmt.Errorf("%w: entry of %d bytes is too long", ErrBadStream, length)
+		}
+		if uint64(cap(record)) < length {
+			record = make([]byte, length)
+		}
+		record = record[:length]
+		if _, err := io.ReadFull(sr, record); err != nil {
+			return sr.n, badStream(err)
+		}
+		key, value, err := readEntry(record)
+		if err != nil {
+			return sr.n, err
+		}
+		if i >= skip {
+			lru.Add(key, value)
+		}
+	}
+	return sr.n, nil
+}
+
+// ReadFrom adds the entries streamed by WriteTo to the cache. The lock is held
+// while they are read. See LRU.ReadFrom.
+func (s *SyncLRU) ReadFrom(r io.Reader) (int64, error) {
+	s.lock()
+	defer s.mu.Unlock()
+	n, err := s.lru.ReadFrom(r)
+	s.notifyAllWaiters()
+	return n, err
+}
+
+// badStream returns the error for a stream that could not be read, which
+// wraps ErrBadStream if it ended early.
+func badStream(err error) error {
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return fmt.Errorf("%w: %v", ErrBadStream, io.ErrUnexpectedEOF)
+	}
+	return err
+}
+
+// readEntry decodes a key and value written by appendEntry, which must take
+// up all of data.
+func readEntry(data []byte) (key, value interface{}, err error) {
+	key, data, err = readValue(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	value, data, err = readValue(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(data) != 0 {
+		return nil, nil, fmt.Errorf("%w: %d bytes left over after entry", ErrBadStream, len(data))
+	}
+	return key, value, nil
+}
+
+// readValue decodes a value written by appendValue from the start of data,
+// and returns it and the rest of data.
+func readValue(data []byte) (interface{}, []byte, error) {
+	if len(data) == 0 {
+		return nil, nil, fmt.Errorf("%w: missing value", ErrBadStream)
+	}
+	tag, data := data[0], data[1:]
+	switch tag {
+	case tagNil:
+		return nil, data, nil
+	case tagString, tagBytes, tagGob:
+		length, n := binary.Uvarint(data)
+		if n <= 0 || uint64(len(data)-n) < length {
+			return nil, nil, fmt.Errorf("%w: bad length", ErrBadStream)
+		}
+		b, rest := data[n:n+int(length)], data[n+int(length):]
+		switch tag {
+		case tagString:
+			return string(b), rest, nil
+		case tagBytes:
+			return append([]byte{}, b...), rest, nil
+		}
+		var v gobValue
+		if err := gob.NewDecoder(bytes.NewReader(b)).Decode(&v); err != nil {
+			return nil, nil, err
+		}
+		return v.V, rest, nil
+	case tagBinary:
+		nameLen, n := binary.Uvarint(data)
+		if n <= 0 || uint64(len(data)-n) < nameLen {
+			return nil, nil, fmt.Errorf("%w: bad length", ErrBadStream)
+		}
+		name, data := string(data[n:n+int(nameLen)]), data[n+int(nameLen):]
+		length, n := binary.Uvarint(data)
+		if n <= 0 || uint64(len(data)-n) < length {
+			return nil, nil, fmt.Errorf("%w: bad length", ErrBadStream)
+		}
+		v, err := unmarshalBinary(name, data[n:n+int(length)])
+		if err != nil {
+			return nil, nil, err
+		}
+		return v, data[n+int(length):], nil
+	case tagBool:
+		if len(data) == 0 || data[0] > 1 {
+			return nil, nil, fmt.Errorf("%w: bad bool", ErrBadStream)
+		}
+		return data[0] == 1, data[1:], nil
+	case tagInt, tagInt8, tagInt16, tagInt32, tagInt64:
+		x, n := binary.Varint(data)
+		if n <= 0 {
+			return nil, nil, fmt.Errorf("%w: bad integer", ErrBadStream)
+		}
+		var v interface{}
+		switch tag {
+		case tagInt:
+			v = int(x)
+		case tagInt8:
+			v = int8(x)
+		case tagInt16:
+			v = int16(x)
+		case tagInt32:
+			v = int32(x)
+		default:
+			v = x
+		}
+		return v, data[n:], nil
+	case tagUint, tagUint8, tagUint16, tagUint32, tagUint64:
+		x, n := binary.Uvarint(data)
+		if n <= 0 {
+			return nil, nil, fmt.Errorf("%w: bad integer", ErrBadStream)
+		}
+		var v interface{}
+		switch tag {
+		case tagUint:
+			v = uint(x)
+		case tagUint8:
+			v = uint8(x)
+		case tagUint16:
+			v = uint16(x)
+		case tagUint32:
+			v = uint32(x)
+		default:
+			v = x
+		}
+		return v, data[n:], nil
+	case tagFloat32:
+		if len(data) < 4 {
+			return nil, nil, fmt.Errorf("%w: bad float", ErrBadStream)
+		}
+		return math.Float32frombits(binary.LittleEndian.Uint32(data)), data[4:], nil
+	case tagFloat64:
+		if len(data) < 8 {
+			return nil, nil, fmt.Errorf("%w: bad float", ErrBadStream)
+		}
+		return math.Float64frombits(binary.LittleEndian.Uint64(data)), data[8:], nil
+	}
+	return nil, nil, fmt.Errorf("%w: unknown type %d", ErrBadStream, tag)
+}
+
+// byteReader can read a byte at a time, as binary.ReadUvarint needs.
+type byteReader interface {
+	io.Reader
+	io.ByteReader
+}
+
+// streamReader counts the bytes read from a reader, which it buffers unless
+// it is already a byteReader.
+type streamReader struct {
+	r byteReader
+	n int64
+}
+
+func newStreamReader(r io.Reader) *streamReader {
+	sr := &streamReader{}
+	if br, ok := r.(byteReader); ok {
+		sr.r = br
+	} else {
+		sr.r = bufio.NewReader(r)
+	}
+	return sr
+}
+
+func (sr *streamReader) Read(p []byte) (int, error) {
+	n, err := sr.r.Read(p)
+	sr.n += int64(n)
+	return n, err
+}
+
+func (sr *streamReader) ReadByte() (byte, error) {
+	b, err := sr.r.ReadByte()
+	if err == nil {
+		sr.n++
+	}
+	return b, err
+}
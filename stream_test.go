@@ -0,0 +1,383 @@
+// Copyright 2026 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package lru_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"time"
+
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/lru"
+)
+
+type StreamSuite struct{}
+
+var _ = gc.Suite(&StreamSuite{})
+
+func (*StreamSuite) TestWriteTo(c *gc.C) {
+	cache := lru.New(10)
+	cache.Add("a", 1)
+	cache.Add(2, []byte("b"))
+	cache.Get("a")
+	var buf bytes.Buffer
+	n, err := cache.WriteTo(&buf)
+	c.Assert(err, gc.IsNil)
+	c.Check(n, gc.Equals, int64(buf.Len()))
+	c.Check(buf.String(), gc.Equals, "LRU\x00\x02\x02"+
+		// 2: []byte("b")
+		"\x05\x04\x04\x02\x01b"+
+		// "a": 1
+		"\x05\x01\x01a\x04\x02")
+}
+
+// steppingClock moves on by step every time it is read.
+type steppingClock struct {
+	*testClock
+	step time.Duration
+}
+
+func (c *steppingClock) Now() time.Time {
+	now := c.testClock.Now()
+	c.Advance(c.step)
+	return now
+}
+
+func (*StreamSuite) TestWriteToExpiringDuringWrite(c *gc.C) {
+	clock := &steppingClock{testClock: newTestClock()}
+	cache := lru.New(10, lru.WithExpiry(time.Minute), lru.WithClock(clock))
+	cache.Add("a", 1)
+	clock.Advance(30 * time.Second)
+	cache.Add("b", 2)
+	clock.Advance(29 * time.Second)
+	// "a" expires between one reading of the clock and the next.
+	clock.step = 2 * time.Second
+	var buf bytes.Buffer
+	_, err := cache.WriteTo(&buf)
+	c.Assert(err, gc.IsNil)
+	loaded := lru.New(10)
+	_, err = loaded.ReadFrom(&buf)
+	c.Assert(err, gc.IsNil)
+	c.Check(collectKeys(loaded.Range), gc.DeepEquals, []interface{}{"b", "a"})
+}
+
+func (*StreamSuite) TestWriteToEmpty(c *gc.C) {
+	var buf bytes.Buffer
+	n, err := lru.New(10).WriteTo(&buf)
+	c.Assert(err, gc.IsNil)
+	c.Check(n, gc.Equals, int64(6))
+	c.Check(buf.String(), gc.Equals, "LRU\x00\x02\x00")
+}
+
+func (*StreamSuite) TestWriteToUnregisteredType(c *gc.C) {
+	cache := lru.New(10)
+	cache.Add("a", persistValue{Name: "a"})
+	var buf bytes.Buffer
+	_, err := cache.WriteTo(&buf)
+	c.Check(err, gc.ErrorMatches, "writing value for a: .*type not registered for interface.*")
+}
+
+func (*StreamSuite) TestSyncWriteTo(c *gc.C) {
+	cache := lru.NewSync(10)
+	cache.Add("a", true)
+	var buf bytes.Buffer
+	_, err := cache.WriteTo(&buf)
+	c.Assert(err, gc.IsNil)
+	c.Check(buf.String(), gc.Equals, "LRU\x00\x02\x01\x05\x01\x01a\x03\x01")
+}
+
+func (*StreamSuite) TestReadFrom(c *gc.C) {
+	cache := lru.New(10)
+	values := []interface{}{
+		nil, "s", []byte("b"), true, false,
+		-1, int8(-2), int16(3), int32(-4), int64(5),
+		uint(6), uint8(7), uint16(8), uint32(9), uint64(10),
+		float32(1.5), -2.25, []string{"gob"},
+	}
+	for i, value := range values {
+		cache.Add(i, value)
+	}
+	cache.Get(0)
+	var buf bytes.Buffer
+	written, err := cache.WriteTo(&buf)
+	c.Assert(err, gc.IsNil)
+
+	loaded := lru.New(20)
+	read, err := loaded.ReadFrom(&buf)
+	c.Assert(err, gc.IsNil)
+	c.Check(read, gc.Equals, written)
+	c.Check(loaded.Dump(), gc.DeepEquals, cache.Dump())
+}
+
+func (*StreamSuite) TestReadFromMoreThanFits(c *gc.C) {
+	var buf bytes.Buffer
+	_, err := simpleFullCache().WriteTo(&buf)
+	c.Assert(err, gc.IsNil)
+	loaded := lru.New(3)
+	_, err = loaded.ReadFrom(&buf)
+	c.Assert(err, gc.IsNil)
+	c.Check(collectKeys(loaded.Range), gc.DeepEquals, []interface{}{0, 9, 8})
+}
+
+func (*StreamSuite) TestReadFromBadStream(c *gc.C) {
+	var buf bytes.Buffer
+	cache := lru.New(10)
+	cache.Add("a", 1)
+	cache.Add("b", 2)
+	_, err := cache.WriteTo(&buf)
+	c.Assert(err, gc.IsNil)
+	stream := buf.String()
+
+	for i, test := range []struct {
+		stream string
+		err    string
+	}{{
+		stream: "",
+		err:    "lru: invalid stream: unexpected EOF",
+	}, {
+		stream: "LRX\x00\x01\x00",
+		err:    "lru: invalid stream: bad magic",
+	}, {
+		stream: "LRU\x00\x09\x00",
+		err:    "lru: invalid stream: unknown version 9",
+	}, {
+		stream: stream[:len(stream)-1],
+		err:    "lru: invalid stream: unexpected EOF",
+	}, {
+		stream: stream[:8],
+		err:    "lru: invalid stream: unexpected EOF",
+	}, {
+		stream: "LRU\x00\x01\x03\x01\x01a\x00",
+		err:    "lru: invalid stream: missing value",
+	}, {
+		stream: "LRU\x00\x01\x05\x01\x01a\x00\x00\x00",
+		err:    "lru: invalid stream: 1 bytes left over after entry",
+	}, {
+		stream: "LRU\x00\x01\x04\x01\x05a\x00\x00",
+		err:    "lru: invalid stream: bad length",
+	}, {
+		stream: "LRU\x00\x01\x04\x01\x01a\x63\x00",
+		err:    "lru: invalid stream: unknown type 99",
+	}, {
+		stream: "LRU\x00\x01\x04\x01\x01a\x03\x02\x00",
+		err:    "lru: invalid stream: bad bool",
+	}, {
+		stream: "LRU\x00\x01\xff\xff\xff\xff\x0f",
+		err:    "lru: invalid stream: entry of 4294967295 bytes is too long",
+	}} {
+		c.Logf("test %d", i)
+		_, err := lru.New(10).ReadFrom(bytes.NewBufferString(test.stream))
+		c.Check(err, gc.ErrorMatches, test.err)
+		c.Check(errors.Is(err, lru.ErrBadStream), gc.Equals, true)
+	}
+}
+
+func (*StreamSuite) TestReadFromPartial(c *gc.C) {
+	var buf bytes.Buffer
+	_, err := simpleFullCache().WriteTo(&buf)
+	c.Assert(err, gc.IsNil)
+	stream := buf.Bytes()
+	// The entries before the error are still added.
+	loaded := lru.New(10)
+	_, err = loaded.ReadFrom(bytes.NewReader(stream[:len(stream)-3]))
+	c.Check(errors.Is(err, lru.ErrBadStream), gc.Equals, true)
+	c.Check(loaded.Len(), gc.Equals, 9)
+}
+
+func (*StreamSuite) TestSyncReadFrom(c *gc.C) {
+	cache := lru.NewSync(10)
+	cache.Add("a", 1)
+	cache.Add("b", 2)
+	var buf bytes.Buffer
+	_, err := cache.WriteTo(&buf)
+	c.Assert(err, gc.IsNil)
+	loaded := lru.NewSync(10)
+	_, err = loaded.ReadFrom(&buf)
+	c.Assert(err, gc.IsNil)
+	c.Check(loaded.Dump(), gc.DeepEquals, cache.Dump())
+}
+
+// point has its own binary encoding, as a pair of bytes.
+type point struct {
+	X, Y int8
+}
+
+var pointMarshals int
+
+func (p point) MarshalBinary() ([]byte, error) {
+	pointMarshals++
+	return []byte{byte(p.X), byte(p.Y)}, nil
+}
+
+func (p *point) UnmarshalBinary(data []byte) error {
+	if len(data) != 2 {
+		return fmt.Errorf("bad point %x", data)
+	}
+	p.X, p.Y = int8(data[0]), int8(data[1])
+	return nil
+}
+
+// label is registered as a pointer.
+type label struct {
+	Text string
+}
+
+func (l *label) MarshalBinary() ([]byte, error) {
+	return []byte(l.Text), nil
+}
+
+func (l *label) UnmarshalBinary(data []byte) error {
+	l.Text = string(data)
+	return nil
+}
+
+func init() {
+	lru.RegisterBinary(point{})
+	lru.RegisterBinary(&label{})
+}
+
+func (*StreamSuite) TestBinaryMarshaler(c *gc.C) {
+	cache := lru.New(10)
+	cache.Add(point{1, 2}, point{-3, 4})
+	cache.Add("label", &label{Text: "hello"})
+	pointMarshals = 0
+	var buf bytes.Buffer
+	_, err := cache.WriteTo(&buf)
+	c.Assert(err, gc.IsNil)
+	c.Check(pointMarshals, gc.Equals, 2)
+	c.Check(bytes.Contains(buf.Bytes(), []byte("github.com/juju/lru_test.point\x02\xfd\x04")), gc.Equals, true)
+
+	loaded := lru.New(10)
+	_, err = loaded.ReadFrom(&buf)
+	c.Assert(err, gc.IsNil)
+	checkPeekExists(c, loaded, point{1, 2}, point{-3, 4})
+	value, ok := loaded.Peek("label")
+	c.Assert(ok, gc.Equals, true)
+	c.Check(value, gc.DeepEquals, &label{Text: "hello"})
+}
+
+func (*StreamSuite) TestBinaryUnmarshalError(c *gc.C) {
+	_, err := lru.New(10).ReadFrom(bytes.NewBufferString("LRU\x00\x01" +
+		"\x25\x01\x01p\x11\x1egithub.com/juju/lru_test.point\x01\x00" +
+		"\x00"))
+	c.Check(err, gc.ErrorMatches, "bad point 00")
+}
+
+func (*StreamSuite) TestBinaryNotRegistered(c *gc.C) {
+	_, err := lru.New(10).ReadFrom(bytes.NewBufferString("LRU\x00\x01" +
+		"\x07\x01\x01p\x11\x01x\x00\x00"))
+	c.Check(err, gc.ErrorMatches, "lru: type x not registered with RegisterBinary")
+}
+
+// writeOnly can be marshaled but not unmarshaled.
+type writeOnly struct{}
+
+func (writeOnly) MarshalBinary() ([]byte, error) {
+	return nil, nil
+}
+
+func (*StreamSuite) TestRegisterBinaryNeedsUnmarshaler(c *gc.C) {
+	c.Check(func() { lru.RegisterBinary(writeOnly{}) }, gc.PanicMatches,
+		`lru: RegisterBinary: \*lru_test.writeOnly does not implement encoding.BinaryUnmarshaler`)
+	c.Check(func() { lru.RegisterBinary(&writeOnly{}) }, gc.PanicMatches,
+		`lru: RegisterBinary: \*lru_test.writeOnly does not implement encoding.BinaryUnmarshaler`)
+}
+
+func (*StreamSuite) TestReadFromVersion1(c *gc.C) {
+	// Streams written before the count was added can still be read.
+	cache := lru.New(10)
+	_, err := cache.ReadFrom(bytes.NewBufferString("LRU\x00\x01" +
+		"\x05\x04\x04\x02\x01b" +
+		"\x05\x01\x01a\x04\x02" +
+		"\x00"))
+	c.Assert(err, gc.IsNil)
+	c.Check(cache.Dump(), gc.DeepEquals, []lru.Entry{{Key: 2, Value: []byte("b")}, {Key: "a", Value: 1}})
+}
+
+func (*StreamSuite) TestReadFromVersion2(c *gc.C) {
+	for i, test := range []struct {
+		stream string
+		err    string
+	}{{
+		stream: "LRU\x00\x02",
+		err:    "lru: invalid stream: unexpected EOF",
+	}, {
+		stream: "LRU\x00\x02\x02\x05\x01\x01a\x04\x02",
+		err:    "lru: invalid stream: unexpected EOF",
+	}, {
+		stream: "LRU\x00\x02\x01\x00",
+		err:    "lru: invalid stream: empty entry",
+	}} {
+		c.Logf("test %d", i)
+		_, err := lru.New(10).ReadFrom(bytes.NewBufferString(test.stream))
+		c.Check(err, gc.ErrorMatches, test.err)
+	}
+	// Anything after the entries is left unread.
+	r := bytes.NewBufferString("LRU\x00\x02\x01\x05\x01\x01a\x04\x02after")
+	n, err := lru.New(10).ReadFrom(r)
+	c.Assert(err, gc.IsNil)
+	c.Check(n, gc.Equals, int64(12))
+	c.Check(r.String(), gc.Equals, "after")
+}
+
+func (*StreamSuite) TestReadFromSkipsWhatWontFit(c *gc.C) {
+	var buf bytes.Buffer
+	_, err := simpleFullCache().WriteTo(&buf)
+	c.Assert(err, gc.IsNil)
+	var evicted []interface{}
+	loaded := lru.New(3, lru.WithOnEvict(func(key, _ interface{}, _ lru.EvictionReason) {
+		evicted = append(evicted, key)
+	}))
+	_, err = loaded.ReadFrom(&buf)
+	c.Assert(err, gc.IsNil)
+	c.Check(collectKeys(loaded.Range), gc.DeepEquals, []interface{}{0, 9, 8})
+	c.Check(evicted, gc.HasLen, 0)
+}
+
+// checkReadFromMatchesAdds checks that reading a stream of keys into the
+// cache made by newCache leaves it holding the same entries, in the same
+// order, as adding them one by one.
+func checkReadFromMatchesAdds(c *gc.C, keys []string, newCache func() *lru.LRU) {
+	source := lru.New(len(keys))
+	for _, key := range keys {
+		source.Add(key, "new")
+	}
+	var buf bytes.Buffer
+	_, err := source.WriteTo(&buf)
+	c.Assert(err, gc.IsNil)
+	loaded := newCache()
+	_, err = loaded.ReadFrom(&buf)
+	c.Assert(err, gc.IsNil)
+	added := newCache()
+	for _, key := range keys {
+		added.Add(key, "new")
+	}
+	c.Check(collectKeys(loaded.Range), gc.DeepEquals, collectKeys(added.Range))
+}
+
+func (*StreamSuite) TestReadFromSIEVE(c *gc.C) {
+	// Re-adding y marks it as visited, which saves it from eviction, so it
+	// can't be skipped.
+	checkReadFromMatchesAdds(c, []string{"y", "x", "z", "a"}, func() *lru.LRU {
+		cache := lru.New(3, lru.WithSIEVE())
+		cache.Add("x", "old")
+		cache.Add("y", "old")
+		cache.Add("z", "old")
+		return cache
+	})
+}
+
+func (*StreamSuite) TestReadFromPriorities(c *gc.C) {
+	// Re-adding an entry changes its priority, so what is evicted depends
+	// on every entry in the stream.
+	checkReadFromMatchesAdds(c, []string{"a", "y", "x", "z"}, func() *lru.LRU {
+		cache := lru.New(3)
+		cache.AddWithPriority("x", "old", lru.PriorityLow)
+		cache.AddWithPriority("y", "old", lru.PriorityLow)
+		cache.AddWithPriority("z", "old", lru.PriorityLow)
+		return cache
+	})
+}
@@ -5,12 +5,17 @@ package lru
 
 import (
 	"fmt"
+	"sync/atomic"
+	"time"
+	"unsafe"
 )
 
 // StringCache tracks a limited number of strings.
 // Use Intern() to get a saved version of the string, such that
-//   x := cache.Intern(s1)
-//   y := cache.Intern(s2)
+//
+//	x := cache.Intern(s1)
+//	y := cache.Intern(s2)
+//
 // Now x and y will use the same underlying memory if s1 == s2.
 // We track a map into a doubly linked list, moving accessed (or recently
 // added) strings to the front of the list, and using the expiry at the end of
@@ -18,23 +23,66 @@ import (
 // Note that StringCache is *not* thread safe, some form of mutex is necessary
 // if you want to access it from multiple threads.
 type StringCache struct {
-	maxSize   int
-	size      int
-	hitCount  int64
-	missCount int64
+	maxSize int
+	size    int
+	// hitCount, missCount and savedBytes are updated atomically, so that
+	// reading them doesn't race with Intern.
+	hitCount   int64
+	missCount  int64
+	savedBytes int64
+	// heldBytes is the total length of the strings in the cache.
+	heldBytes int64
 	buf       []stringElem
 	values    map[string]uint32
 	root      *stringElem
+
+	// added is parallel to buf, holding when each string was interned (as
+	// UnixNano). It is only allocated when maxAge is set.
+	added  []int64
+	maxAge time.Duration
+	clock  Clock
+
+	// logger is set by WithStringLogger.
+	logger Logger
+}
+
+// StringOption configures a StringCache.
+type StringOption func(*StringCache)
+
+// WithMaxAge stops a StringCache from keeping a string for longer than maxAge
+// after it was interned, even if it is still being used. Interning a string
+// that is too old is counted as a miss, and the cache keeps the newly passed
+// string instead. Each time a new string is interned, any that are too old at
+// the least recently used end of the cache are removed first, so a cache that
+// is still being used lets go of the strings that aren't. A string that is in
+// use is only replaced the next time it is interned, and a cache that is no
+// longer being used keeps its strings until RemoveExpired is called.
+func WithMaxAge(maxAge time.Duration) StringOption {
+	return func(sc *StringCache) {
+		sc.maxAge = maxAge
+	}
+}
+
+// WithStringClock sets the clock used by WithMaxAge, which defaults to
+// WallClock.
+func WithStringClock(clock Clock) StringOption {
+	return func(sc *StringCache) {
+		sc.clock = clock
+	}
 }
 
 // NewStringCache creates a cache for string objects that will hold no-more
-// than 'size' strings.
-func NewStringCache(size int) *StringCache {
+// than 'size' strings, configured by any options given.
+func NewStringCache(size int, options ...StringOption) *StringCache {
 	if size > maxLRUSize || size <= 0 {
 		panic("size must not be <= 0 or >= 2^32")
 	}
 	cache := &StringCache{
 		maxSize: size,
+		clock:   WallClock,
+	}
+	for _, option := range options {
+		option(cache)
 	}
 	cache.init()
 	return cache
@@ -46,8 +94,9 @@ func NewStringCache(size int) *StringCache {
 // For a complete double-linked list implementation, see the golang standard
 // library "container/list". However, by inlining some of the functionality,
 // we are able to save on storage size, and remove levels of indirection.
-// The only function we need is 'MoveToFront', as we also never remove items,
-// instead replacing the content when the old value is expired.
+// The only function we need is 'MoveToFront', as we only remove items when
+// they are too old (see WithMaxAge) or are removed with Remove, and otherwise
+// replace the content when the old value is expired.
 type stringElem struct {
 	value      string
 	prev, next uint32
@@ -60,7 +109,11 @@ func (sc *StringCache) init() {
 	}
 	sc.values = make(map[string]uint32, initialSize)
 	sc.buf = make([]stringElem, initialSize)
+	if sc.maxAge > 0 {
+		sc.added = make([]int64, initialSize)
+	}
 	sc.size = 0
+	sc.heldBytes = 0
 	sc.root = &sc.buf[0]
 	sc.root.next = 0
 	sc.root.prev = 0
@@ -71,23 +124,89 @@ func (sc *StringCache) Len() int {
 	return sc.size
 }
 
+// SizeBytes returns an estimate of the memory used by the cache: its buffer and
+// map, and the strings it holds.
+func (sc *StringCache) SizeBytes() int {
+	size := int(unsafe.Sizeof(*sc))
+	size += cap(sc.buf)*int(unsafe.Sizeof(stringElem{})) + cap(sc.added)*8
+	size += len(sc.values) * mapEntryOverhead
+	return size + int(sc.heldBytes)
+}
+
+// stringEntryOverhead estimates the bytes used by each string in a
+// StringCache, not counting the string's data: its slot, and its entry in the
+// map.
+const stringEntryOverhead = int64(unsafe.Sizeof(stringElem{})) + mapEntryOverhead
+
+// TotalBytes returns the total length of the strings in the cache, plus an
+// estimate of what it costs to keep track of each of them. Unlike SizeBytes,
+// it doesn't count slots that have been allocated but aren't in use, so it
+// reflects the memory spent on the strings held, rather than the cache as a
+// whole. It is O(1).
+func (sc *StringCache) TotalBytes() int64 {
+	perString := stringEntryOverhead
+	if sc.added != nil {
+		perString += 8
+	}
+	return sc.heldBytes + int64(sc.size)*perString
+}
+
 // HitCounts is used to track how well this cache is working
 type HitCounts struct {
 	Hit, Miss int64
 }
 
 // HitCounts gives information about accesses to the cache. The total number of
-// calls to Intern can be computed by adding Hit and Miss.
+// calls to Intern can be computed by adding Hit and Miss. It is safe to call at
+// the same time as other methods.
 func (sc *StringCache) HitCounts() HitCounts {
 	return HitCounts{
-		Hit:  sc.hitCount,
-		Miss: sc.missCount,
+		Hit:  atomic.LoadInt64(&sc.hitCount),
+		Miss: atomic.LoadInt64(&sc.missCount),
+	}
+}
+
+// ResetHitCounts returns the same counts as HitCounts, and sets them back to
+// zero, so that each call reports the accesses since the last. Like
+// HitCounts, it is safe to call at the same time as other methods, and no
+// accesses are lost.
+func (sc *StringCache) ResetHitCounts() HitCounts {
+	return HitCounts{
+		Hit:  atomic.SwapInt64(&sc.hitCount, 0),
+		Miss: atomic.SwapInt64(&sc.missCount, 0),
+	}
+}
+
+// DedupStats describes how much memory interning strings has saved.
+type DedupStats struct {
+	// SavedBytes is the total length of the strings Intern has found in the
+	// cache, which would otherwise each have been kept as a separate copy.
+	SavedBytes int64
+	// HeldBytes is the total length of the strings in the cache, which is
+	// what it costs to keep them.
+	HeldBytes int64
+}
+
+// DedupStats returns how many bytes of strings interning has saved, and how
+// many the cache holds. Strings that have expired are not counted as saved.
+func (sc *StringCache) DedupStats() DedupStats {
+	return DedupStats{
+		SavedBytes: atomic.LoadInt64(&sc.savedBytes),
+		HeldBytes:  sc.heldBytes,
 	}
 }
 
 // Validate checks invariants to make sure the double-linked list is properly
 // linked, and that the values map to the correct element.
 func (sc *StringCache) Validate() error {
+	err := sc.validate()
+	if err != nil && sc.logger != nil {
+		sc.logger.Debugf("lru: StringCache failed validation: %v", err)
+	}
+	return err
+}
+
+func (sc *StringCache) validate() error {
 	count := 0
 	if sc.root != &sc.buf[0] {
 		return fmt.Errorf("error, root=%p, not buf[0]=%p", sc.root, &sc.buf[0])
@@ -143,6 +262,41 @@ func (sc *StringCache) realloc(nextSize int) {
 	copy(newBuf, sc.buf)
 	sc.buf = newBuf
 	sc.root = &newBuf[0]
+	if sc.added != nil {
+		newAdded := make([]int64, nextSize)
+		copy(newAdded, sc.added)
+		sc.added = newAdded
+	}
+}
+
+// SetMaxSize changes how many strings the cache can hold. If it holds more
+// than size, the least recently used are evicted, and the buffer is shrunk to
+// free their slots. If size is larger, the buffer grows as strings are added,
+// as it does when the cache is new.
+func (sc *StringCache) SetMaxSize(size int) {
+	if size > maxLRUSize || size <= 0 {
+		panic("size must not be <= 0 or >= 2^32")
+	}
+	if sc.logger != nil {
+		sc.logger.Debugf("lru: StringCache max size changed from %d to %d", sc.maxSize, size)
+	}
+	sc.maxSize = size
+	for sc.size > size {
+		sc.removeElem(sc.root.prev)
+	}
+	if len(sc.buf) > size+1 {
+		// The strings left are all in the first size slots, as removeElem
+		// always fills the hole it leaves.
+		newBuf := make([]stringElem, size+1)
+		copy(newBuf, sc.buf)
+		sc.buf = newBuf
+		sc.root = &newBuf[0]
+		if sc.added != nil {
+			newAdded := make([]int64, size+1)
+			copy(newAdded, sc.added)
+			sc.added = newAdded
+		}
+	}
 }
 
 // Intern takes a string, and returns either the cached copy of the string, or
@@ -150,12 +304,62 @@ func (sc *StringCache) realloc(nextSize int) {
 // string was seen, so that strings aren't cached forever.
 func (sc *StringCache) Intern(v string) string {
 	if elem, ok := sc.values[v]; ok {
-		sc.moveToFront(elem)
-		value := sc.buf[elem].value
-		sc.hitCount++
-		return value
+		if value, ok := sc.hit(elem); ok {
+			return value
+		}
+		sc.renew(elem, v)
+		return v
+	}
+	return sc.insert(v)
+}
+
+// InternBytes is just like Intern, except it takes the string as a byte
+// slice, such as one just read from the network. The slice is only copied
+// into a new string when it isn't already in the cache, so a hit doesn't
+// allocate. The slice is not kept, and may be reused once it returns.
+func (sc *StringCache) InternBytes(b []byte) string {
+	// The compiler doesn't allocate for string(b) in a map index.
+	if elem, ok := sc.values[string(b)]; ok {
+		if value, ok := sc.hit(elem); ok {
+			return value
+		}
+		v := string(b)
+		sc.renew(elem, v)
+		return v
+	}
+	return sc.insert(string(b))
+}
+
+// hit moves elem to the front, and returns its value, counting a hit, unless
+// it has expired.
+func (sc *StringCache) hit(elem uint32) (string, bool) {
+	sc.moveToFront(elem)
+	if sc.added != nil && sc.expiredAt(elem, sc.clock.Now().UnixNano()) {
+		return "", false
+	}
+	value := sc.buf[elem].value
+	atomic.AddInt64(&sc.hitCount, 1)
+	atomic.AddInt64(&sc.savedBytes, int64(len(value)))
+	return value, true
+}
+
+// renew replaces the expired copy of v in elem with v, so the old one can be
+// freed, counting a miss. The map keeps the string it was given as the key,
+// so that has to be replaced too.
+func (sc *StringCache) renew(elem uint32, v string) {
+	atomic.AddInt64(&sc.missCount, 1)
+	sc.buf[elem].value = v
+	sc.values[v] = elem
+	sc.added[elem] = sc.clock.Now().UnixNano()
+}
+
+// insert adds v, which is not in the cache, evicting the least recently used
+// string if it is full, counting a miss.
+func (sc *StringCache) insert(v string) string {
+	atomic.AddInt64(&sc.missCount, 1)
+	if sc.added != nil {
+		sc.removeExpiredTail(sc.clock.Now().UnixNano())
 	}
-	sc.missCount++
 	var elem uint32
 	if sc.size < sc.maxSize {
 		sc.size++
@@ -168,20 +372,120 @@ func (sc *StringCache) Intern(v string) string {
 		elem = sc.root.prev
 		e := &sc.buf[elem]
 		delete(sc.values, e.value)
+		sc.heldBytes -= int64(len(e.value))
 		e.value = v
 	}
+	sc.heldBytes += int64(len(v))
 	sc.moveToFront(elem)
 	sc.values[v] = elem
+	if sc.added != nil {
+		sc.added[elem] = sc.clock.Now().UnixNano()
+	}
 	return v
 }
 
 // Contains returns true if the string is in the cache. It does not change
 // information about recently-used.
 func (sc *StringCache) Contains(v string) bool {
-	_, ok := sc.values[v]
+	elem, ok := sc.values[v]
+	if ok && sc.added != nil {
+		return !sc.expiredAt(elem, sc.clock.Now().UnixNano())
+	}
 	return ok
 }
 
+// Remove removes v from the cache, so that the cache no longer holds on to it,
+// and returns whether it was there.
+func (sc *StringCache) Remove(v string) bool {
+	elem, ok := sc.values[v]
+	if !ok {
+		return false
+	}
+	sc.removeElem(elem)
+	return true
+}
+
+// Clear removes every string from the cache, keeping the memory allocated for
+// them so the cache can be filled again without growing. The hit counts are
+// not changed; call ResetHitCounts as well to start them again.
+func (sc *StringCache) Clear() {
+	for v := range sc.values {
+		delete(sc.values, v)
+	}
+	for elem := 1; elem <= sc.size; elem++ {
+		sc.buf[elem] = stringElem{}
+		if sc.added != nil {
+			sc.added[elem] = 0
+		}
+	}
+	sc.size = 0
+	sc.heldBytes = 0
+	sc.root.next = 0
+	sc.root.prev = 0
+}
+
+// RemoveExpired removes every string that was interned more than the
+// WithMaxAge duration ago, and returns how many were removed.
+func (sc *StringCache) RemoveExpired() int {
+	if sc.added == nil {
+		return 0
+	}
+	now := sc.clock.Now().UnixNano()
+	removed := 0
+	for elem := sc.root.next; elem != 0; {
+		next := sc.buf[elem].next
+		if sc.expiredAt(elem, now) {
+			if next == uint32(sc.size) {
+				// removeElem is about to move the last element into this slot
+				next = elem
+			}
+			sc.removeElem(elem)
+			removed++
+		}
+		elem = next
+	}
+	return removed
+}
+
+// removeExpiredTail removes the least recently used strings for as long as
+// they are too old at the time now.
+func (sc *StringCache) removeExpiredTail(now int64) {
+	for sc.root.prev != 0 && sc.expiredAt(sc.root.prev, now) {
+		sc.removeElem(sc.root.prev)
+	}
+}
+
+// expiredAt returns whether elem is too old to be used at the time now.
+func (sc *StringCache) expiredAt(elem uint32, now int64) bool {
+	return now >= sc.added[elem]+int64(sc.maxAge)
+}
+
+// removeElem unlinks elem from the list and forgets its value, moving the last
+// element into its slot. See LRU.removeElem.
+func (sc *StringCache) removeElem(elem uint32) {
+	e := &sc.buf[elem]
+	sc.buf[e.prev].next = e.next
+	sc.buf[e.next].prev = e.prev
+	delete(sc.values, e.value)
+	sc.heldBytes -= int64(len(e.value))
+	last := uint32(sc.size)
+	if elem != last {
+		moved := sc.buf[last]
+		sc.buf[elem] = moved
+		sc.buf[moved.prev].next = elem
+		sc.buf[moved.next].prev = elem
+		sc.values[moved.value] = elem
+		if sc.added != nil {
+			sc.added[elem] = sc.added[last]
+		}
+	}
+	sc.buf[last] = stringElem{}
+	if sc.added != nil {
+		sc.added[last] = 0
+	}
+	sc.size--
+}
+
 func (sc *StringCache) moveToFront(elem uint32) {
 	if sc.root.next == elem {
 		// we're already at the front
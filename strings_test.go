@@ -6,7 +6,10 @@ package lru_test
 import (
 	"fmt"
 	"math/rand"
+	"runtime"
 	"sync"
+	"testing"
+	"time"
 	"unsafe"
 
 	gc "gopkg.in/check.v1"
@@ -49,6 +52,43 @@ func (*StringsSuite) TestIntern(c *gc.C) {
 	c.Check(cache.Contains(str1), gc.Equals, true)
 }
 
+func (*StringsSuite) TestInternBytes(c *gc.C) {
+	cache := lru.NewStringCache(10)
+	b := []byte("foobar")
+	str1 := cache.InternBytes(b)
+	c.Check(str1, gc.Equals, "foobar")
+	// The bytes are copied, not kept.
+	b[0] = 'g'
+	c.Check(str1, gc.Equals, "foobar")
+	str2 := cache.InternBytes([]byte("foobar"))
+	c.Check(isSameStr(str1, str2), gc.Equals, true)
+	c.Check(isSameStr(str1, cache.Intern("foobar")), gc.Equals, true)
+	c.Check(cache.HitCounts(), gc.Equals, lru.HitCounts{Hit: 2, Miss: 1})
+	c.Assert(cache.Validate(), gc.IsNil)
+}
+
+func (*StringsSuite) TestInternBytesHitDoesNotAllocate(c *gc.C) {
+	cache := lru.NewStringCache(10)
+	b := []byte("foobar")
+	cache.InternBytes(b)
+	allocs := testing.AllocsPerRun(100, func() {
+		cache.InternBytes(b)
+	})
+	c.Check(allocs, gc.Equals, float64(0))
+}
+
+func (*StringsSuite) TestInternBytesMaxAge(c *gc.C) {
+	clock := newTestClock()
+	cache := lru.NewStringCache(10, lru.WithMaxAge(time.Minute), lru.WithStringClock(clock))
+	str1 := cache.InternBytes([]byte("foobar"))
+	clock.Advance(time.Minute)
+	str2 := cache.InternBytes([]byte("foobar"))
+	c.Check(str2, gc.Equals, "foobar")
+	c.Check(isSameStr(str1, str2), gc.Equals, false)
+	c.Check(isSameStr(str2, cache.Intern("foobar")), gc.Equals, true)
+	c.Check(cache.HitCounts(), gc.Equals, lru.HitCounts{Hit: 1, Miss: 2})
+}
+
 func (*StringsSuite) TestInternMaxSize(c *gc.C) {
 	cache := lru.NewStringCache(5)
 	for i := 0; i < 30; i++ {
@@ -91,6 +131,99 @@ func (*StringsSuite) TestInternAbuse(c *gc.C) {
 	c.Assert(cache.Validate(), gc.IsNil)
 }
 
+func (*StringsSuite) TestRemove(c *gc.C) {
+	cache := lru.NewStringCache(3)
+	for _, s := range []string{"a", "bb", "ccc"} {
+		cache.Intern(s)
+	}
+	c.Check(cache.Remove("bb"), gc.Equals, true)
+	c.Assert(cache.Validate(), gc.IsNil)
+	c.Check(cache.Remove("bb"), gc.Equals, false)
+	c.Check(cache.Remove("d"), gc.Equals, false)
+	c.Check(cache.Len(), gc.Equals, 2)
+	c.Check(cache.Contains("bb"), gc.Equals, false)
+	c.Check(cache.DedupStats().HeldBytes, gc.Equals, int64(4))
+	// The freed slot is used again before anything is evicted.
+	cache.Intern("d")
+	c.Check(cache.Contains("a"), gc.Equals, true)
+	cache.Intern("e")
+	c.Check(cache.Contains("a"), gc.Equals, false)
+	c.Check(cache.Len(), gc.Equals, 3)
+	c.Assert(cache.Validate(), gc.IsNil)
+	// Removing the last slot, and then the only one left.
+	c.Check(cache.Remove("e"), gc.Equals, true)
+	c.Check(cache.Remove("ccc"), gc.Equals, true)
+	c.Check(cache.Remove("d"), gc.Equals, true)
+	c.Check(cache.Len(), gc.Equals, 0)
+	c.Assert(cache.Validate(), gc.IsNil)
+}
+
+func (*StringsSuite) TestClear(c *gc.C) {
+	cache := lru.NewStringCache(1000)
+	for i := 0; i < 500; i++ {
+		cache.Intern(fmt.Sprint(i))
+	}
+	cache.Intern("0")
+	cache.Clear()
+	c.Assert(cache.Validate(), gc.IsNil)
+	c.Check(cache.Len(), gc.Equals, 0)
+	c.Check(cache.Contains("0"), gc.Equals, false)
+	c.Check(cache.DedupStats().HeldBytes, gc.Equals, int64(0))
+	c.Check(cache.HitCounts(), gc.Equals, lru.HitCounts{Hit: 1, Miss: 500})
+	// The buffer is kept, even though the strings aren't.
+	c.Check(cache.SizeBytes() > lru.NewStringCache(1000).SizeBytes(), gc.Equals, true)
+	for i := 0; i < 10; i++ {
+		s := fmt.Sprint(i)
+		c.Check(isSameStr(cache.Intern(s), s), gc.Equals, true)
+	}
+	c.Check(cache.Len(), gc.Equals, 10)
+	c.Assert(cache.Validate(), gc.IsNil)
+}
+
+func (*StringsSuite) TestSetMaxSize(c *gc.C) {
+	cache := lru.NewStringCache(10)
+	for i := 0; i < 10; i++ {
+		cache.Intern(fmt.Sprint(i))
+	}
+	cache.Intern("0")
+	cache.SetMaxSize(3)
+	c.Assert(cache.Validate(), gc.IsNil)
+	c.Check(cache.Len(), gc.Equals, 3)
+	for i, expected := range []bool{true, false, false, false, false, false, false, false, true, true} {
+		c.Check(cache.Contains(fmt.Sprint(i)), gc.Equals, expected, gc.Commentf("string %d", i))
+	}
+	cache.Intern("a")
+	c.Check(cache.Len(), gc.Equals, 3)
+	c.Check(cache.Contains("8"), gc.Equals, false)
+	c.Assert(cache.Validate(), gc.IsNil)
+
+	cache.SetMaxSize(200)
+	for i := 0; i < 300; i++ {
+		cache.Intern(fmt.Sprint(i))
+		c.Assert(cache.Validate(), gc.IsNil)
+	}
+	c.Check(cache.Len(), gc.Equals, 200)
+	c.Check(cache.Contains("299"), gc.Equals, true)
+	c.Check(cache.Contains("99"), gc.Equals, false)
+	c.Check(func() { cache.SetMaxSize(0) }, gc.PanicMatches, "size must not be <= 0 or >= 2\\^32")
+}
+
+func (*StringsSuite) TestSetMaxSizeMaxAge(c *gc.C) {
+	clock := newTestClock()
+	cache := lru.NewStringCache(10, lru.WithMaxAge(time.Minute), lru.WithStringClock(clock))
+	for i := 0; i < 10; i++ {
+		cache.Intern(fmt.Sprint(i))
+		clock.Advance(time.Second)
+	}
+	cache.SetMaxSize(5)
+	c.Assert(cache.Validate(), gc.IsNil)
+	// The times the strings were interned stay with them.
+	clock.Advance(time.Minute - 4*time.Second)
+	c.Check(cache.RemoveExpired(), gc.Equals, 2)
+	c.Check(cache.Contains("7"), gc.Equals, true)
+	c.Assert(cache.Validate(), gc.IsNil)
+}
+
 func (*StringsSuite) TestInternAndPrealloc(c *gc.C) {
 	str1 := fmt.Sprintf("foo%s", "bar")
 	str2 := fmt.Sprintf("foo%s", "bar")
@@ -122,6 +255,140 @@ func (*StringsSuite) TestHitCount(c *gc.C) {
 	c.Assert(cache.Validate(), gc.IsNil)
 }
 
+func (*StringsSuite) TestResetHitCounts(c *gc.C) {
+	cache := lru.NewStringCache(5)
+	cache.Intern("a")
+	cache.Intern("a")
+	c.Check(cache.ResetHitCounts(), gc.Equals, lru.HitCounts{Hit: 1, Miss: 1})
+	c.Check(cache.HitCounts(), gc.Equals, lru.HitCounts{})
+	cache.Intern("a")
+	c.Check(cache.ResetHitCounts(), gc.Equals, lru.HitCounts{Hit: 1})
+}
+
+func (*StringsSuite) TestHitCountsConcurrent(c *gc.C) {
+	cache := lru.NewStringCache(10)
+	var mu sync.Mutex
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 1000; i++ {
+			mu.Lock()
+			cache.Intern("a")
+			mu.Unlock()
+		}
+	}()
+	// Reading the counts needs no lock.
+	var total lru.HitCounts
+	for running := true; running; {
+		select {
+		case <-done:
+			running = false
+		default:
+		}
+		counts := cache.ResetHitCounts()
+		total.Hit += counts.Hit
+		total.Miss += counts.Miss
+	}
+	c.Check(total, gc.Equals, lru.HitCounts{Hit: 999, Miss: 1})
+}
+
+func (*StringsSuite) TestInternMaxAge(c *gc.C) {
+	clock := newTestClock()
+	cache := lru.NewStringCache(10, lru.WithMaxAge(time.Minute), lru.WithStringClock(clock))
+	str1 := fmt.Sprintf("foo%s", "bar")
+	str2 := fmt.Sprintf("foo%s", "bar")
+	str3 := fmt.Sprintf("foo%s", "bar")
+	c.Check(isSameStr(cache.Intern(str1), str1), gc.Equals, true)
+	clock.Advance(30 * time.Second)
+	c.Check(isSameStr(cache.Intern(str2), str1), gc.Equals, true)
+	// Even though it is in use, it is dropped once it is too old.
+	clock.Advance(30 * time.Second)
+	c.Check(cache.Contains(str1), gc.Equals, false)
+	c.Check(isSameStr(cache.Intern(str2), str2), gc.Equals, true)
+	c.Check(isSameStr(cache.Intern(str3), str2), gc.Equals, true)
+	c.Check(cache.HitCounts(), gc.Equals, lru.HitCounts{Hit: 2, Miss: 2})
+	c.Check(cache.Len(), gc.Equals, 1)
+	c.Check(cache.Validate(), gc.IsNil)
+}
+
+func (*StringsSuite) TestInternMaxAgeFreesOldCopy(c *gc.C) {
+	clock := newTestClock()
+	cache := lru.NewStringCache(10, lru.WithMaxAge(time.Minute), lru.WithStringClock(clock))
+	freed := make(chan struct{})
+	func() {
+		data := new([64]byte)
+		copy(data[:], "foobar")
+		runtime.SetFinalizer(data, func(*[64]byte) { close(freed) })
+		b := data[:]
+		cache.Intern(*(*string)(unsafe.Pointer(&b)))
+	}()
+	clock.Advance(time.Minute)
+	str := string(make([]byte, 64))
+	str = "foobar" + str[6:]
+	c.Check(isSameStr(cache.Intern(str), str), gc.Equals, true)
+	// Nothing in the cache may still refer to the expired copy, but the
+	// cache itself must not be freed.
+	defer runtime.KeepAlive(cache)
+	for i := 0; i < 50; i++ {
+		runtime.GC()
+		select {
+		case <-freed:
+			return
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	c.Fatalf("expired string was not freed")
+}
+
+func (*StringsSuite) TestRemoveExpired(c *gc.C) {
+	clock := newTestClock()
+	cache := lru.NewStringCache(10, lru.WithMaxAge(time.Minute), lru.WithStringClock(clock))
+	for i := 0; i < 5; i++ {
+		cache.Intern(fmt.Sprint(i))
+	}
+	clock.Advance(30 * time.Second)
+	for i := 5; i < 8; i++ {
+		cache.Intern(fmt.Sprint(i))
+	}
+	// Using them doesn't keep them around.
+	for i := 0; i < 8; i++ {
+		cache.Intern(fmt.Sprint(i))
+	}
+	clock.Advance(30 * time.Second)
+	c.Check(cache.RemoveExpired(), gc.Equals, 5)
+	c.Check(cache.Len(), gc.Equals, 3)
+	c.Check(cache.Validate(), gc.IsNil)
+	for i := 0; i < 8; i++ {
+		c.Check(cache.Contains(fmt.Sprint(i)), gc.Equals, i >= 5)
+	}
+	c.Check(lru.NewStringCache(10).RemoveExpired(), gc.Equals, 0)
+}
+
+func (*StringsSuite) TestInternRemovesExpiredTail(c *gc.C) {
+	clock := newTestClock()
+	cache := lru.NewStringCache(10, lru.WithMaxAge(time.Minute), lru.WithStringClock(clock))
+	cache.Intern("a")
+	cache.Intern("b")
+	clock.Advance(30 * time.Second)
+	cache.Intern("c")
+	cache.Intern("a")
+	clock.Advance(30 * time.Second)
+	// b is too old, and least recently used, so it goes as soon as anything
+	// new is interned. a is too old, but was used since c was interned, so
+	// it stays until it is next interned.
+	cache.Intern("d")
+	c.Check(cache.Len(), gc.Equals, 3)
+	c.Check(cache.DedupStats().HeldBytes, gc.Equals, int64(3))
+	c.Check(cache.Contains("b"), gc.Equals, false)
+	c.Check(cache.Contains("c"), gc.Equals, true)
+	c.Check(cache.Validate(), gc.IsNil)
+	clock.Advance(30 * time.Second)
+	cache.Intern("e")
+	c.Check(cache.Len(), gc.Equals, 2)
+	c.Check(cache.Contains("d"), gc.Equals, true)
+	c.Check(cache.Validate(), gc.IsNil)
+}
+
 func (*StringsSuite) TestInternMultithreaded(c *gc.C) {
 	const totalKeys = 100000
 	const totalUniqueKeys = 1000
@@ -339,3 +606,53 @@ func (*BenchmarkStrings) BenchmarkInternMemSize(c *gc.C) {
 	c.StopTimer()
 	c.Assert(cache.Validate(), gc.IsNil)
 }
+
+func (*StringsSuite) TestSizeBytes(c *gc.C) {
+	cache := lru.NewStringCache(1000)
+	empty := cache.SizeBytes()
+	cache.Intern(string(make([]byte, 10000)))
+	c.Check(cache.SizeBytes() >= empty+10000, gc.Equals, true)
+}
+
+func (*StringsSuite) TestTotalBytes(c *gc.C) {
+	cache := lru.NewStringCache(1000)
+	c.Check(cache.TotalBytes(), gc.Equals, int64(0))
+	cache.Intern("abc")
+	overhead := cache.TotalBytes() - 3
+	c.Check(overhead > 0, gc.Equals, true)
+	cache.Intern(string(make([]byte, 3000)))
+	cache.Intern("abc")
+	c.Check(cache.TotalBytes(), gc.Equals, 3003+2*overhead)
+	cache.Remove("abc")
+	c.Check(cache.TotalBytes(), gc.Equals, 3000+overhead)
+	// Slots that aren't used aren't counted.
+	c.Check(cache.TotalBytes() < int64(cache.SizeBytes()), gc.Equals, true)
+	cache.Clear()
+	c.Check(cache.TotalBytes(), gc.Equals, int64(0))
+}
+
+func (*StringsSuite) TestDedupStats(c *gc.C) {
+	cache := lru.NewStringCache(2)
+	cache.Intern("abc")
+	c.Check(cache.DedupStats(), gc.Equals, lru.DedupStats{HeldBytes: 3})
+	cache.Intern("abc")
+	cache.Intern("abc")
+	cache.Intern("de")
+	c.Check(cache.DedupStats(), gc.Equals, lru.DedupStats{SavedBytes: 6, HeldBytes: 5})
+	// Evicting abc stops it being held, but what was saved stays saved.
+	cache.Intern("f")
+	c.Check(cache.DedupStats(), gc.Equals, lru.DedupStats{SavedBytes: 6, HeldBytes: 3})
+}
+
+func (*StringsSuite) TestDedupStatsMaxAge(c *gc.C) {
+	clock := newTestClock()
+	cache := lru.NewStringCache(10, lru.WithMaxAge(time.Minute), lru.WithStringClock(clock))
+	cache.Intern("abc")
+	clock.Advance(time.Minute)
+	// An expired string isn't a saving.
+	cache.Intern("abc")
+	c.Check(cache.DedupStats(), gc.Equals, lru.DedupStats{HeldBytes: 3})
+	clock.Advance(time.Minute)
+	c.Check(cache.RemoveExpired(), gc.Equals, 1)
+	c.Check(cache.DedupStats(), gc.Equals, lru.DedupStats{})
+}
@@ -0,0 +1,659 @@
+// Copyright 2026 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package lru
+
+import (
+	"context"
+	"io"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// SyncLRU is an LRU that is safe for concurrent use from multiple goroutines.
+// A single read-write mutex guards the whole cache. Methods that modify the
+// cache hold it exclusively for the duration of the call, while Get, Peek, Len
+// and the iteration methods only take a read lock, so lookups can proceed in
+// parallel.
+// Because Get does not hold the exclusive lock, it can't move the entry to
+// the front of the recency list itself. Instead it records the access in one
+// of several striped buffers, each with its own small lock, so concurrent
+// Gets rarely contend with each other. When a buffer fills up, its accesses
+// are applied in a batch if the exclusive lock is free; if it is busy, the
+// oldest accesses in the buffer are overwritten instead of waiting. Every
+// method that takes the exclusive lock first applies all buffered accesses.
+// This means recency is tracked approximately: methods that only read the
+// recency order (PeekMostRecentN, Range, etc) may not yet reflect recent
+// Gets, and under heavy contention some accesses are dropped, so evictions
+// are only nearly LRU.
+// Methods that take a callback hold the lock while it runs, so the callback
+// must not call back into the SyncLRU.
+type SyncLRU struct {
+	mu  sync.RWMutex
+	lru *LRU
+
+	// stripes record the keys returned by Get that have not yet been moved
+	// to the front of the recency list.
+	stripes []accessStripe
+
+	// reaperMu guards reaperStop and reaperDone, which are used to stop the
+	// goroutine started by StartReaper.
+	reaperMu   sync.Mutex
+	reaperStop chan struct{}
+	reaperDone chan struct{}
+
+	// waiters holds the channels of GetWait calls blocked on each key. It is
+	// guarded by the exclusive lock.
+	waiters map[interface{}][]chan interface{}
+}
+
+// accessStripe is a ring buffer of accessed keys.
+type accessStripe struct {
+	mu    sync.Mutex
+	keys  [accessStripeSize]interface{}
+	start int
+	count int
+	// pad keeps stripes on separate cache lines
+	_ [64]byte
+}
+
+// accessStripeSize is how many Gets a stripe records before they are applied.
+const accessStripeSize = 32
+
+// NewSync creates a SyncLRU that will hold no more than the given number of
+// items, configured by any options given. See New.
+func NewSync(size int, options ...Option) *SyncLRU {
+	s := &SyncLRU{}
+	s.init(New(size, options...))
+	return s
+}
+
+// NewSyncWithMaxCost creates a SyncLRU that keeps the total cost of its
+// entries within maxCost. See NewWithMaxCost.
+func NewSyncWithMaxCost(maxCost int64, options ...Option) *SyncLRU {
+	s := &SyncLRU{}
+	s.init(NewWithMaxCost(maxCost, options...))
+	return s
+}
+
+// NewSyncWithMaxBytes creates a SyncLRU that keeps the estimated memory used
+// by its entries within maxBytes. See NewWithMaxBytes.
+func NewSyncWithMaxBytes(maxBytes int64, options ...Option) *SyncLRU {
+	s := &SyncLRU{}
+	s.init(NewWithMaxBytes(maxBytes, options...))
+	return s
+}
+
+func (s *SyncLRU) init(lru *LRU) {
+	s.lru = lru
+	stripes := 1
+	for stripes < runtime.GOMAXPROCS(0) {
+		stripes *= 2
+	}
+	s.stripes = make([]accessStripe, stripes)
+}
+
+// lock takes the exclusive lock, and applies any accesses recorded by Get.
+func (s *SyncLRU) lock() {
+	s.mu.Lock()
+	for i := range s.stripes {
+		stripe := &s.stripes[i]
+		stripe.mu.Lock()
+		s.applyAccesses(stripe)
+		stripe.mu.Unlock()
+	}
+}
+
+// applyAccesses must be called with both the exclusive lock and the stripe's
+// lock held.
+func (s *SyncLRU) applyAccesses(stripe *accessStripe) {
+	for ; stripe.count > 0; stripe.count-- {
+		s.lru.get(stripe.keys[stripe.start])
+		stripe.keys[stripe.start] = nil
+		stripe.start = (stripe.start + 1) % accessStripeSize
+	}
+}
+
+// recordAccess notes that Get found key, which is stored at elem.
+func (s *SyncLRU) recordAccess(elem uint32, key interface{}) {
+	stripe := &s.stripes[int(elem)&(len(s.stripes)-1)]
+	stripe.mu.Lock()
+	defer stripe.mu.Unlock()
+	if stripe.count == accessStripeSize {
+		if s.mu.TryLock() {
+			s.applyAccesses(stripe)
+			s.mu.Unlock()
+		} else {
+			// Somebody else holds the lock, rather than waiting, we drop
+			// the oldest access.
+			stripe.start = (stripe.start + 1) % accessStripeSize
+			stripe.count--
+		}
+	}
+	stripe.keys[(stripe.start+stripe.count)%accessStripeSize] = key
+	stripe.count++
+}
+
+// Len gives the number of items in the cache
+func (s *SyncLRU) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.lru.Len()
+}
+
+// SizeBytes returns an estimate of the memory used by the cache. See
+// LRU.SizeBytes.
+func (s *SyncLRU) SizeBytes() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.lru.SizeBytes()
+}
+
+// Compact reallocates the cache's buffers to fit the entries it holds. See
+// LRU.Compact.
+func (s *SyncLRU) Compact() {
+	s.lock()
+	defer s.mu.Unlock()
+	s.lru.Compact()
+}
+
+// HitCounts gives information about calls to Get. See LRU.HitCounts.
+func (s *SyncLRU) HitCounts() HitCounts {
+	return s.lru.HitCounts()
+}
+
+// EstimatedHitCounts estimates what HitCounts would be if the cache were
+// larger. See LRU.EstimatedHitCounts.
+func (s *SyncLRU) EstimatedHitCounts() HitCounts {
+	return s.lru.EstimatedHitCounts()
+}
+
+// PeekHitCounts gives information about calls to Peek. See
+// LRU.PeekHitCounts.
+func (s *SyncLRU) PeekHitCounts() HitCounts {
+	return s.lru.PeekHitCounts()
+}
+
+// Stats returns a snapshot of the state of the cache and its counters. See
+// LRU.Stats.
+func (s *SyncLRU) Stats() Stats {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.lru.Stats()
+}
+
+// AccessCount returns how many times Get has found key since it was added.
+// See LRU.AccessCount.
+func (s *SyncLRU) AccessCount(key interface{}) (uint64, bool) {
+	s.lock()
+	defer s.mu.Unlock()
+	return s.lru.AccessCount(key)
+}
+
+// AccessCounts returns how many times Get has found each entry in the cache.
+// See LRU.AccessCounts.
+func (s *SyncLRU) AccessCounts() []KeyCount {
+	s.lock()
+	defer s.mu.Unlock()
+	return s.lru.AccessCounts()
+}
+
+// EvictionAges returns how old the entries evicted to make room for others
+// have been. See LRU.EvictionAges.
+func (s *SyncLRU) EvictionAges() EvictionAges {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.lru.EvictionAges()
+}
+
+// EntryAges returns how old the entries in the cache are. See LRU.EntryAges.
+func (s *SyncLRU) EntryAges() EntryAges {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.lru.EntryAges()
+}
+
+// ResetStats returns a snapshot of the state of the cache and its counters,
+// and resets the counters. See LRU.ResetStats.
+func (s *SyncLRU) ResetStats() Stats {
+	s.lock()
+	defer s.mu.Unlock()
+	return s.lru.ResetStats()
+}
+
+// Cost returns the total cost of the entries in the cache. See LRU.Cost.
+func (s *SyncLRU) Cost() int64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.lru.Cost()
+}
+
+// Add a new entry into the cache
+func (s *SyncLRU) Add(key, value interface{}) {
+	s.lock()
+	defer s.mu.Unlock()
+	s.lru.Add(key, value)
+	s.notifyWaiters(key)
+}
+
+// TryAdd is like Add, but returns whether the key is now in the cache. See
+// LRU.TryAdd.
+func (s *SyncLRU) TryAdd(key, value interface{}) bool {
+	s.lock()
+	defer s.mu.Unlock()
+	added := s.lru.TryAdd(key, value)
+	s.notifyWaiters(key)
+	return added
+}
+
+// AddWithCost adds a new entry into the cache with the given cost. See
+// LRU.AddWithCost.
+func (s *SyncLRU) AddWithCost(key, value interface{}, cost int64) {
+	s.lock()
+	defer s.mu.Unlock()
+	s.lru.AddWithCost(key, value, cost)
+	s.notifyWaiters(key)
+}
+
+// UpdateCost changes the cost of the entry for key. See LRU.UpdateCost.
+func (s *SyncLRU) UpdateCost(key interface{}, cost int64) bool {
+	s.lock()
+	defer s.mu.Unlock()
+	return s.lru.UpdateCost(key, cost)
+}
+
+// AddWithPriority adds a new entry into the cache with the given priority.
+// See LRU.AddWithPriority.
+func (s *SyncLRU) AddWithPriority(key, value interface{}, priority Priority) {
+	s.lock()
+	defer s.mu.Unlock()
+	s.lru.AddWithPriority(key, value, priority)
+	s.notifyWaiters(key)
+}
+
+// Update is LRU.Update, with fn called while the lock is held, making the
+// read-modify-write atomic.
+func (s *SyncLRU) Update(key interface{}, fn func(old interface{}, exists bool) (new interface{}, write bool)) {
+	s.lock()
+	defer s.mu.Unlock()
+	s.lru.Update(key, fn)
+	s.notifyWaiters(key)
+}
+
+// Get returns the Value associated with key, and a boolean as to whether it
+// actually exists in the cache. See LRU.Get.
+func (s *SyncLRU) Get(key interface{}) (interface{}, bool) {
+	s.mu.RLock()
+	elem, ok := s.lru.lookup(key)
+	var value interface{}
+	stale := false
+	if ok && s.lru.expiresEarly(elem, s.lru.nowNano()) {
+		ok = false
+	} else if ok {
+		value = s.lru.buf[elem].value
+	} else if s.lru.revalidate != nil {
+		_, stale, _ = s.lru.PeekStale(key)
+	}
+	if !ok && !stale {
+		s.lru.countGhost(key)
+	}
+	s.mu.RUnlock()
+	if stale {
+		// Serving a stale value needs to record that it is being
+		// revalidated, so we go the slow way.
+		s.lock()
+		defer s.mu.Unlock()
+		return s.lru.Get(key)
+	}
+	s.lru.profile(key)
+	s.lru.countGet(ok)
+	if ok {
+		// Stripes are picked by where the entry is stored, which is cheap
+		// and spreads different keys across them.
+		s.recordAccess(elem, key)
+	}
+	return value, ok
+}
+
+// GetWithDefault is like Get, but returns def if key is not in the cache.
+func (s *SyncLRU) GetWithDefault(key, def interface{}) interface{} {
+	if value, ok := s.Get(key); ok {
+		return value
+	}
+	return def
+}
+
+// Peek is just like Get() except it doesn't affect if it was 'recently accessed'
+func (s *SyncLRU) Peek(key interface{}) (interface{}, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.lru.Peek(key)
+}
+
+// PeekStale is like Peek, but also returns expired entries. See
+// LRU.PeekStale.
+func (s *SyncLRU) PeekStale(key interface{}) (value interface{}, expired bool, ok bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.lru.PeekStale(key)
+}
+
+// PeekMostRecentN returns up to n entries, starting with the most recently
+// used. See LRU.PeekMostRecentN.
+func (s *SyncLRU) PeekMostRecentN(n int) []Entry {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.lru.PeekMostRecentN(n)
+}
+
+// PeekLeastRecentN returns up to n entries, starting with the least recently
+// used. See LRU.PeekLeastRecentN.
+func (s *SyncLRU) PeekLeastRecentN(n int) []Entry {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.lru.PeekLeastRecentN(n)
+}
+
+// Range calls fn for each entry, starting with the most recently used, until
+// fn returns false. The lock is held for the whole iteration.
+func (s *SyncLRU) Range(fn func(key, value interface{}) bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	s.lru.Range(fn)
+}
+
+// RangeReverse is like Range, but starts with the least recently used entry.
+func (s *SyncLRU) RangeReverse(fn func(key, value interface{}) bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	s.lru.RangeReverse(fn)
+}
+
+// Save writes the entries in the cache to w, so that Load can recreate them.
+// See LRU.Save.
+func (s *SyncLRU) Save(w io.Writer) error {
+	// Taking the write lock applies any Gets not yet recorded, so the
+	// recency order is up to date.
+	s.lock()
+	defer s.mu.Unlock()
+	return s.lru.Save(w)
+}
+
+// Load adds the entries written by Save to the cache. See LRU.Load.
+func (s *SyncLRU) Load(r io.Reader) error {
+	s.lock()
+	defer s.mu.Unlock()
+	err := s.lru.Load(r)
+	s.notifyAllWaiters()
+	return err
+}
+
+// Dump returns the entries in the cache, from the least recently used to the
+// most. See LRU.Dump.
+func (s *SyncLRU) Dump() []Entry {
+	// Taking the write lock applies any Gets not yet recorded, so the
+	// recency order is up to date.
+	s.lock()
+	defer s.mu.Unlock()
+	return s.lru.Dump()
+}
+
+// Warm adds entries to the cache, from the least recently used to the most.
+// See LRU.Warm.
+func (s *SyncLRU) Warm(entries []Entry) {
+	s.lock()
+	defer s.mu.Unlock()
+	s.lru.Warm(entries)
+	s.notifyAllWaiters()
+}
+
+// RemoveIf removes every entry for which fn returns true, and returns how many
+// entries were removed.
+func (s *SyncLRU) RemoveIf(fn func(key, value interface{}) bool) int {
+	s.lock()
+	defer s.mu.Unlock()
+	return s.lru.RemoveIf(fn)
+}
+
+// RetainOnly removes every entry whose key is not in keys, and returns how
+// many entries were removed.
+func (s *SyncLRU) RetainOnly(keys map[interface{}]struct{}) int {
+	s.lock()
+	defer s.mu.Unlock()
+	return s.lru.RetainOnly(keys)
+}
+
+// Do calls fn with the underlying LRU while holding the exclusive lock, so a
+// series of operations can be done atomically while only taking the lock
+// once. fn must not keep a reference to the LRU after it returns.
+func (s *SyncLRU) Do(fn func(*LRU)) {
+	s.lock()
+	defer s.mu.Unlock()
+	fn(s.lru)
+	// We don't know what fn added, so check everything being waited for.
+	s.notifyAllWaiters()
+}
+
+// GetWait is like Get, but if key is not in the cache, it waits until another
+// goroutine adds it, or until ctx is done, in which case the context's error
+// is returned.
+func (s *SyncLRU) GetWait(ctx context.Context, key interface{}) (interface{}, error) {
+	if value, ok := s.Get(key); ok {
+		return value, nil
+	}
+	s.lock()
+	// It may have been added while we didn't hold the lock.
+	if value, ok := s.lru.get(key); ok {
+		s.mu.Unlock()
+		return value, nil
+	}
+	id := s.lru.identity(key)
+	ch := make(chan interface{}, 1)
+	if s.waiters == nil {
+		s.waiters = make(map[interface{}][]chan interface{})
+	}
+	s.waiters[id] = append(s.waiters[id], ch)
+	s.mu.Unlock()
+
+	select {
+	case value := <-ch:
+		return value, nil
+	case <-ctx.Done():
+	}
+	s.lock()
+	defer s.mu.Unlock()
+	chans := s.waiters[id]
+	for i, waiter := range chans {
+		if waiter == ch {
+			chans = append(chans[:i], chans[i+1:]...)
+			break
+		}
+	}
+	if len(chans) == 0 {
+		delete(s.waiters, id)
+	} else {
+		s.waiters[id] = chans
+	}
+	// We may have been woken up before we got the lock back.
+	select {
+	case value := <-ch:
+		return value, nil
+	default:
+		return nil, ctx.Err()
+	}
+}
+
+// Watch returns a channel that is sent an event whenever key is added to the
+// cache, its value is replaced, or it leaves the cache, and a function to stop
+// watching. See LRU.Watch.
+func (s *SyncLRU) Watch(key interface{}) (<-chan WatchEvent, func()) {
+	s.lock()
+	defer s.mu.Unlock()
+	ch, stop := s.lru.Watch(key)
+	return ch, func() {
+		s.lock()
+		defer s.mu.Unlock()
+		stop()
+	}
+}
+
+// notifyWaiters wakes any GetWait calls waiting for key, if it is now in the
+// cache. It must be called with the exclusive lock held.
+func (s *SyncLRU) notifyWaiters(key interface{}) {
+	if len(s.waiters) == 0 {
+		return
+	}
+	id := s.lru.identity(key)
+	if elem, ok := s.lru.elements[id]; ok {
+		s.wake(id, s.lru.buf[elem].value)
+	}
+}
+
+// notifyAllWaiters wakes any GetWait calls waiting for keys that are now in
+// the cache, after entries have been added without knowing which. It must be
+// called with the exclusive lock held.
+func (s *SyncLRU) notifyAllWaiters() {
+	for id := range s.waiters {
+		if elem, ok := s.lru.elements[id]; ok {
+			s.wake(id, s.lru.buf[elem].value)
+		}
+	}
+}
+
+// wake passes value to everything waiting on id. It must be called with the
+// exclusive lock held.
+func (s *SyncLRU) wake(id, value interface{}) {
+	for _, ch := range s.waiters[id] {
+		ch <- value
+	}
+	delete(s.waiters, id)
+}
+
+// StartReaper starts a goroutine that calls RemoveExpired every interval, so
+// expired entries that are never looked up again don't keep taking up room.
+// Any reaper that is already running is stopped first. Close must be called
+// to stop the goroutine once the cache is no longer needed.
+func (s *SyncLRU) StartReaper(interval time.Duration) {
+	s.reaperMu.Lock()
+	defer s.reaperMu.Unlock()
+	s.stopReaper()
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	s.reaperStop, s.reaperDone = stop, done
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				removed := s.RemoveExpired()
+				s.lru.debugf("lru: reaper removed %d expired entries", removed)
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+// Close stops the reaper started by StartReaper, if any, and waits for it to
+// finish. The cache can still be used after it is closed.
+func (s *SyncLRU) Close() {
+	s.reaperMu.Lock()
+	defer s.reaperMu.Unlock()
+	s.stopReaper()
+}
+
+// stopReaper must be called with reaperMu held.
+func (s *SyncLRU) stopReaper() {
+	if s.reaperStop == nil {
+		return
+	}
+	close(s.reaperStop)
+	<-s.reaperDone
+	s.reaperStop, s.reaperDone = nil, nil
+}
+
+// RemoveExpired removes every entry that has expired, and returns how many
+// were removed.
+func (s *SyncLRU) RemoveExpired() int {
+	s.lock()
+	defer s.mu.Unlock()
+	return s.lru.RemoveExpired()
+}
+
+// TrimTo evicts entries until no more than n remain. See LRU.TrimTo.
+func (s *SyncLRU) TrimTo(n int) int {
+	s.lock()
+	defer s.mu.Unlock()
+	return s.lru.TrimTo(n)
+}
+
+// DrainEvicted returns the entries that have left the cache since it was last
+// called, and forgets them. See LRU.DrainEvicted.
+func (s *SyncLRU) DrainEvicted() []Entry {
+	s.lock()
+	defer s.mu.Unlock()
+	return s.lru.DrainEvicted()
+}
+
+// Purge removes every entry from the cache. See LRU.Purge.
+func (s *SyncLRU) Purge() {
+	s.lock()
+	defer s.mu.Unlock()
+	s.lru.Purge()
+}
+
+// setMaxSize changes how many entries the cache can hold.
+func (s *SyncLRU) setMaxSize(size int) {
+	s.lock()
+	defer s.mu.Unlock()
+	s.lru.setMaxSize(size)
+}
+
+// EvictOlderThan removes every entry that was last written or returned by Get
+// before t. See LRU.EvictOlderThan.
+func (s *SyncLRU) EvictOlderThan(t time.Time) int {
+	s.lock()
+	defer s.mu.Unlock()
+	return s.lru.EvictOlderThan(t)
+}
+
+// AgeOf returns how long ago the value for key was written. See LRU.AgeOf.
+func (s *SyncLRU) AgeOf(key interface{}) (time.Duration, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.lru.AgeOf(key)
+}
+
+// LastAccessed returns when key was last written or returned by Get. See
+// LRU.LastAccessed. As Gets are recorded in batches, this takes the exclusive
+// lock so that any outstanding ones are included.
+func (s *SyncLRU) LastAccessed(key interface{}) (time.Time, bool) {
+	s.lock()
+	defer s.mu.Unlock()
+	return s.lru.LastAccessed(key)
+}
+
+// SetTTL changes when key expires to ttl from now. See LRU.SetTTL.
+func (s *SyncLRU) SetTTL(key interface{}, ttl time.Duration) bool {
+	s.lock()
+	defer s.mu.Unlock()
+	return s.lru.SetTTL(key, ttl)
+}
+
+// Extend pushes back when key expires by d. See LRU.Extend.
+func (s *SyncLRU) Extend(key interface{}, d time.Duration) bool {
+	s.lock()
+	defer s.mu.Unlock()
+	return s.lru.Extend(key, d)
+}
+
+// SetRecomputeTime records how long it takes to compute the value for key.
+// See LRU.SetRecomputeTime.
+func (s *SyncLRU) SetRecomputeTime(key interface{}, d time.Duration) bool {
+	s.lock()
+	defer s.mu.Unlock()
+	return s.lru.SetRecomputeTime(key, d)
+}
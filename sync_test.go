@@ -0,0 +1,362 @@
+// Copyright 2026 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package lru_test
+
+import (
+	"bytes"
+	"context"
+	"expvar"
+	"runtime"
+	"sync"
+	"time"
+
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/lru"
+)
+
+type SyncLRUSuite struct{}
+
+var _ = gc.Suite(&SyncLRUSuite{})
+
+func (*SyncLRUSuite) TestBasics(c *gc.C) {
+	cache := lru.NewSync(2)
+	cache.Add("a", 1)
+	cache.Add("b", 2)
+	v, ok := cache.Get("a")
+	c.Check(ok, gc.Equals, true)
+	c.Check(v, gc.Equals, 1)
+	cache.Add("c", 3)
+	_, ok = cache.Peek("b")
+	c.Check(ok, gc.Equals, false)
+	c.Check(cache.Len(), gc.Equals, 2)
+	c.Check(cache.GetWithDefault("b", 0), gc.Equals, 0)
+	c.Check(cache.PeekMostRecentN(2), gc.DeepEquals, []lru.Entry{
+		{Key: "c", Value: 3},
+		{Key: "a", Value: 1},
+	})
+}
+
+func (*SyncLRUSuite) TestConcurrentUpdates(c *gc.C) {
+	const goroutines = 10
+	const increments = 1000
+	// Large enough that "count" is never evicted
+	cache := lru.NewSync(300)
+	var wg sync.WaitGroup
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < increments; j++ {
+				cache.Update("count", func(old interface{}, exists bool) (interface{}, bool) {
+					if !exists {
+						return 1, true
+					}
+					return old.(int) + 1, true
+				})
+				cache.Add(j%200, i)
+				cache.Get((j + 1) % 200)
+				cache.Peek(j % 50)
+				cache.Len()
+			}
+		}(i)
+	}
+	wg.Wait()
+	v, ok := cache.Get("count")
+	c.Check(ok, gc.Equals, true)
+	c.Check(v, gc.Equals, goroutines*increments)
+	c.Check(cache.Len(), gc.Equals, 201)
+}
+
+func (*SyncLRUSuite) TestGetAffectsEviction(c *gc.C) {
+	cache := lru.NewSync(3)
+	cache.Add("a", 1)
+	cache.Add("b", 2)
+	cache.Add("c", 3)
+	// The access is only recorded by Get, but must still be applied
+	// before we decide what to evict.
+	cache.Get("a")
+	cache.Add("d", 4)
+	_, ok := cache.Peek("a")
+	c.Check(ok, gc.Equals, true)
+	_, ok = cache.Peek("b")
+	c.Check(ok, gc.Equals, false)
+}
+
+func (*SyncLRUSuite) TestManyGetsApplied(c *gc.C) {
+	cache := lru.NewSync(200)
+	for i := 0; i < 200; i++ {
+		cache.Add(i, i)
+	}
+	// Enough Gets to force the recorded accesses to be applied.
+	for i := 0; i < 100; i++ {
+		cache.Get(i)
+	}
+	entries := cache.PeekLeastRecentN(1)
+	c.Check(entries, gc.HasLen, 1)
+	c.Check(entries[0].Key, gc.Not(gc.Equals), 0)
+	for i := 200; i < 300; i++ {
+		cache.Add(i, i)
+	}
+	for i := 0; i < 100; i++ {
+		_, ok := cache.Peek(i)
+		c.Check(ok, gc.Equals, true)
+	}
+}
+
+func (*SyncLRUSuite) TestConcurrentReaders(c *gc.C) {
+	cache := lru.NewSync(100)
+	for i := 0; i < 100; i++ {
+		cache.Add(i, i)
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 5000; j++ {
+				if v, ok := cache.Get(j % 100); ok && v != j%100 {
+					c.Errorf("got %v for %d", v, j%100)
+					return
+				}
+			}
+		}()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for j := 0; j < 1000; j++ {
+			cache.Add(j%100, j%100)
+		}
+	}()
+	wg.Wait()
+	c.Check(cache.Len(), gc.Equals, 100)
+}
+
+func (*BenchmarkLRUSuite) BenchmarkSyncGetParallel(c *gc.C) {
+	const size = 10000
+	cache := lru.NewSync(size)
+	for i := 0; i < size; i++ {
+		cache.Add(i, i)
+	}
+	goroutines := runtime.GOMAXPROCS(0)
+	c.ResetTimer()
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := g; i < c.N; i += goroutines {
+				cache.Get(i % size)
+			}
+		}(g)
+	}
+	wg.Wait()
+}
+
+func (*SyncLRUSuite) TestDo(c *gc.C) {
+	cache := lru.NewSync(10)
+	cache.Add("a", 1)
+	cache.Get("a")
+	cache.Do(func(l *lru.LRU) {
+		v, ok := l.Peek("a")
+		c.Check(ok, gc.Equals, true)
+		l.RemoveIf(func(key, value interface{}) bool { return key == "a" })
+		l.Add("a", v.(int)+1)
+		l.Add("b", 2)
+	})
+	v, ok := cache.Peek("a")
+	c.Check(ok, gc.Equals, true)
+	c.Check(v, gc.Equals, 2)
+	c.Check(cache.Len(), gc.Equals, 2)
+}
+
+func (*SyncLRUSuite) TestGetWaitPresent(c *gc.C) {
+	cache := lru.NewSync(10)
+	cache.Add("a", 1)
+	v, err := cache.GetWait(context.Background(), "a")
+	c.Assert(err, gc.IsNil)
+	c.Check(v, gc.Equals, 1)
+}
+
+func (*SyncLRUSuite) TestGetWaitAdded(c *gc.C) {
+	cache := lru.NewSync(10)
+	results := make(chan interface{}, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			v, err := cache.GetWait(context.Background(), "a")
+			c.Check(err, gc.IsNil)
+			results <- v
+		}()
+	}
+	// Give the waiters a chance to start waiting, although the result
+	// should be the same either way.
+	time.Sleep(10 * time.Millisecond)
+	cache.Add("b", 2)
+	cache.Add("a", 1)
+	for i := 0; i < 2; i++ {
+		select {
+		case v := <-results:
+			c.Check(v, gc.Equals, 1)
+		case <-time.After(10 * time.Second):
+			c.Fatalf("GetWait never returned")
+		}
+	}
+}
+
+func (*SyncLRUSuite) TestGetWaitDo(c *gc.C) {
+	cache := lru.NewSync(10)
+	results := make(chan interface{}, 1)
+	go func() {
+		v, err := cache.GetWait(context.Background(), "a")
+		c.Check(err, gc.IsNil)
+		results <- v
+	}()
+	time.Sleep(10 * time.Millisecond)
+	cache.Do(func(l *lru.LRU) {
+		l.Add("a", "done")
+	})
+	select {
+	case v := <-results:
+		c.Check(v, gc.Equals, "done")
+	case <-time.After(10 * time.Second):
+		c.Fatalf("GetWait never returned")
+	}
+}
+
+func (*SyncLRUSuite) TestGetWaitWarm(c *gc.C) {
+	cache := lru.NewSync(10)
+	results := make(chan interface{}, 1)
+	go func() {
+		v, err := cache.GetWait(context.Background(), "a")
+		c.Check(err, gc.IsNil)
+		results <- v
+	}()
+	time.Sleep(10 * time.Millisecond)
+	cache.Warm([]lru.Entry{{Key: "b", Value: 2}, {Key: "a", Value: 1}})
+	select {
+	case v := <-results:
+		c.Check(v, gc.Equals, 1)
+	case <-time.After(10 * time.Second):
+		c.Fatalf("GetWait never returned")
+	}
+}
+
+func (*SyncLRUSuite) TestGetWaitReadFrom(c *gc.C) {
+	source := lru.New(10)
+	source.Add("a", 1)
+	var buf bytes.Buffer
+	_, err := source.WriteTo(&buf)
+	c.Assert(err, gc.IsNil)
+	cache := lru.NewSync(10)
+	results := make(chan interface{}, 1)
+	go func() {
+		v, err := cache.GetWait(context.Background(), "a")
+		c.Check(err, gc.IsNil)
+		results <- v
+	}()
+	time.Sleep(10 * time.Millisecond)
+	_, err = cache.ReadFrom(&buf)
+	c.Assert(err, gc.IsNil)
+	select {
+	case v := <-results:
+		c.Check(v, gc.Equals, 1)
+	case <-time.After(10 * time.Second):
+		c.Fatalf("GetWait never returned")
+	}
+}
+
+func (*SyncLRUSuite) TestGetWaitCancelled(c *gc.C) {
+	cache := lru.NewSync(10)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	v, err := cache.GetWait(ctx, "a")
+	c.Check(err, gc.Equals, context.DeadlineExceeded)
+	c.Check(v, gc.IsNil)
+	// Adding after the waiter has gone must not block.
+	cache.Add("a", 1)
+	checkSyncPeek(c, cache, "a", 1)
+}
+
+func (*SyncLRUSuite) TestDrainEvicted(c *gc.C) {
+	cache := lru.NewSync(2, lru.WithDrainEvicted())
+	c.Check(cache.DrainEvicted(), gc.HasLen, 0)
+	cache.Add("a", 1)
+	cache.Add("b", 2)
+	cache.Add("b", 3)
+	cache.Add("c", 4)
+	cache.RemoveIf(func(key, _ interface{}) bool { return key == "b" })
+	c.Check(cache.DrainEvicted(), gc.DeepEquals, []lru.Entry{
+		{Key: "a", Value: 1},
+		{Key: "b", Value: 3},
+	})
+	c.Check(cache.DrainEvicted(), gc.HasLen, 0)
+	cache.Purge()
+	c.Check(cache.DrainEvicted(), gc.DeepEquals, []lru.Entry{{Key: "c", Value: 4}})
+}
+
+func (*SyncLRUSuite) TestDrainEvictedNeedsOption(c *gc.C) {
+	cache := lru.NewSync(2)
+	c.Check(cache.DrainEvicted, gc.PanicMatches, "DrainEvicted needs the cache to be created WithDrainEvicted")
+}
+
+func (*SyncLRUSuite) TestWatch(c *gc.C) {
+	cache := lru.NewSync(10)
+	events, stop := cache.Watch("config")
+	defer stop()
+	go cache.Add("config", "v1")
+	select {
+	case event := <-events:
+		c.Check(event, gc.Equals, lru.WatchEvent{Kind: lru.WatchAdded, Key: "config", Value: "v1"})
+	case <-time.After(10 * time.Second):
+		c.Fatalf("no event")
+	}
+}
+
+func (*SyncLRUSuite) TestHitCounts(c *gc.C) {
+	cache := lru.NewSync(10)
+	cache.Add("a", 1)
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				cache.Get("a")
+				cache.Get("b")
+				cache.Peek("a")
+			}
+		}()
+	}
+	wg.Wait()
+	c.Check(cache.HitCounts(), gc.Equals, lru.HitCounts{Hit: 400, Miss: 400})
+	c.Check(cache.PeekHitCounts(), gc.Equals, lru.HitCounts{Hit: 400})
+}
+
+func (*SyncLRUSuite) TestPublishExpvar(c *gc.C) {
+	cache := lru.NewSync(10)
+	cache.PublishExpvar("lru-test-sync")
+	cache.Add("a", 1)
+	cache.Get("a")
+	cache.Get("b")
+	c.Check(expvar.Get("lru-test-sync").String(), gc.Equals,
+		`{"len":1,"cap":10,"hits":1,"misses":1,"evictions":0,"expirations":0,"replacements":0}`)
+	c.Check(func() { cache.PublishExpvar("lru-test-sync") }, gc.PanicMatches, "(?s).*lru-test-sync.*")
+}
+
+func checkSyncPeek(c *gc.C, cache *lru.SyncLRU, key, value interface{}) {
+	v, ok := cache.Peek(key)
+	c.Check(ok, gc.Equals, true, gc.Commentf("key %#v did not exist in cache", key))
+	c.Check(v, gc.Equals, value)
+}
+
+func (*SyncLRUSuite) TestEstimatedHitCounts(c *gc.C) {
+	cache := lru.NewSync(1, lru.WithGhostKeys(1))
+	cache.Add("a", 1)
+	cache.Add("b", 2)
+	cache.Get("a")
+	cache.Get("b")
+	c.Check(cache.HitCounts(), gc.Equals, lru.HitCounts{Hit: 1, Miss: 1})
+	c.Check(cache.EstimatedHitCounts(), gc.Equals, lru.HitCounts{Hit: 2})
+}
@@ -0,0 +1,127 @@
+// Copyright 2026 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package lru
+
+const (
+	// DefaultTwoQueueRecentRatio is the share of a TwoQueueCache given to
+	// entries that have only been seen once.
+	DefaultTwoQueueRecentRatio = 0.25
+	// DefaultTwoQueueGhostRatio is how many recently evicted keys a
+	// TwoQueueCache remembers, relative to its size.
+	DefaultTwoQueueGhostRatio = 0.5
+)
+
+// TwoQueueCache is a cache using the 2Q algorithm, which is resistant to
+// scans of keys that are only used once. New entries go into a "recent"
+// queue (A1in), and are only moved into the main "frequent" queue (Am) when
+// they are used again. When the recent queue is over its share of the cache,
+// its oldest entries are evicted, but their keys are remembered for a while
+// in a "ghost" queue (A1out), so that if they are added again soon after,
+// they go straight into the frequent queue. A scan therefore only ever
+// displaces the recent queue, leaving the frequent entries alone.
+// Each queue is an LRU, so it uses the same compact storage.
+// Note that TwoQueueCache is *not* thread safe, some form of mutex is
+// necessary if you want to access it from multiple threads.
+type TwoQueueCache struct {
+	size       int
+	recentSize int
+	recent     *LRU
+	frequent   *LRU
+	// ghost holds the keys recently evicted from recent, with nil values.
+	ghost *LRU
+}
+
+// NewTwoQueue creates a TwoQueueCache that will hold no more than the given
+// number of items, using the default queue ratios.
+func NewTwoQueue(size int) *TwoQueueCache {
+	return NewTwoQueueWithRatios(size, DefaultTwoQueueRecentRatio, DefaultTwoQueueGhostRatio)
+}
+
+// NewTwoQueueWithRatios creates a TwoQueueCache that will hold no more than
+// the given number of items. recentRatio is the share of the cache that
+// entries that have been seen once can take up when the cache is full, and
+// ghostRatio is how many evicted keys to remember, relative to size.
+func NewTwoQueueWithRatios(size int, recentRatio, ghostRatio float64) *TwoQueueCache {
+	if recentRatio < 0 || recentRatio > 1 {
+		panic("recentRatio must be between 0 and 1")
+	}
+	if ghostRatio < 0 || ghostRatio > 1 {
+		panic("ghostRatio must be between 0 and 1")
+	}
+	ghostSize := int(float64(size) * ghostRatio)
+	if ghostSize < 1 {
+		ghostSize = 1
+	}
+	return &TwoQueueCache{
+		size:       size,
+		recentSize: int(float64(size) * recentRatio),
+		recent:     New(size),
+		frequent:   New(size),
+		ghost:      New(ghostSize),
+	}
+}
+
+// Len gives the number of items in the cache
+func (c *TwoQueueCache) Len() int {
+	return c.recent.Len() + c.frequent.Len()
+}
+
+// Add a new entry into the cache
+func (c *TwoQueueCache) Add(key, value interface{}) {
+	if _, ok := c.frequent.elements[c.frequent.identity(key)]; ok {
+		c.frequent.Add(key, value)
+		return
+	}
+	if _, ok := c.recent.remove(key); ok {
+		// Seen a second time, so it is no longer just recent.
+		c.frequent.Add(key, value)
+		return
+	}
+	if _, ok := c.ghost.remove(key); ok {
+		// It was evicted too soon, so it gets another chance as a
+		// frequent entry.
+		c.makeRoom(true)
+		c.frequent.Add(key, value)
+		return
+	}
+	c.makeRoom(false)
+	c.recent.Add(key, value)
+}
+
+// makeRoom evicts an entry if the cache is full. ghostHit is whether the
+// entry being added was found in the ghost queue.
+func (c *TwoQueueCache) makeRoom(ghostHit bool) {
+	if c.Len() < c.size {
+		return
+	}
+	recentLen := c.recent.Len()
+	if recentLen > 0 && (recentLen > c.recentSize || (recentLen == c.recentSize && !ghostHit) || c.frequent.Len() == 0) {
+		entry, _ := c.recent.removeOldest()
+		c.ghost.Add(entry.Key, nil)
+		return
+	}
+	c.frequent.removeOldest()
+}
+
+// Get returns the Value associated with key, and a boolean as to whether it
+// actually exists in the cache. If it does exist in the cache, then it is
+// treated as recently accessed, and moved into the frequent queue.
+func (c *TwoQueueCache) Get(key interface{}) (interface{}, bool) {
+	if value, ok := c.frequent.Get(key); ok {
+		return value, true
+	}
+	if value, ok := c.recent.remove(key); ok {
+		c.frequent.Add(key, value)
+		return value, true
+	}
+	return nil, false
+}
+
+// Peek is just like Get() except it doesn't affect if it was 'recently accessed'
+func (c *TwoQueueCache) Peek(key interface{}) (interface{}, bool) {
+	if value, ok := c.frequent.Peek(key); ok {
+		return value, true
+	}
+	return c.recent.Peek(key)
+}
@@ -0,0 +1,100 @@
+// Copyright 2026 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package lru_test
+
+import (
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/lru"
+)
+
+type TwoQueueSuite struct{}
+
+var _ = gc.Suite(&TwoQueueSuite{})
+
+func (*TwoQueueSuite) TestAddGet(c *gc.C) {
+	cache := lru.NewTwoQueue(10)
+	cache.Add("a", 1)
+	cache.Add("b", 2)
+	value, ok := cache.Get("a")
+	c.Check(ok, gc.Equals, true)
+	c.Check(value, gc.Equals, 1)
+	value, ok = cache.Peek("b")
+	c.Check(ok, gc.Equals, true)
+	c.Check(value, gc.Equals, 2)
+	cache.Add("a", 3)
+	cache.Add("b", 4)
+	value, _ = cache.Get("a")
+	c.Check(value, gc.Equals, 3)
+	value, _ = cache.Get("b")
+	c.Check(value, gc.Equals, 4)
+	_, ok = cache.Get("c")
+	c.Check(ok, gc.Equals, false)
+	c.Check(cache.Len(), gc.Equals, 2)
+}
+
+func (*TwoQueueSuite) TestMaxSize(c *gc.C) {
+	cache := lru.NewTwoQueue(10)
+	for i := 0; i < 100; i++ {
+		cache.Add(i, i)
+		if i%3 == 0 {
+			cache.Get(i)
+		}
+		c.Assert(cache.Len() <= 10, gc.Equals, true)
+	}
+	c.Check(cache.Len(), gc.Equals, 10)
+}
+
+func (*TwoQueueSuite) TestScanResistance(c *gc.C) {
+	cache := lru.NewTwoQueue(100)
+	// Build up a working set that is used more than once.
+	for i := 0; i < 50; i++ {
+		cache.Add(i, i)
+		cache.Get(i)
+	}
+	// A scan of keys that are only seen once.
+	for i := 1000; i < 2000; i++ {
+		cache.Add(i, i)
+	}
+	for i := 0; i < 50; i++ {
+		_, ok := cache.Peek(i)
+		c.Check(ok, gc.Equals, true, gc.Commentf("lost %d", i))
+	}
+	c.Check(cache.Len(), gc.Equals, 100)
+	// Whereas a plain LRU loses everything.
+	plain := lru.New(100)
+	for i := 0; i < 50; i++ {
+		plain.Add(i, i)
+	}
+	for i := 1000; i < 2000; i++ {
+		plain.Add(i, i)
+	}
+	_, ok := plain.Peek(0)
+	c.Check(ok, gc.Equals, false)
+}
+
+func (*TwoQueueSuite) TestGhostHit(c *gc.C) {
+	cache := lru.NewTwoQueueWithRatios(4, 0.5, 1)
+	cache.Add("a", 1)
+	cache.Add("b", 2)
+	cache.Add("c", 3)
+	cache.Add("d", 4)
+	cache.Add("e", 5)
+	// "a" was evicted from the recent queue, but is remembered, so adding it
+	// again puts it in the frequent queue, where it survives a scan.
+	_, ok := cache.Peek("a")
+	c.Check(ok, gc.Equals, false)
+	cache.Add("a", 6)
+	for i := 0; i < 10; i++ {
+		cache.Add(i, i)
+	}
+	value, ok := cache.Peek("a")
+	c.Check(ok, gc.Equals, true)
+	c.Check(value, gc.Equals, 6)
+}
+
+func (*TwoQueueSuite) TestBadRatios(c *gc.C) {
+	c.Check(func() { lru.NewTwoQueueWithRatios(10, 2, 0.5) }, gc.PanicMatches, "recentRatio must be .*")
+	c.Check(func() { lru.NewTwoQueueWithRatios(10, 0.5, -1) }, gc.PanicMatches, "ghostRatio must be .*")
+}
@@ -0,0 +1,354 @@
+// Copyright 2026 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package lru
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+const (
+	// walMagic starts the log of a WALCache, followed by a byte giving the
+	// version of the format. Each record is its length as a uvarint, the
+	// record, and (since version 2) a CRC-32C checksum of both, as a little
+	// endian uint32.
+	walMagic   = "LRW\x00"
+	walVersion = 2
+
+	walSnapshot = "snapshot"
+	walLog      = "log"
+
+	// DefaultCheckpointEvery is how many records a WALCache appends to its
+	// log before taking a checkpoint, unless WALConfig says otherwise.
+	DefaultCheckpointEvery = 10000
+)
+
+// walTable is used for the checksums of records in the log.
+var walTable = crc32.MakeTable(crc32.Castagnoli)
+
+// Operations recorded in the log of a WALCache.
+const (
+	walAdd byte = iota + 1
+	walRemove
+)
+
+// WALConfig configures a WALCache.
+type WALConfig struct {
+	// Dir is the directory holding the snapshot and log, which is created
+	// if needed.
+	Dir string
+
+	// CheckpointEvery is how many records are appended to the log before a
+	// checkpoint is taken, which defaults to DefaultCheckpointEvery. If it
+	// is negative, checkpoints are only taken when Checkpoint is called.
+	CheckpointEvery int
+
+	// SyncWrites makes Add and Remove sync the log to disk before they
+	// return, so that no change is lost even if the machine crashes, not
+	// just the process.
+	SyncWrites bool
+}
+
+// WALCache is an LRU cache whose changes are written to a log on disk, so
+// that after a crash or restart the entries can be recovered by opening it
+// again, rather than built again. Every Add and Remove appends a record to
+// the log before the cache is changed. A checkpoint writes all the entries to
+// a snapshot (as WriteTo does), and empties the log, so that it doesn't grow
+// without limit. When the cache is opened, the snapshot is loaded, and then
+// the log is replayed. A record left half written by a crash, which is
+// detected by its checksum, is dropped; any other damage to the log is an
+// error.
+// Only Add and Remove are logged, not Get, so the recency order recovered,
+// and which entries were evicted in the meantime, may differ from before.
+// Keys and values are written as WriteTo writes them, so other types need to
+// be registered with RegisterBinary or gob.Register.
+// Note that WALCache is *not* thread safe, some form of mutex is necessary if
+// you want to access it from multiple threads.
+type WALCache struct {
+	lru     *LRU
+	config  WALConfig
+	log     *os.File
+	records int
+}
+
+// OpenWALCache opens the WALCache in config.Dir, recovering its entries if it
+// has been used before, and creating it if not. The cache holds up to size
+// entries, and is configured by any options given. Close must be called when
+// it is no longer used.
+func OpenWALCache(config WALConfig, size int, options ...Option) (*WALCache, error) {
+	if config.CheckpointEvery == 0 {
+		config.CheckpointEvery = DefaultCheckpointEvery
+	}
+	if err := os.MkdirAll(config.Dir, 0o700); err != nil {
+		return nil, err
+	}
+	c := &WALCache{
+		lru:    New(size, options...),
+		config: config,
+	}
+	snapshot, err := os.Open(filepath.Join(config.Dir, walSnapshot))
+	if err == nil {
+		_, err = c.lru.ReadFrom(snapshot)
+		snapshot.Close()
+		if err != nil {
+			return nil, fmt.Errorf("reading snapshot: %w", err)
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+	c.log, err = os.OpenFile(filepath.Join(config.Dir, walLog), os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	version, err := c.replay()
+	if err != nil {
+		c.log.Close()
+		return nil, fmt.Errorf("replaying log: %w", err)
+	}
+	if version != walVersion {
+		// Records are only appended in the current format, so an older log
+		// has to be replaced before anything is added to it.
+		if err := c.Checkpoint(); err != nil {
+			c.log.Close()
+			return nil, fmt.Errorf("upgrading log: %w", err)
+		}
+	}
+	return c, nil
+}
+
+// replay applies the records in the log to the cache, and leaves the log
+// ready to append to. The last record is removed if it was cut short, or its
+// checksum doesn't match, as happens when a write is interrupted. A record
+// that was written completely but can't be applied is an error, as are
+// records after it, so that nothing that was logged is quietly dropped. It
+// returns the version of the log's format.
+func (c *WALCache) replay() (byte, error) {
+	sr := newStreamReader(c.log)
+	header := make([]byte, len(walMagic)+1)
+	if _, err := io.ReadFull(sr, header); err == io.EOF || err == io.ErrUnexpectedEOF {
+		// A new log, or one whose header was never completely written.
+		return walVersion, c.resetLog()
+	} else if err != nil {
+		return 0, badStream(err)
+	}
+	if string(header[:len(walMagic)]) != walMagic {
+		return 0, fmt.Errorf("%w: bad magic", ErrBadStream)
+	}
+	version := header[len(walMagic)]
+	if version != 1 && version != walVersion {
+		return 0, fmt.Errorf("%w: unknown version %d", ErrBadStream, version)
+	}
+	// Version 1 has no checksums.
+	checksummed := version >= 2
+	var record []byte
+	for {
+		good := sr.n
+		length, err := binary.ReadUvarint(sr)
+		if err == io.EOF {
+			break
+		}
+		if err == nil && (length == 0 || length > maxStreamRecord) {
+			return 0, fmt.Errorf("%w: bad length %d for record at offset %d", ErrBadStream, length, good)
+		}
+		if err == nil {
+			record = make([]byte, length)
+			_, err = io.ReadFull(sr, record)
+		}
+		var sum [4]byte
+		if err == nil && checksummed {
+			_, err = io.ReadFull(sr, sum[:])
+		}
+		if err == io.ErrUnexpectedEOF || err == io.EOF {
+			// The last record was not written completely.
+			return version, c.truncateLog(good)
+		} else if err != nil {
+			return 0, err
+		}
+		if checksummed && binary.LittleEndian.Uint32(sum[:]) != walChecksum(record) {
+			if _, err := sr.ReadByte(); err == io.EOF {
+				return version, c.truncateLog(good)
+			}
+			return 0, fmt.Errorf("%w: bad checksum for record at offset %d", ErrBadStream, good)
+		}
+		if err := c.apply(record); err != nil {
+			return 0, fmt.Errorf("record at offset %d: %w", good, err)
+		}
+		c.records++
+	}
+	_, err := c.log.Seek(0, io.SeekEnd)
+	return version, err
+}
+
+// truncateLog cuts the log short at offset, and leaves it ready to append to.
+func (c *WALCache) truncateLog(offset int64) error {
+	if err := c.log.Truncate(offset); err != nil {
+		return err
+	}
+	_, err := c.log.Seek(offset, io.SeekStart)
+	return err
+}
+
+// walChecksum returns the checksum of record, and of its length, as written
+// to the log.
+func walChecksum(record []byte) uint32 {
+	sum := crc32.Update(0, walTable, appendUvarint(nil, uint64(len(record))))
+	return crc32.Update(sum, walTable, record)
+}
+
+// apply makes the change recorded in record to the cache.
+func (c *WALCache) apply(record []byte) error {
+	switch record[0] {
+	case walAdd:
+		key, value, err := readEntry(record[1:])
+		if err != nil {
+			return err
+		}
+		c.lru.Add(key, value)
+	case walRemove:
+		key, rest, err := readValue(record[1:])
+		if err != nil {
+			return err
+		}
+		if len(rest) != 0 {
+			return fmt.Errorf("%w: %d bytes left over after entry", ErrBadStream, len(rest))
+		}
+		c.lru.remove(key)
+	default:
+		return fmt.Errorf("%w: unknown operation %d", ErrBadStream, record[0])
+	}
+	return nil
+}
+
+// Len returns the number of entries in the cache.
+func (c *WALCache) Len() int {
+	return c.lru.Len()
+}
+
+// Add adds an entry to the cache, or updates the value of an existing one,
+// once it has been written to the log. If it can't be, the cache is not
+// changed.
+func (c *WALCache) Add(key, value interface{}) error {
+	record, err := appendEntry([]byte{walAdd}, key, value)
+	if err != nil {
+		return err
+	}
+	if err := c.append(record); err != nil {
+		return err
+	}
+	c.lru.Add(key, value)
+	return c.maybeCheckpoint()
+}
+
+// Get returns the value for key, and whether it is in the cache. If it is, it
+// is treated as recently used.
+func (c *WALCache) Get(key interface{}) (interface{}, bool) {
+	return c.lru.Get(key)
+}
+
+// Peek is just like Get, except it doesn't count as a use.
+func (c *WALCache) Peek(key interface{}) (interface{}, bool) {
+	return c.lru.Peek(key)
+}
+
+// Remove removes the entry for key, once its removal has been written to the
+// log, and returns whether there was one.
+func (c *WALCache) Remove(key interface{}) (bool, error) {
+	if _, ok := c.lru.elements[c.lru.identity(key)]; !ok {
+		return false, nil
+	}
+	record, err := appendValue([]byte{walRemove}, key)
+	if err != nil {
+		return false, err
+	}
+	if err := c.append(record); err != nil {
+		return false, err
+	}
+	c.lru.remove(key)
+	return true, c.maybeCheckpoint()
+}
+
+// append writes record to the log, after its length, and followed by its
+// checksum.
+func (c *WALCache) append(record []byte) error {
+	buf := appendUvarint(make([]byte, 0, len(record)+binary.MaxVarintLen64+4), uint64(len(record)))
+	buf = append(buf, record...)
+	var sum [4]byte
+	binary.LittleEndian.PutUint32(sum[:], walChecksum(record))
+	if _, err := c.log.Write(append(buf, sum[:]...)); err != nil {
+		return err
+	}
+	c.records++
+	if c.config.SyncWrites {
+		return c.log.Sync()
+	}
+	return nil
+}
+
+// maybeCheckpoint takes a checkpoint if enough records have been logged.
+func (c *WALCache) maybeCheckpoint() error {
+	if c.config.CheckpointEvery > 0 && c.records >= c.config.CheckpointEvery {
+		return c.Checkpoint()
+	}
+	return nil
+}
+
+// Checkpoint writes all the entries to the snapshot, and empties the log.
+func (c *WALCache) Checkpoint() error {
+	tmp, err := os.CreateTemp(c.config.Dir, walSnapshot+"-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	w := bufio.NewWriter(tmp)
+	_, err = c.lru.WriteTo(w)
+	if err == nil {
+		err = w.Flush()
+	}
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(c.config.Dir, walSnapshot)); err != nil {
+		return err
+	}
+	// If we crash before the log is emptied, it is replayed on top of the
+	// new snapshot when the cache is next opened. Each key in it still ends
+	// up with the last value logged for it, but if the cache is full, the
+	// Adds being applied again change the recency order, and may evict
+	// entries that the snapshot kept.
+	return c.resetLog()
+}
+
+// resetLog empties the log, leaving just its header.
+func (c *WALCache) resetLog() error {
+	if err := c.log.Truncate(0); err != nil {
+		return err
+	}
+	if _, err := c.log.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	if _, err := c.log.Write(append([]byte(walMagic), walVersion)); err != nil {
+		return err
+	}
+	c.records = 0
+	if c.config.SyncWrites {
+		return c.log.Sync()
+	}
+	return nil
+}
+
+// Close closes the log. The cache must not be used after it is closed.
+func (c *WALCache) Close() error {
+	return c.log.Close()
+}
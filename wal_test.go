@@ -0,0 +1,241 @@
+// Copyright 2026 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package lru_test
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+
+	gc "gopkg.in/check.v1"
+
+	"github.com/juju/lru"
+)
+
+type WALSuite struct {
+	config lru.WALConfig
+}
+
+var _ = gc.Suite(&WALSuite{})
+
+func (s *WALSuite) SetUpTest(c *gc.C) {
+	s.config = lru.WALConfig{Dir: c.MkDir()}
+}
+
+func (s *WALSuite) open(c *gc.C, size int) *lru.WALCache {
+	cache, err := lru.OpenWALCache(s.config, size)
+	c.Assert(err, gc.IsNil)
+	return cache
+}
+
+func checkWALGet(c *gc.C, cache *lru.WALCache, key, value interface{}) {
+	got, ok := cache.Peek(key)
+	c.Check(ok, gc.Equals, true, gc.Commentf("key %v", key))
+	c.Check(got, gc.Equals, value)
+}
+
+func (s *WALSuite) TestRecover(c *gc.C) {
+	cache := s.open(c, 10)
+	c.Assert(cache.Add("a", 1), gc.IsNil)
+	c.Assert(cache.Add("b", 2), gc.IsNil)
+	c.Assert(cache.Add("a", 3), gc.IsNil)
+	removed, err := cache.Remove("b")
+	c.Assert(err, gc.IsNil)
+	c.Check(removed, gc.Equals, true)
+	removed, err = cache.Remove("b")
+	c.Assert(err, gc.IsNil)
+	c.Check(removed, gc.Equals, false)
+	c.Assert(cache.Add("c", 4), gc.IsNil)
+	// Not closed, as if the process crashed.
+
+	cache = s.open(c, 10)
+	defer cache.Close()
+	c.Check(cache.Len(), gc.Equals, 2)
+	checkWALGet(c, cache, "a", 3)
+	checkWALGet(c, cache, "c", 4)
+	_, ok := cache.Get("b")
+	c.Check(ok, gc.Equals, false)
+}
+
+func (s *WALSuite) TestCheckpoint(c *gc.C) {
+	s.config.CheckpointEvery = 3
+	cache := s.open(c, 10)
+	for i := 0; i < 4; i++ {
+		c.Assert(cache.Add(i, i), gc.IsNil)
+	}
+	info, err := os.Stat(filepath.Join(s.config.Dir, "snapshot"))
+	c.Assert(err, gc.IsNil)
+	c.Check(info.Size() > 0, gc.Equals, true)
+	// The log only holds what was added since the checkpoint.
+	log, err := os.ReadFile(filepath.Join(s.config.Dir, "log"))
+	c.Assert(err, gc.IsNil)
+	c.Check(string(log), gc.Equals, "LRW\x00\x02\x05\x01\x04\x06\x04\x06\x98\x0eF\xf0")
+	c.Assert(cache.Close(), gc.IsNil)
+
+	cache = s.open(c, 10)
+	defer cache.Close()
+	c.Check(cache.Len(), gc.Equals, 4)
+	for i := 0; i < 4; i++ {
+		checkWALGet(c, cache, i, i)
+	}
+}
+
+func (s *WALSuite) TestExplicitCheckpoint(c *gc.C) {
+	s.config.CheckpointEvery = -1
+	cache := s.open(c, 2)
+	c.Assert(cache.Add("a", 1), gc.IsNil)
+	c.Assert(cache.Add("b", 2), gc.IsNil)
+	c.Assert(cache.Add("c", 3), gc.IsNil)
+	c.Assert(cache.Checkpoint(), gc.IsNil)
+	c.Assert(cache.Close(), gc.IsNil)
+
+	cache = s.open(c, 2)
+	defer cache.Close()
+	c.Check(cache.Len(), gc.Equals, 2)
+	checkWALGet(c, cache, "b", 2)
+	checkWALGet(c, cache, "c", 3)
+}
+
+func (s *WALSuite) TestTornRecord(c *gc.C) {
+	cache := s.open(c, 10)
+	c.Assert(cache.Add("a", 1), gc.IsNil)
+	c.Assert(cache.Add("b", 2), gc.IsNil)
+	c.Assert(cache.Close(), gc.IsNil)
+	path := filepath.Join(s.config.Dir, "log")
+	log, err := os.ReadFile(path)
+	c.Assert(err, gc.IsNil)
+	c.Assert(os.WriteFile(path, log[:len(log)-2], 0o600), gc.IsNil)
+
+	cache = s.open(c, 10)
+	c.Check(cache.Len(), gc.Equals, 1)
+	checkWALGet(c, cache, "a", 1)
+	// The torn record is gone, so new records follow on from a.
+	c.Assert(cache.Add("c", 3), gc.IsNil)
+	c.Assert(cache.Close(), gc.IsNil)
+	cache = s.open(c, 10)
+	defer cache.Close()
+	c.Check(cache.Len(), gc.Equals, 2)
+	checkWALGet(c, cache, "c", 3)
+}
+
+func (s *WALSuite) TestTornChecksum(c *gc.C) {
+	cache := s.open(c, 10)
+	c.Assert(cache.Add("a", 1), gc.IsNil)
+	c.Assert(cache.Add("b", 2), gc.IsNil)
+	c.Assert(cache.Close(), gc.IsNil)
+	// The last record is all there, but some of it was never written.
+	path := filepath.Join(s.config.Dir, "log")
+	log, err := os.ReadFile(path)
+	c.Assert(err, gc.IsNil)
+	log[len(log)-6] ^= 0xff
+	c.Assert(os.WriteFile(path, log, 0o600), gc.IsNil)
+
+	cache = s.open(c, 10)
+	defer cache.Close()
+	c.Check(cache.Len(), gc.Equals, 1)
+	checkWALGet(c, cache, "a", 1)
+}
+
+func (s *WALSuite) TestCorruptRecord(c *gc.C) {
+	cache := s.open(c, 10)
+	c.Assert(cache.Add("a", 1), gc.IsNil)
+	c.Assert(cache.Add("b", 2), gc.IsNil)
+	c.Assert(cache.Close(), gc.IsNil)
+	path := filepath.Join(s.config.Dir, "log")
+	log, err := os.ReadFile(path)
+	c.Assert(err, gc.IsNil)
+	log[len("LRW\x00\x02")+3] ^= 0xff
+	c.Assert(os.WriteFile(path, log, 0o600), gc.IsNil)
+
+	// The record after it shows the damage isn't from a crash.
+	_, err = lru.OpenWALCache(s.config, 10)
+	c.Check(err, gc.ErrorMatches, "replaying log: lru: invalid stream: bad checksum for record at offset 5")
+	after, err := os.ReadFile(path)
+	c.Assert(err, gc.IsNil)
+	c.Check(after, gc.DeepEquals, log)
+}
+
+// walBlob is a value whose UnmarshalBinary fails for some data, as though it
+// had been written by a newer version of the program.
+type walBlob string
+
+func (b walBlob) MarshalBinary() ([]byte, error) {
+	return []byte(b), nil
+}
+
+func (b *walBlob) UnmarshalBinary(data []byte) error {
+	if string(data) == "bad" {
+		return io.ErrUnexpectedEOF
+	}
+	*b = walBlob(data)
+	return nil
+}
+
+func init() {
+	lru.RegisterBinary(walBlob(""))
+}
+
+func (s *WALSuite) TestUndecodableRecord(c *gc.C) {
+	cache := s.open(c, 10)
+	c.Assert(cache.Add("a", walBlob("good")), gc.IsNil)
+	c.Assert(cache.Add("b", walBlob("bad")), gc.IsNil)
+	c.Assert(cache.Add("c", walBlob("good")), gc.IsNil)
+	c.Assert(cache.Close(), gc.IsNil)
+	path := filepath.Join(s.config.Dir, "log")
+	log, err := os.ReadFile(path)
+	c.Assert(err, gc.IsNil)
+
+	// The records are complete, so they must not be dropped.
+	_, err = lru.OpenWALCache(s.config, 10)
+	c.Check(err, gc.ErrorMatches, "replaying log: record at offset [0-9]+: .*unexpected EOF")
+	after, err := os.ReadFile(path)
+	c.Assert(err, gc.IsNil)
+	c.Check(after, gc.DeepEquals, log)
+}
+
+func (s *WALSuite) TestReplayVersion1(c *gc.C) {
+	// Logs written before records had checksums can still be read.
+	path := filepath.Join(s.config.Dir, "log")
+	c.Assert(os.WriteFile(path, []byte("LRW\x00\x01\x06\x01\x01\x01a\x04\x02\x06\x01\x01\x01b"), 0o600), gc.IsNil)
+	cache := s.open(c, 10)
+	c.Check(cache.Len(), gc.Equals, 1)
+	checkWALGet(c, cache, "a", 1)
+	// It is upgraded, so what is added now can be read back.
+	c.Assert(cache.Add("c", 3), gc.IsNil)
+	c.Assert(cache.Add("b", 2), gc.IsNil)
+	c.Assert(cache.Close(), gc.IsNil)
+	log, err := os.ReadFile(path)
+	c.Assert(err, gc.IsNil)
+	c.Check(string(log[:5]), gc.Equals, "LRW\x00\x02")
+
+	cache = s.open(c, 10)
+	defer cache.Close()
+	c.Check(cache.Len(), gc.Equals, 3)
+	checkWALGet(c, cache, "a", 1)
+	checkWALGet(c, cache, "b", 2)
+	checkWALGet(c, cache, "c", 3)
+}
+
+func (s *WALSuite) TestBadLog(c *gc.C) {
+	c.Assert(os.WriteFile(filepath.Join(s.config.Dir, "log"), []byte("not a log"), 0o600), gc.IsNil)
+	_, err := lru.OpenWALCache(s.config, 10)
+	c.Check(err, gc.ErrorMatches, "replaying log: lru: invalid stream: bad magic")
+}
+
+func (s *WALSuite) TestAddUnencodable(c *gc.C) {
+	cache := s.open(c, 10)
+	defer cache.Close()
+	c.Check(cache.Add("a", persistValue{}), gc.ErrorMatches, "writing value for a: .*")
+	c.Check(cache.Len(), gc.Equals, 0)
+}
+
+func (s *WALSuite) TestSyncWrites(c *gc.C) {
+	s.config.SyncWrites = true
+	cache := s.open(c, 10)
+	c.Assert(cache.Add("a", 1), gc.IsNil)
+	c.Assert(cache.Close(), gc.IsNil)
+	cache = s.open(c, 10)
+	defer cache.Close()
+	checkWALGet(c, cache, "a", 1)
+}
@@ -0,0 +1,88 @@
+// Copyright 2026 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package lru
+
+// WatchKind says what happened to a watched key.
+type WatchKind int
+
+const (
+	// WatchAdded is sent when the key is added to the cache.
+	WatchAdded WatchKind = iota
+	// WatchUpdated is sent when the value of the key is replaced.
+	WatchUpdated
+	// WatchEvicted is sent when the key leaves the cache, for the reason
+	// given in the event.
+	WatchEvicted
+)
+
+// WatchEvent describes a change to a watched key.
+type WatchEvent struct {
+	Kind WatchKind
+	Key  interface{}
+	// Value is the new value for WatchAdded and WatchUpdated, and the value
+	// that left the cache for WatchEvicted.
+	Value interface{}
+	// Reason is why the key left the cache, for WatchEvicted.
+	Reason EvictionReason
+}
+
+// watchBuffer is how many events a watch channel holds before the oldest are
+// dropped.
+const watchBuffer = 8
+
+// Watch returns a channel that is sent an event whenever key is added to the
+// cache, its value is replaced, or it leaves the cache, and a function that
+// stops the watch and closes the channel. Events are never blocked on: if
+// the channel already holds 8 events that haven't been received, the oldest
+// is dropped to make room, so a slow receiver still sees the latest events.
+func (lru *LRU) Watch(key interface{}) (<-chan WatchEvent, func()) {
+	id := lru.identity(key)
+	ch := make(chan WatchEvent, watchBuffer)
+	if lru.watchers == nil {
+		lru.watchers = make(map[interface{}][]chan WatchEvent)
+	}
+	lru.watchers[id] = append(lru.watchers[id], ch)
+	stopped := false
+	return ch, func() {
+		if stopped {
+			return
+		}
+		stopped = true
+		chans := lru.watchers[id]
+		for i, watcher := range chans {
+			if watcher == ch {
+				chans = append(chans[:i], chans[i+1:]...)
+				break
+			}
+		}
+		if len(chans) == 0 {
+			delete(lru.watchers, id)
+		} else {
+			lru.watchers[id] = chans
+		}
+		close(ch)
+	}
+}
+
+// notifyWatchers sends event to everything watching its key.
+func (lru *LRU) notifyWatchers(event WatchEvent) {
+	if len(lru.watchers) == 0 {
+		return
+	}
+	for _, ch := range lru.watchers[mapKey(event.Key)] {
+		for sent := false; !sent; {
+			select {
+			case ch <- event:
+				sent = true
+			default:
+				// Drop the oldest event, unless the receiver has just
+				// taken it.
+				select {
+				case <-ch:
+				default:
+				}
+			}
+		}
+	}
+}
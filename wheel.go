@@ -0,0 +1,264 @@
+// Copyright 2026 Canonical Ltd.
+// Licensed under the LGPLv3, see LICENCE file for details.
+
+package lru
+
+const (
+	wheelBits   = 6
+	wheelSlots  = 1 << wheelBits
+	wheelMask   = wheelSlots - 1
+	wheelLevels = 4
+
+	// overflowSlot holds entries that expire too far in the future for the
+	// top level of the wheel.
+	overflowSlot = wheelLevels * wheelSlots
+	// processingSlot holds the entries of a slot that is being expired.
+	processingSlot = overflowSlot + 1
+
+	// ticksPerTimeout is how finely the shortest timeout is divided into
+	// ticks. The entries in the current tick's slot are looked at on every
+	// advance, so ticks need to be short enough that few entries share one.
+	ticksPerTimeout = 1 << 12
+)
+
+// timingWheel indexes entries by when they expire, so that RemoveExpired only
+// needs to look at entries that are due, rather than every entry in the
+// cache.
+// Time is divided into ticks. Level 0 of the wheel has a slot for each of the
+// next 64 ticks, level 1 has a slot for each of the following 64 spans of 64
+// ticks, and so on. As time passes, the entries in a higher level slot are
+// cascaded down into the level below when its span is reached, so an entry is
+// only moved a handful of times before it expires.
+// The slots are doubly linked lists threaded through entryMeta, so the wheel
+// needs no memory of its own per entry.
+// Entries are not moved when their deadline is extended (such as by being
+// accessed with an idle timeout). Instead, when their slot comes up and they
+// are found not to be due yet, they are rescheduled. Only when a deadline is
+// brought forward does the entry need to be moved straight away.
+type timingWheel struct {
+	// tick is the length of a tick, in nanoseconds.
+	tick int64
+	// current is the tick that has most recently been processed. Its level
+	// 0 slot is processed again on every advance, as it can hold entries
+	// that are due later in the tick.
+	current int64
+	// slots holds the first element of each slot's list, or 0 if it is
+	// empty.
+	slots [processingSlot + 1]uint32
+	// counts holds the number of entries in each level, with the overflow
+	// list counted as an extra level.
+	counts [wheelLevels + 1]int
+}
+
+// newTimingWheel creates a timing wheel whose ticks are a fraction of the
+// shortest timeout, starting at now.
+func newTimingWheel(timeout, now int64) *timingWheel {
+	tick := timeout / ticksPerTimeout
+	if tick < 1 {
+		tick = 1
+	}
+	return &timingWheel{
+		tick:    tick,
+		current: now / tick,
+	}
+}
+
+// slotFor returns the slot in which to put an entry that is due in the given
+// tick. It is put in the lowest level where it shares its slot in the level
+// above with the current tick, so that it will have been cascaded down to
+// level 0 by the time it is due.
+func (w *timingWheel) slotFor(due int64) int {
+	if due < w.current {
+		due = w.current
+	}
+	for level := 0; level < wheelLevels; level++ {
+		shift := uint(wheelBits * (level + 1))
+		if due>>shift == w.current>>shift {
+			return level*wheelSlots + int(due>>(shift-wheelBits)&wheelMask)
+		}
+	}
+	return overflowSlot
+}
+
+// count returns the counter for slot, or nil if the slot is not counted.
+func (w *timingWheel) count(slot int) *int {
+	if slot == processingSlot {
+		return nil
+	}
+	return &w.counts[slot/wheelSlots]
+}
+
+// schedule adds elem to the wheel, if it has a deadline. It is put in the
+// slot for when it can be removed, rather than when it expires, so that stale
+// entries are kept for WithStaleWhileRevalidate.
+func (lru *LRU) schedule(elem uint32) {
+	if lru.wheel == nil {
+		return
+	}
+	removeAt := lru.removeAt(elem)
+	if removeAt == 0 {
+		return
+	}
+	lru.link(elem, lru.wheel.slotFor(removeAt/lru.wheel.tick))
+}
+
+// deadlineChanged updates the wheel after the deadline of elem has changed
+// from old.
+func (lru *LRU) deadlineChanged(elem uint32, old int64) {
+	if lru.wheel == nil {
+		return
+	}
+	deadline := lru.deadline(elem)
+	if lru.meta[elem].wheelSlot == 0 || (deadline != 0 && deadline < old) {
+		lru.unschedule(elem)
+		lru.schedule(elem)
+	}
+}
+
+// link adds elem to the front of the list for slot.
+func (lru *LRU) link(elem uint32, slot int) {
+	w := lru.wheel
+	meta := &lru.meta[elem]
+	meta.wheelSlot = uint32(slot + 1)
+	meta.wheelPrev = 0
+	meta.wheelNext = w.slots[slot]
+	if meta.wheelNext != 0 {
+		lru.meta[meta.wheelNext].wheelPrev = elem
+	}
+	w.slots[slot] = elem
+	if count := w.count(slot); count != nil {
+		*count++
+	}
+}
+
+// unschedule removes elem from the wheel, if it is in it.
+func (lru *LRU) unschedule(elem uint32) {
+	if lru.wheel == nil || lru.meta[elem].wheelSlot == 0 {
+		return
+	}
+	w := lru.wheel
+	meta := &lru.meta[elem]
+	slot := int(meta.wheelSlot - 1)
+	if meta.wheelPrev != 0 {
+		lru.meta[meta.wheelPrev].wheelNext = meta.wheelNext
+	} else {
+		w.slots[slot] = meta.wheelNext
+	}
+	if meta.wheelNext != 0 {
+		lru.meta[meta.wheelNext].wheelPrev = meta.wheelPrev
+	}
+	meta.wheelSlot, meta.wheelPrev, meta.wheelNext = 0, 0, 0
+	if count := w.count(slot); count != nil {
+		*count--
+	}
+}
+
+// wheelMoved fixes up the wheel after removeElem has moved the metadata of
+// another element into elem.
+func (lru *LRU) wheelMoved(elem uint32) {
+	if lru.wheel == nil || lru.meta[elem].wheelSlot == 0 {
+		return
+	}
+	meta := &lru.meta[elem]
+	if meta.wheelPrev != 0 {
+		lru.meta[meta.wheelPrev].wheelNext = elem
+	} else {
+		lru.wheel.slots[meta.wheelSlot-1] = elem
+	}
+	if meta.wheelNext != 0 {
+		lru.meta[meta.wheelNext].wheelPrev = elem
+	}
+}
+
+// advanceWheel processes every tick up to now, removing the entries that
+// have expired, and returns how many were removed.
+func (lru *LRU) advanceWheel(now int64) int {
+	w := lru.wheel
+	target := now / w.tick
+	removed := 0
+	for {
+		removed += lru.expireSlot(int(w.current&wheelMask), now)
+		if w.current >= target {
+			return removed
+		}
+		// Nothing happens until the lowest level with any entries in it
+		// next cascades, so we can skip straight there.
+		next := target
+		for level := 0; level <= wheelLevels; level++ {
+			if w.counts[level] == 0 {
+				continue
+			}
+			if level == 0 {
+				next = w.current + 1
+			} else {
+				span := int64(1) << uint(wheelBits*level)
+				next = (w.current/span + 1) * span
+			}
+			break
+		}
+		if next > target {
+			next = target
+		}
+		w.current = next
+		lru.cascade()
+	}
+}
+
+// cascade moves entries down from the higher levels whose span has been
+// reached by the current tick.
+func (lru *LRU) cascade() {
+	w := lru.wheel
+	for level := wheelLevels; level > 0; level-- {
+		shift := uint(wheelBits * level)
+		if w.current&(1<<shift-1) != 0 {
+			continue
+		}
+		slot := overflowSlot
+		if level < wheelLevels {
+			slot = level*wheelSlots + int(w.current>>shift&wheelMask)
+		}
+		// Entries in the overflow list may go straight back into it.
+		lru.takeSlot(slot)
+		for w.slots[processingSlot] != 0 {
+			elem := w.slots[processingSlot]
+			lru.unschedule(elem)
+			lru.schedule(elem)
+		}
+	}
+}
+
+// takeSlot moves the entries in slot into the processing list. They need to
+// be in a real list rather than just detached, as removeElem may move them
+// around.
+func (lru *LRU) takeSlot(slot int) {
+	w := lru.wheel
+	for w.slots[slot] != 0 {
+		elem := w.slots[slot]
+		lru.unschedule(elem)
+		lru.link(elem, processingSlot)
+	}
+}
+
+// expireSlot removes the entries in slot that can be removed at now, and
+// reschedules the rest.
+func (lru *LRU) expireSlot(slot int, now int64) int {
+	w := lru.wheel
+	if w.slots[slot] == 0 {
+		return 0
+	}
+	// Entries that aren't due yet may be rescheduled into the same slot, so
+	// we move them all out of the way first.
+	lru.takeSlot(slot)
+	removed := 0
+	for w.slots[processingSlot] != 0 {
+		elem := w.slots[processingSlot]
+		lru.unschedule(elem)
+		if removeAt := lru.removeAt(elem); removeAt != 0 && now >= removeAt {
+			lru.removeElem(elem, Expired)
+			removed++
+		} else {
+			lru.schedule(elem)
+		}
+	}
+	return removed
+}